@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/httpd"
+	"github.com/lodastack/agent/agent/sysinfo"
+)
+
+// output formats of Once
+const (
+	FormatJSON       = "json"
+	FormatPrometheus = "prometheus"
+)
+
+// gap between the two cycles of Once
+const onceGap = 2 * time.Second
+
+// Once runs two cycles of every collect type onceGap apart and writes the
+// metrics of the second to w: cpu usage and every rate need two samples
+// and report nothing on a first cycle. Neither the outputs nor httpd are
+// started, so it runs without any push config.
+func Once(c *common.AgentConfig, w io.Writer, format string) error {
+	common.InitCollectConfig(c)
+	types := sysinfo.Types()
+	collectTypes(types)
+	time.Sleep(onceGap)
+	// the background update only samples cpu and disk stats every 10s,
+	// failures are logged by the updates
+	sysinfo.UpdateCpuStat()
+	sysinfo.UpdateDiskStats()
+	return writeMetrics(w, collectTypes(types), format)
+}
+
+// collectTypes runs one cycle of the collect types in parallel
+func collectTypes(types []string) []*common.Metric {
+	results := make([][]*common.Metric, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Add(1)
+		go func(i int, t string) {
+			defer wg.Done()
+			results[i] = sysinfo.Collector{Name: t, Cycle: onceInterval(t)}.Collect()
+		}(i, t)
+	}
+	wg.Wait()
+
+	var metrics []*common.Metric
+	for _, L := range results {
+		metrics = append(metrics, L...)
+	}
+	return metrics
+}
+
+// onceInterval is the cycle of a collect type, used for its deadlines
+func onceInterval(t string) int {
+	if common.Conf != nil && common.Conf.GroupIntervals[t] > 0 {
+		return common.Conf.GroupIntervals[t]
+	}
+	return common.DEFAULT_INTERVAL[t]
+}
+
+func writeMetrics(w io.Writer, metrics []*common.Metric, format string) error {
+	switch format {
+	case "", FormatJSON:
+		data, err := common.MarshalBatch(metrics)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case FormatPrometheus:
+		httpd.WritePrometheus(w, metrics)
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, use %s or %s", format, FormatJSON, FormatPrometheus)
+}
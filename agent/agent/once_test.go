@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_Once(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+
+	buf := new(bytes.Buffer)
+	// no output config at all
+	if err := Once(&common.AgentConfig{}, buf, FormatJSON); err != nil {
+		t.Fatalf("once fatal: %s", err)
+	}
+	metrics, err := common.UnmarshalBatch(buf.Bytes())
+	if err != nil {
+		t.Fatalf("decode once output fatal: %s", err)
+	}
+	if len(metrics) == 0 {
+		t.Fatalf("once wrote no metrics")
+	}
+	// rates need the second cycle
+	found := false
+	for _, m := range metrics {
+		if m.Name == "kernel.irq.rate" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("once wrote no rates")
+	}
+}
+
+func Test_writeMetrics(t *testing.T) {
+	metrics := []*common.Metric{
+		{Name: "cpu.idle", Timestamp: 1500000000, Value: 98.5, Tags: map[string]string{"core": "0"}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeMetrics(buf, metrics, FormatJSON); err != nil {
+		t.Fatalf("write json fatal: %s", err)
+	}
+	if buf.String() != `[{"name":"cpu.idle","timestamp":1500000000,"value":98.5,"tags":{"core":"0"}}]`+"\n" {
+		t.Fatalf("json output fatal: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeMetrics(buf, metrics, FormatPrometheus); err != nil {
+		t.Fatalf("write prometheus fatal: %s", err)
+	}
+	if !strings.Contains(buf.String(), `cpu_idle{core="0"} 98.5`) {
+		t.Fatalf("prometheus output fatal: %s", buf.String())
+	}
+
+	if err := writeMetrics(buf, metrics, "xml"); err == nil {
+		t.Fatalf("unknown format should fail")
+	}
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"encoding/json"
+)
+
+// batchMetric is the wire format of a Metric in a batch, fields are
+// encoded in this order and empty tags are left out
+type batchMetric struct {
+	Name      string            `json:"name"`
+	Timestamp int64             `json:"timestamp"`
+	Value     interface{}       `json:"value"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Offset    int64             `json:"offset,omitempty"`
+}
+
+// MarshalBatch encodes metrics as a compact JSON array:
+//
+//	[{"name":"cpu.idle","timestamp":1500000000,"value":98.5,"tags":{"core":"0"}}]
+func MarshalBatch(metrics []*Metric) ([]byte, error) {
+	batch := make([]batchMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		batch = append(batch, batchMetric{Name: m.Name, Timestamp: m.Timestamp, Value: m.Value, Tags: m.Tags, Offset: m.Offset})
+	}
+	return json.Marshal(batch)
+}
+
+// UnmarshalBatch decodes the output of MarshalBatch, numeric values are
+// returned as float64
+func UnmarshalBatch(data []byte) ([]*Metric, error) {
+	var batch []batchMetric
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, err
+	}
+	metrics := make([]*Metric, 0, len(batch))
+	for _, b := range batch {
+		tags := b.Tags
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		metrics = append(metrics, &Metric{Name: b.Name, Timestamp: b.Timestamp, Value: b.Value, Tags: tags, Offset: b.Offset})
+	}
+	return metrics, nil
+}
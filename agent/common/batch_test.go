@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+)
+
+func Test_MarshalBatch(t *testing.T) {
+	metrics := []*Metric{
+		{Name: "cpu.idle", Timestamp: 1500000000, Value: SetPrecision(98.123456, 2), Tags: map[string]string{"core": "0"}},
+		{Name: "mem.used.percent", Timestamp: 1500000000, Value: SetPrecision(33.339, 2)},
+		nil,
+	}
+	data, err := MarshalBatch(metrics)
+	if err != nil {
+		t.Fatalf("marshal batch fatal: %s", err)
+	}
+	want := `[{"name":"cpu.idle","timestamp":1500000000,"value":98.12,"tags":{"core":"0"}},` +
+		`{"name":"mem.used.percent","timestamp":1500000000,"value":33.33}]`
+	if string(data) != want {
+		t.Fatalf("marshal batch fatal: %s", data)
+	}
+
+	res, err := UnmarshalBatch(data)
+	if err != nil || len(res) != 2 {
+		t.Fatalf("unmarshal batch fatal: %v - %d", err, len(res))
+	}
+	for i, m := range res {
+		if m.Name != metrics[i].Name || m.Timestamp != metrics[i].Timestamp || m.Value.(float64) != metrics[i].Value.(float64) {
+			t.Fatalf("batch round trip fatal: %s - %s", m.String(), metrics[i].String())
+		}
+	}
+	if res[0].Tags["core"] != "0" || res[1].Tags == nil {
+		t.Fatalf("batch round trip tags fatal: %v - %v", res[0].Tags, res[1].Tags)
+	}
+
+	if _, err := UnmarshalBatch([]byte("{")); err == nil {
+		t.Fatalf("unmarshal invalid batch should fail")
+	}
+}
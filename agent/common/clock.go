@@ -0,0 +1,29 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var monoStart = time.Now()
+
+func parseUptime(content string) (float64, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid uptime content: %q", content)
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// RateNow returns the time base (unit: second) delta-based rates divide by.
+// With monotonicrate enabled the Go monotonic clock is used, so a stepped
+// wall clock does not produce a rate spike. It is the only base then, mixing
+// in e.g. the kernel uptime would jump the deltas whenever a read failed.
+func RateNow() float64 {
+	if Conf != nil && Conf.MonotonicRate {
+		return time.Since(monoStart).Seconds()
+	}
+	return float64(time.Now().UnixNano()) / float64(time.Second)
+}
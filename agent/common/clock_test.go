@@ -0,0 +1,36 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseUptime(t *testing.T) {
+	up, err := parseUptime("350735.47 234388.90\n")
+	if err != nil {
+		t.Fatalf("parse uptime fatal: %s", err.Error())
+	}
+	if up != 350735.47 {
+		t.Fatalf("parse uptime fatal: %f", up)
+	}
+
+	if _, err := parseUptime(""); err == nil {
+		t.Fatalf("parse empty uptime should fail")
+	}
+}
+
+func Test_RateNow(t *testing.T) {
+	MustConfig()
+	Conf.MonotonicRate = true
+	defer func() { Conf.MonotonicRate = false }()
+
+	first := RateNow()
+	second := RateNow()
+	if second < first {
+		t.Fatalf("monotonic rate time went backwards: %f - %f", first, second)
+	}
+	// one base since start, not the kernel uptime
+	if since := time.Since(monoStart).Seconds(); second > since {
+		t.Fatalf("monotonic rate time not based on process start: %f - %f", second, since)
+	}
+}
@@ -1,5 +1,11 @@
 package common
 
+import (
+	"os/exec"
+
+	"github.com/lodastack/log"
+)
+
 type AgentConfig struct {
 	Listen       string   `toml:"listen"`
 	IfacePrefix  []string `toml:"ifaceprefix"`
@@ -7,6 +13,124 @@ type AgentConfig struct {
 	PluginsUser  string   `toml:"pluginsuser"`
 	RegistryAddr string   `toml:registryaddr"`
 	Git          string   `toml:"git"`
+
+	// use the monotonic clock instead of wall clock as the time base of rates
+	MonotonicRate bool `toml:"monotonicrate"`
+	// count open fds per filesystem type, walks every /proc/<pid>/fd
+	FdByFsType bool `toml:"fdbyfstype"`
+	// local ports whose connections are counted from the socket table
+	PortWatch []int `toml:"portwatch"`
+	// directory core files are counted in, default from kernel core_pattern
+	CoreDumpDir string `toml:"coredumpdir"`
+	// collect fan, voltage and temperature from `sensors -j`, needs lm-sensors
+	EnableSensors bool `toml:"enablesensors"`
+	// collect run queue latency from schedstat, needs CONFIG_SCHEDSTATS
+	EnableSchedstat bool `toml:"enableschedstat"`
+	// collect BMC sensors with `ipmitool sdr`, needs ipmitool and /dev/ipmi0
+	EnableIPMI bool `toml:"enableipmi"`
+	// rename metrics before sending, old name -> new name, "*" suffix wildcard
+	MetricRename map[string]string `toml:"metricrename"`
+	// prepended with a dot to every metric name after renaming, e.g. "prod1"
+	MetricPrefix string `toml:"metricprefix"`
+	// static tags added to every metric, the tags set by collectors win
+	GlobalTags map[string]string `toml:"globaltags"`
+	// container runtime sockets to check, default docker and containerd
+	ContainerSockets []string `toml:"containersockets"`
+	// docker engine socket to count containers from, default /var/run/docker.sock
+	DockerSocket string `toml:"dockersocket"`
+	// max number of failed systemd units reported by name, default 20
+	SystemdFailedMax int `toml:"systemdfailedmax"`
+	// systemd units whose active and failed state is reported
+	WatchedUnits []string `toml:"watchedunits"`
+	// run a collect function only every Nth cycle, keyed by function name
+	CollectorSampleRate map[string]int `toml:"collectorsamplerate"`
+	// run a collect function at most once per this many seconds, keyed by
+	// function name, the last result is reused in between
+	CollectorMinInterval map[string]int `toml:"collectormininterval"`
+	// report IPv6 addresses of the monitored interfaces too
+	IncludeIPv6 bool `toml:"includeipv6"`
+	// CIDR blocks treated as intranet, default RFC1918 and fc00::/7
+	IntranetCIDRs []string `toml:"intranetcidrs"`
+	// timeout of the `ps` command, unit: second, default 5
+	PsTimeout int `toml:"pstimeout"`
+	// ps binary, default ps from PATH
+	PsPath string `toml:"pspath"`
+	// arguments of the ps command, default ["axo", "state"]
+	PsArgs []string `toml:"psargs"`
+	// wtmp file of login records, default /var/log/wtmp
+	WtmpPath string `toml:"wtmppath"`
+	// report logins of the last N seconds, default 300
+	LoginWindow int `toml:"loginwindow"`
+	// collectors not to run, by name, e.g. ["PcapMetrics", "TimeMetrics"]
+	DisabledCollectors []string `toml:"disabledcollectors"`
+	// only report df metrics of these filesystem types
+	FsTypeWhitelist []string `toml:"fstypewhitelist"`
+	// never report df metrics of these filesystem types
+	FsTypeBlacklist []string `toml:"fstypeblacklist"`
+	// report every CPU mode per core too, not only the idle time
+	CpuPerCore bool `toml:"cpupercore"`
+	// number of processes reported by the top N process collectors, default 5
+	ProcMetricsTopN int `toml:"procmetricstopn"`
+	// count the processes whose command line matches a pattern
+	ProcMatchers []ProcMatcher `toml:"procmatchers"`
+	// do not report the processes of users with an uid below 1000
+	SkipSystemUsers bool `toml:"skipsystemusers"`
+	// host tag of every metric, default the system hostname
+	Hostname string `toml:"hostname"`
+	// interfaces ignored even if they match ifaceprefix, names or globs
+	IfaceExclude []string `toml:"ifaceexclude"`
+	// refresh interval of the cached interface addresses, unit: second, default 60
+	IPCacheTTL int `toml:"ipcachettl"`
+	// how the ip tag is picked: first, prefer-intranet or prefer-public, default prefer-intranet
+	IPStrategy string `toml:"ipstrategy"`
+	// NTP server the clock offset is measured against, host or host:port
+	NtpServer string `toml:"ntpserver"`
+	// max clock offset still reported as synced, unit: millisecond, default 100
+	NtpMaxOffset int `toml:"ntpmaxoffset"`
+	// hostnames resolved every cycle to measure DNS latency
+	DnsProbes []string `toml:"dnsprobes"`
+	// timeout of each DNS probe, unit: millisecond, default 2000
+	DnsTimeout int `toml:"dnstimeout"`
+	// path of the smartctl binary disk health is read with, default from PATH
+	SmartctlPath string `toml:"smartctlpath"`
+	// mount points that must exist, reported as df.mount.present
+	RequiredMounts []string `toml:"requiredmounts"`
+	// report zombies per parent process once more than this many exist, default 10
+	ZombieThreshold int `toml:"zombiethreshold"`
+	// flag the processes matching a pattern that run longer than maxage
+	MaxAgeMatchers []MaxAgeMatcher `toml:"maxagematchers"`
+	// /healthz fails if no collection cycle completed for this long, unit: second, default 300
+	HealthzWindow int `toml:"healthzwindow"`
+	// max metrics a collect type sends per cycle, the rest is dropped and
+	// counted in agent.dropped.count, 0 is no limit
+	MaxMetricsPerCycle int `toml:"maxmetricspercycle"`
+	// collectors of a collect type run in parallel on this many workers, default 4
+	CollectConcurrency int `toml:"collectconcurrency"`
+	// interval of every collect type, unit: second, keyed by type, e.g. CPU,
+	// overrides the registry and the defaults
+	GroupIntervals map[string]int `toml:"groupintervals"`
+	// decimals float values are cut to before they are sent, unset keeps
+	// the precision of the collectors
+	DefaultPrecision *int `toml:"defaultprecision"`
+	// decimals of single metrics, keyed by the name before renaming
+	MetricPrecision map[string]int `toml:"metricprecision"`
+	// files whose presence and age since the last modification are reported
+	WatchedFiles []string `toml:"watchedfiles"`
+	// count the new lines of a log file matching a pattern
+	LogWatchers []LogWatcher `toml:"logwatchers"`
+	// how tags and names are normalized before they are sent
+	SanitizeRules SanitizeRules `toml:"sanitize"`
+	// address of the admin API listing and toggling collectors, e.g.
+	// 127.0.0.1:8001, empty is off
+	AdminListen string `toml:"adminlisten"`
+	// read a collector this many times per interval and report the min, avg
+	// and max of every metric, keyed by collector name, e.g. CpuMetrics
+	SubSamples map[string]int `toml:"subsamples"`
+	// interrupts per second an IRQ must exceed on a CPU to be reported, default 10
+	IrqRateThreshold int `toml:"irqratethreshold"`
+	// a collector running longer than this is abandoned and reported as
+	// collector.timeout, unit: second, default half its collect interval
+	CollectorTimeout int `toml:"collectortimeout"`
 }
 
 var Conf *AgentConfig
@@ -16,5 +140,22 @@ func InitCollectConfig(config *AgentConfig) {
 	if config.PluginsUser == "" {
 		config.PluginsUser = "root"
 	}
+	if err := SetIntranetCIDRs(config.IntranetCIDRs); err != nil {
+		log.Errorf("invalid intranetcidrs, use the default ranges: %s", err)
+		SetIntranetCIDRs(nil)
+	}
+	config.ProcMatchers = compileProcMatchers(config.ProcMatchers)
+	config.MaxAgeMatchers = compileMaxAgeMatchers(config.MaxAgeMatchers)
+	config.LogWatchers = compileLogWatchers(config.LogWatchers)
+	if config.PsPath != "" {
+		if _, err := exec.LookPath(config.PsPath); err != nil {
+			log.Errorf("invalid pspath, use ps from PATH: %s", err)
+			config.PsPath = ""
+		}
+	}
+	if _, err := ipPreference(config.IPStrategy); err != nil {
+		log.Errorf("invalid ipstrategy, use %s: %s", IPStrategyPreferIntranet, err)
+		config.IPStrategy = IPStrategyPreferIntranet
+	}
 	Conf = config
 }
@@ -29,6 +29,20 @@ func Test_InitCollectConfig(t *testing.T) {
 	}
 }
 
+func Test_InitCollectConfigPsPath(t *testing.T) {
+	old := Conf
+	defer func() { Conf = old }()
+
+	InitCollectConfig(&AgentConfig{PsPath: "/nonexistent/ps"})
+	if Conf.PsPath != "" {
+		t.Fatalf("missing pspath should fall back to PATH: %s", Conf.PsPath)
+	}
+	InitCollectConfig(&AgentConfig{PsPath: "sh"})
+	if Conf.PsPath != "sh" {
+		t.Fatalf("valid pspath fatal: %s", Conf.PsPath)
+	}
+}
+
 func MustConfig() *AgentConfig {
 	pluginsDir, err := ioutil.TempDir("", "install-config-test-")
 	if err != nil {
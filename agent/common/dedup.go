@@ -0,0 +1,31 @@
+package common
+
+import (
+	"strconv"
+
+	"github.com/lodastack/log"
+)
+
+// logWarning is replaced in tests
+var logWarning = log.Warning
+
+// Dedup drops the metrics of L sharing name, tags and timestamp with a later
+// one: the last value is kept at the place of the first. Duplicates point
+// to a bug like two collectors emitting the same series, so each is logged.
+// Points with the same series but another timestamp, e.g. two logins, are
+// kept.
+func Dedup(L []Metric) []Metric {
+	index := make(map[string]int, len(L))
+	res := L[:0]
+	for i := range L {
+		key := seriesKey(L[i].Name, L[i].Tags) + "@" + strconv.FormatInt(L[i].Timestamp, 10)
+		if j, ok := index[key]; ok {
+			logWarning("drop duplicate metric ", key, ": ", res[j].Value, " replaced by ", L[i].Value)
+			res[j] = L[i]
+			continue
+		}
+		index[key] = len(res)
+		res = append(res, L[i])
+	}
+	return res
+}
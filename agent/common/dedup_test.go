@@ -0,0 +1,41 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_Dedup(t *testing.T) {
+	var warnings []string
+	old := logWarning
+	defer func() { logWarning = old }()
+	logWarning = func(args ...interface{}) {
+		warnings = append(warnings, fmt.Sprint(args...))
+	}
+
+	L := []Metric{
+		{Name: "proc.running", Timestamp: 100, Tags: map[string]string{"host": "a"}, Value: 1},
+		{Name: "cpu.idle", Timestamp: 100, Tags: map[string]string{"host": "a", "core": "0"}, Value: 90},
+		{Name: "proc.running", Timestamp: 100, Tags: map[string]string{"host": "a"}, Value: 2},
+		// same series, another point in time
+		{Name: "proc.running", Timestamp: 110, Tags: map[string]string{"host": "a"}, Value: 3},
+		{Name: "cpu.idle", Timestamp: 100, Tags: map[string]string{"core": "0", "host": "a"}, Value: 95},
+	}
+	res := Dedup(L)
+	if len(res) != 3 {
+		t.Fatalf("dedup fatal: %v", res)
+	}
+	if res[0].Name != "proc.running" || res[0].Value != 2 {
+		t.Fatalf("dedup should keep the last value: %v", res[0])
+	}
+	if res[1].Name != "cpu.idle" || res[1].Value != 95 {
+		t.Fatalf("dedup with tags in another order fatal: %v", res[1])
+	}
+	if res[2].Timestamp != 110 {
+		t.Fatalf("dedup should keep other timestamps: %v", res[2])
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "proc.running,host=a@100") {
+		t.Fatalf("dedup warnings fatal: %v", warnings)
+	}
+}
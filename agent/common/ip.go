@@ -1,12 +1,107 @@
 package common
 
 import (
+	"errors"
+	"fmt"
 	"net"
-	"strconv"
+	"path"
 	"strings"
 )
 
+// IP returns the IPv4 addresses of the monitored interfaces, followed by
+// the IPv6 ones if includeipv6 is set
 func IP() (ips []string, err error) {
+	v6 := Conf != nil && Conf.IncludeIPv6
+	return interfaceIPs(func(addrs []net.Addr) []string {
+		ips := filterIPs(addrs, false)
+		if v6 {
+			ips = append(ips, filterIPs(addrs, true)...)
+		}
+		return ips
+	})
+}
+
+// strategies of PrimaryIP picking the address identifying this host
+const (
+	// the first address of the monitored interfaces
+	IPStrategyFirst = "first"
+	// the first intranet address, or the first address if there is none
+	IPStrategyPreferIntranet = "prefer-intranet"
+	// the first public address, or the first address if there is none
+	IPStrategyPreferPublic = "prefer-public"
+)
+
+// PrimaryIP returns the address of CachedIP identifying this host picked by
+// strategy, an empty strategy is prefer-intranet
+func PrimaryIP(strategy string) (string, error) {
+	ips, err := CachedIP()
+	if err != nil {
+		return "", err
+	}
+	return primaryIP(ips, strategy)
+}
+
+func primaryIP(ips []string, strategy string) (string, error) {
+	prefer, err := ipPreference(strategy)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", errors.New("no ip found on the monitored interfaces")
+	}
+	if prefer != nil {
+		for _, ip := range ips {
+			if prefer(ip) {
+				return ip, nil
+			}
+		}
+	}
+	return ips[0], nil
+}
+
+// PrimaryIntranetIP returns the first intranet address of CachedIP and fails
+// if there is none; unlike PrimaryIP it never falls back to a public address.
+// The host identity sent with metrics is PrimaryIP of the configured strategy.
+func PrimaryIntranetIP() (string, error) {
+	ips, err := CachedIP()
+	if err != nil {
+		return "", err
+	}
+	return firstIntranetIP(ips)
+}
+
+func firstIntranetIP(ips []string) (string, error) {
+	for _, ip := range ips {
+		if IsIntranet(ip) {
+			return ip, nil
+		}
+	}
+	return "", errors.New("no intranet ip found on the monitored interfaces")
+}
+
+// ipPreference returns the addresses a strategy prefers, nil if it takes
+// the first one
+func ipPreference(strategy string) (func(string) bool, error) {
+	switch strategy {
+	case IPStrategyFirst:
+		return nil, nil
+	case "", IPStrategyPreferIntranet:
+		return IsIntranet, nil
+	case IPStrategyPreferPublic:
+		return func(ip string) bool { return !IsIntranet(ip) }, nil
+	}
+	return nil, fmt.Errorf("unknown ip strategy %q", strategy)
+}
+
+// IP6 returns the global and unique local IPv6 addresses of the monitored
+// interfaces
+func IP6() ([]string, error) {
+	return interfaceIPs(func(addrs []net.Addr) []string {
+		return filterIPs(addrs, true)
+	})
+}
+
+func interfaceIPs(filter func([]net.Addr) []string) (ips []string, err error) {
 	ips = make([]string, 0)
 
 	ifaces, e := net.Interfaces()
@@ -24,7 +119,7 @@ func IP() (ips []string, err error) {
 		}
 
 		// ignore docker and warden bridge
-		if !HasInterfacePrefix(iface.Name) {
+		if !IsMonitoredInterface(iface.Name) {
 			continue
 		}
 
@@ -33,34 +128,45 @@ func IP() (ips []string, err error) {
 			return ips, e
 		}
 
-		for _, addr := range addrs {
-			var ip net.IP
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ip = v.IP
-			case *net.IPAddr:
-				ip = v.IP
-			}
+		// append all IP
+		ips = append(ips, filter(addrs)...)
+	}
 
-			// IP filter
-			// 224.0.0
-			// 169.254.0.0/16
-			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalMulticast() || ip.IsLinkLocalUnicast() {
-				continue
-			}
+	return ips, nil
+}
 
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
-			}
+// filterIPs returns the IPv4 (or IPv6 if v6 is true) addresses worth
+// reporting, loopback, link local and multicast addresses are dropped
+func filterIPs(addrs []net.Addr, v6 bool) []string {
+	var ips []string
+	for _, addr := range addrs {
+		var ip net.IP
+		switch v := addr.(type) {
+		case *net.IPNet:
+			ip = v.IP
+		case *net.IPAddr:
+			ip = v.IP
+		}
 
-			ipStr := ip.String()
-			// append all IP
-			ips = append(ips, ipStr)
+		// IP filter
+		// 224.0.0
+		// 169.254.0.0/16
+		// fe80::/10
+		if ip == nil || ip.IsLoopback() || ip.IsMulticast() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			continue
 		}
-	}
 
-	return ips, nil
+		if ip.To4() != nil {
+			if !v6 {
+				ips = append(ips, ip.To4().String())
+			}
+			continue
+		}
+		if v6 && ip.To16() != nil {
+			ips = append(ips, ip.To16().String())
+		}
+	}
+	return ips
 }
 
 func HasInterfacePrefix(ifacename string) bool {
@@ -72,27 +178,72 @@ func HasInterfacePrefix(ifacename string) bool {
 	return false
 }
 
-func IsIntranet(ipStr string) bool {
-	if strings.HasPrefix(ipStr, "10.") || strings.HasPrefix(ipStr, "192.168.") {
-		return true
-	}
+// IsMonitoredInterface reports whether the interface matches ifaceprefix
+// and is not listed in ifaceexclude
+func IsMonitoredInterface(ifacename string) bool {
+	return HasInterfacePrefix(ifacename) && !isExcludedInterface(ifacename)
+}
 
-	if strings.HasPrefix(ipStr, "172.") {
-		// 172.16.0.0-172.31.255.255
-		arr := strings.Split(ipStr, ".")
-		if len(arr) != 4 {
-			return false
+// isExcludedInterface matches the name against ifaceexclude, exact names
+// or glob patterns like "eth0.*"
+func isExcludedInterface(ifacename string) bool {
+	for _, pattern := range Conf.IfaceExclude {
+		if ok, _ := path.Match(pattern, ifacename); ok || pattern == ifacename {
+			return true
 		}
+	}
+	return false
+}
 
-		second, err := strconv.ParseInt(arr[1], 10, 64)
-		if err != nil {
-			return false
-		}
+// RFC1918 and RFC4193 ranges, used when intranetcidrs is not configured
+var defaultIntranetCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
 
-		if second >= 16 && second <= 31 {
+var intranetNets = mustParseCIDRs(defaultIntranetCIDRs)
+
+// SetIntranetCIDRs replaces the ranges IsIntranet matches against, an empty
+// list restores the builtin RFC1918 and RFC4193 ranges
+func SetIntranetCIDRs(cidrs []string) error {
+	if len(cidrs) == 0 {
+		intranetNets = mustParseCIDRs(defaultIntranetCIDRs)
+		return nil
+	}
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return err
+	}
+	intranetNets = nets
+	return nil
+}
+
+func IsIntranet(ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range intranetNets {
+		if n.Contains(ip) {
 			return true
 		}
 	}
-
 	return false
 }
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func mustParseCIDRs(cidrs []string) []*net.IPNet {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		panic(err)
+	}
+	return nets
+}
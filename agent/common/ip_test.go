@@ -0,0 +1,127 @@
+package common
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func Test_filterIPs(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+		&net.IPNet{IP: net.ParseIP("169.254.1.1"), Mask: net.CIDRMask(16, 32)},
+		&net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("fd12:3456::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPAddr{IP: net.ParseIP("ff02::1")},
+		&net.IPAddr{IP: net.ParseIP("::1")},
+	}
+
+	if got, want := filterIPs(addrs, false), []string{"10.0.0.1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("filter ipv4 fatal: %v - %v", got, want)
+	}
+	if got, want := filterIPs(addrs, true), []string{"2001:db8::1", "fd12:3456::1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("filter ipv6 fatal: %v - %v", got, want)
+	}
+}
+
+func Test_IsIntranet(t *testing.T) {
+	cases := map[string]bool{
+		"10.1.2.3":       true,
+		"172.16.0.1":     true,
+		"172.31.255.255": true,
+		"172.32.0.1":     false,
+		"172.5.0.1":      false,
+		"192.168.1.1":    true,
+		"8.8.8.8":        false,
+		"fd00::1":        true,
+		"2001:db8::1":    false,
+		"":               false,
+		"10.1.2":         false,
+		"not-an-ip":      false,
+	}
+	for ip, want := range cases {
+		if got := IsIntranet(ip); got != want {
+			t.Fatalf("is intranet fatal: %s - %v, want %v", ip, got, want)
+		}
+	}
+
+	if err := SetIntranetCIDRs([]string{"100.64.0.0/10"}); err != nil {
+		t.Fatalf("set intranet cidrs fatal: %s", err)
+	}
+	defer SetIntranetCIDRs(nil)
+	if !IsIntranet("100.64.1.1") || IsIntranet("10.1.2.3") {
+		t.Fatalf("is intranet with custom cidrs fatal")
+	}
+	if err := SetIntranetCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatalf("set malformed intranet cidrs fatal")
+	}
+}
+
+func Test_primaryIP(t *testing.T) {
+	ips := []string{"8.8.8.8", "192.168.1.2", "1.1.1.1", "10.0.0.1"}
+	for strategy, want := range map[string]string{
+		"":                       "192.168.1.2",
+		IPStrategyFirst:          "8.8.8.8",
+		IPStrategyPreferIntranet: "192.168.1.2",
+		IPStrategyPreferPublic:   "8.8.8.8",
+	} {
+		if ip, err := primaryIP(ips, strategy); err != nil || ip != want {
+			t.Fatalf("primary ip of strategy %q fatal: %s - %v, want %s", strategy, ip, err, want)
+		}
+	}
+
+	// no address of the preferred kind falls back to the first one
+	if ip, err := primaryIP([]string{"10.0.0.1", "192.168.1.2"}, IPStrategyPreferPublic); err != nil || ip != "10.0.0.1" {
+		t.Fatalf("prefer public without public ip fatal: %s - %v", ip, err)
+	}
+	if ip, err := primaryIP([]string{"8.8.8.8", "1.1.1.1"}, IPStrategyPreferIntranet); err != nil || ip != "8.8.8.8" {
+		t.Fatalf("prefer intranet without intranet ip fatal: %s - %v", ip, err)
+	}
+
+	if _, err := primaryIP(nil, IPStrategyFirst); err == nil {
+		t.Fatalf("primary ip without address should fail")
+	}
+	if _, err := primaryIP(ips, "random"); err == nil {
+		t.Fatalf("primary ip of unknown strategy should fail")
+	}
+}
+
+func Test_firstIntranetIP(t *testing.T) {
+	if _, err := firstIntranetIP([]string{"8.8.8.8", "1.1.1.1"}); err == nil {
+		t.Fatalf("first intranet ip of public ips should fail")
+	}
+	if _, err := firstIntranetIP(nil); err == nil {
+		t.Fatalf("first intranet ip without address should fail")
+	}
+	if ip, err := firstIntranetIP([]string{"10.0.0.1", "192.168.1.2"}); err != nil || ip != "10.0.0.1" {
+		t.Fatalf("first intranet ip of intranet ips fatal: %s - %v", ip, err)
+	}
+	if ip, err := firstIntranetIP([]string{"8.8.8.8", "fd00::1", "10.0.0.1"}); err != nil || ip != "fd00::1" {
+		t.Fatalf("first intranet ip of mixed ips fatal: %s - %v", ip, err)
+	}
+}
+
+func Test_IsMonitoredInterface(t *testing.T) {
+	old := Conf
+	defer func() { Conf = old }()
+	Conf = &AgentConfig{IfacePrefix: []string{"eth"}}
+	if !IsMonitoredInterface("eth0.100") || IsMonitoredInterface("docker0") {
+		t.Fatalf("monitored interface without exclude fatal")
+	}
+
+	Conf.IfaceExclude = []string{"eth0.*", "eth2"}
+	cases := map[string]bool{
+		"eth0":     true,
+		"eth0.100": false,
+		"eth1":     true,
+		"eth2":     false,
+		"docker0":  false,
+	}
+	for name, want := range cases {
+		if IsMonitoredInterface(name) != want {
+			t.Fatalf("monitored interface %s fatal, want %v", name, want)
+		}
+	}
+}
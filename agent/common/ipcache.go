@@ -0,0 +1,52 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lodastack/log"
+)
+
+// default refresh interval of CachedIP, unit: second
+const defaultIPCacheTTL = 60
+
+// ipCache keeps the last good result of an address lookup
+type ipCache struct {
+	lock   sync.Mutex
+	ips    []string
+	at     time.Time
+	lookup func() ([]string, error)
+	now    func() time.Time
+}
+
+var cachedIPs = &ipCache{lookup: IP, now: time.Now}
+
+// CachedIP is IP refreshed at most every ipcachettl seconds. If a refresh
+// fails the last addresses are returned.
+func CachedIP() ([]string, error) {
+	ttl := defaultIPCacheTTL
+	if Conf != nil && Conf.IPCacheTTL > 0 {
+		ttl = Conf.IPCacheTTL
+	}
+	return cachedIPs.get(time.Duration(ttl) * time.Second)
+}
+
+func (c *ipCache) get(ttl time.Duration) ([]string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	if c.ips == nil || now.Sub(c.at) >= ttl {
+		ips, err := c.lookup()
+		if err != nil {
+			if c.ips == nil {
+				return nil, err
+			}
+			log.Errorf("refresh ip failed, use the cached ones: %s", err)
+		} else {
+			c.ips, c.at = ips, now
+		}
+	}
+	res := make([]string, len(c.ips))
+	copy(res, c.ips)
+	return res, nil
+}
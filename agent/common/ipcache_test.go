@@ -0,0 +1,49 @@
+package common
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_ipCache(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	calls := 0
+	var lookupErr error
+	c := &ipCache{
+		lookup: func() ([]string, error) {
+			calls++
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+			return []string{"10.0.0." + strconv.Itoa(calls)}, nil
+		},
+		now: func() time.Time { return now },
+	}
+
+	ips, err := c.get(time.Minute)
+	if err != nil || len(ips) != 1 || ips[0] != "10.0.0.1" || calls != 1 {
+		t.Fatalf("ip cache first lookup fatal: %v %v %d", ips, err, calls)
+	}
+	now = now.Add(30 * time.Second)
+	if ips, _ = c.get(time.Minute); ips[0] != "10.0.0.1" || calls != 1 {
+		t.Fatalf("ip cache within ttl fatal: %v %d", ips, calls)
+	}
+	now = now.Add(30 * time.Second)
+	if ips, _ = c.get(time.Minute); ips[0] != "10.0.0.2" || calls != 2 {
+		t.Fatalf("ip cache after ttl fatal: %v %d", ips, calls)
+	}
+
+	lookupErr = errors.New("no interfaces")
+	now = now.Add(time.Minute)
+	ips, err = c.get(time.Minute)
+	if err != nil || len(ips) != 1 || ips[0] != "10.0.0.2" {
+		t.Fatalf("ip cache should keep the last ips on error: %v %v", ips, err)
+	}
+
+	empty := &ipCache{lookup: c.lookup, now: c.now}
+	if _, err := empty.get(time.Minute); err == nil {
+		t.Fatalf("ip cache without previous ips should fail")
+	}
+}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"sync"
+)
+
+// MetricMeta describes a metric for self-describing outputs, e.g. the
+// Prometheus HELP line. Both fields are optional.
+type MetricMeta struct {
+	Unit string `json:"unit,omitempty"`
+	Help string `json:"help,omitempty"`
+}
+
+var (
+	metaLock = new(sync.RWMutex)
+	metas    = map[string]MetricMeta{}
+)
+
+// SetMetricMeta declares the unit and help text of a metric name
+func SetMetricMeta(name, unit, help string) {
+	metaLock.Lock()
+	defer metaLock.Unlock()
+	metas[name] = MetricMeta{Unit: unit, Help: help}
+}
+
+// GetMetricMeta returns the declared metadata of a metric name
+func GetMetricMeta(name string) (MetricMeta, bool) {
+	metaLock.RLock()
+	defer metaLock.RUnlock()
+	m, ok := metas[name]
+	return m, ok
+}
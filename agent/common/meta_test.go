@@ -0,0 +1,17 @@
+package common
+
+import (
+	"testing"
+)
+
+func Test_MetricMeta(t *testing.T) {
+	SetMetricMeta("test.meta.used", "bytes", "used test memory")
+	m, ok := GetMetricMeta("test.meta.used")
+	if !ok || m.Unit != "bytes" || m.Help != "used test memory" {
+		t.Fatalf("get metric meta fatal: %v - %v", ok, m)
+	}
+
+	if _, ok := GetMetricMeta("test.meta.unknown"); ok {
+		t.Fatalf("get undeclared metric meta fatal")
+	}
+}
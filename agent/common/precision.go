@@ -0,0 +1,56 @@
+package common
+
+import (
+	"sync"
+)
+
+var (
+	precisionLock sync.RWMutex
+	// decimals collectors asked for, keyed by metric name
+	metricPrecisions = make(map[string]int)
+)
+
+// SetMetricPrecision asks the emit layer to cut the values of the named
+// metric to precision decimals, meant to be called from init() of the
+// collector emitting it
+func SetMetricPrecision(name string, precision int) {
+	precisionLock.Lock()
+	defer precisionLock.Unlock()
+	metricPrecisions[name] = precision
+}
+
+// MetricPrecision returns the decimals the values of a metric are cut to:
+// metricprecision of the config, else the one the collector asked for, else
+// defaultprecision. ok is false if the value is sent as collected.
+func MetricPrecision(name string) (precision int, ok bool) {
+	if Conf != nil {
+		if precision, ok = Conf.MetricPrecision[name]; ok {
+			return
+		}
+	}
+	precisionLock.RLock()
+	precision, ok = metricPrecisions[name]
+	precisionLock.RUnlock()
+	if ok {
+		return
+	}
+	if Conf != nil && Conf.DefaultPrecision != nil {
+		return *Conf.DefaultPrecision, true
+	}
+	return 0, false
+}
+
+// ApplyPrecision cuts a float value of m to the precision of its name,
+// other values are left alone
+func ApplyPrecision(m *Metric) {
+	precision, ok := MetricPrecision(m.Name)
+	if !ok {
+		return
+	}
+	switch v := m.Value.(type) {
+	case float64:
+		m.Value = SetPrecision(v, precision)
+	case float32:
+		m.Value = SetPrecision(float64(v), precision)
+	}
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"testing"
+)
+
+func Test_ApplyPrecision(t *testing.T) {
+	old := Conf
+	defer func() { Conf = old }()
+
+	cases := []struct {
+		precision int
+		in, out   float64
+	}{
+		{0, 12.987, 12},
+		{0, -12.987, -12},
+		{2, 0.123456, 0.12},
+		{2, -0.123456, -0.12},
+		{4, 0.123456, 0.1234},
+		{4, -0.000123456, -0.0001},
+	}
+	for _, c := range cases {
+		precision := c.precision
+		Conf = &AgentConfig{DefaultPrecision: &precision}
+		m := &Metric{Name: "test.value", Value: c.in}
+		ApplyPrecision(m)
+		if m.Value.(float64) != c.out {
+			t.Fatalf("precision %d of %v fatal: %v, want %v", c.precision, c.in, m.Value, c.out)
+		}
+	}
+
+	// values are sent as collected by default
+	Conf = &AgentConfig{}
+	m := &Metric{Name: "test.value", Value: 0.123456}
+	if ApplyPrecision(m); m.Value.(float64) != 0.123456 {
+		t.Fatalf("default precision should keep the value: %v", m.Value)
+	}
+	m = &Metric{Name: "test.count", Value: 3}
+	if ApplyPrecision(m); m.Value.(int) != 3 {
+		t.Fatalf("precision of an int fatal: %v", m.Value)
+	}
+}
+
+func Test_MetricPrecision(t *testing.T) {
+	old := Conf
+	defer func() { Conf = old }()
+	defer func() {
+		precisionLock.Lock()
+		delete(metricPrecisions, "time.offset")
+		precisionLock.Unlock()
+	}()
+
+	two := 2
+	Conf = &AgentConfig{DefaultPrecision: &two, MetricPrecision: map[string]int{"load.1min": 0}}
+	SetMetricPrecision("time.offset", 4)
+	for name, want := range map[string]int{"time.offset": 4, "load.1min": 0, "cpu.idle": 2} {
+		if p, ok := MetricPrecision(name); !ok || p != want {
+			t.Fatalf("precision of %s fatal: %d %v, want %d", name, p, ok, want)
+		}
+	}
+	// the config wins over the collector
+	Conf.MetricPrecision["time.offset"] = 1
+	if p, _ := MetricPrecision("time.offset"); p != 1 {
+		t.Fatalf("configured precision should win: %d", p)
+	}
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"regexp"
+
+	"github.com/lodastack/log"
+)
+
+// ProcMatcher counts the processes whose command line matches Pattern
+type ProcMatcher struct {
+	Name    string `toml:"name"`
+	Pattern string `toml:"pattern"`
+
+	re *regexp.Regexp
+}
+
+// Compile compiles Pattern, it is called once when the config is loaded
+func (m *ProcMatcher) Compile() (err error) {
+	m.re, err = regexp.Compile(m.Pattern)
+	return
+}
+
+// Match reports whether the command line matches, always false before Compile
+func (m *ProcMatcher) Match(cmdline string) bool {
+	return m.re != nil && m.re.MatchString(cmdline)
+}
+
+// compileProcMatchers returns the matchers with a valid pattern
+func compileProcMatchers(matchers []ProcMatcher) []ProcMatcher {
+	res := make([]ProcMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		if err := m.Compile(); err != nil {
+			log.Errorf("invalid pattern of procmatcher %s, ignore it: %s", m.Name, err)
+			continue
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// MaxAgeMatcher flags the processes whose command line matches Pattern
+// and that run longer than MaxAge seconds
+type MaxAgeMatcher struct {
+	Name    string `toml:"name"`
+	Pattern string `toml:"pattern"`
+	MaxAge  int    `toml:"maxage"`
+
+	re *regexp.Regexp
+}
+
+// Compile compiles Pattern, it is called once when the config is loaded
+func (m *MaxAgeMatcher) Compile() (err error) {
+	m.re, err = regexp.Compile(m.Pattern)
+	return
+}
+
+// Match reports whether the command line matches, always false before Compile
+func (m *MaxAgeMatcher) Match(cmdline string) bool {
+	return m.re != nil && m.re.MatchString(cmdline)
+}
+
+// compileMaxAgeMatchers returns the matchers with a valid pattern and age
+func compileMaxAgeMatchers(matchers []MaxAgeMatcher) []MaxAgeMatcher {
+	res := make([]MaxAgeMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		if m.MaxAge <= 0 {
+			log.Errorf("maxage of maxagematcher %s must be positive, ignore it", m.Name)
+			continue
+		}
+		if err := m.Compile(); err != nil {
+			log.Errorf("invalid pattern of maxagematcher %s, ignore it: %s", m.Name, err)
+			continue
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// LogWatcher counts the new lines of the file Path matching Pattern
+type LogWatcher struct {
+	Name    string `toml:"name"`
+	Path    string `toml:"path"`
+	Pattern string `toml:"pattern"`
+
+	re *regexp.Regexp
+}
+
+// Compile compiles Pattern, it is called once when the config is loaded
+func (w *LogWatcher) Compile() (err error) {
+	w.re, err = regexp.Compile(w.Pattern)
+	return
+}
+
+// Match reports whether the line matches, always false before Compile
+func (w *LogWatcher) Match(line []byte) bool {
+	return w.re != nil && w.re.Match(line)
+}
+
+// compileLogWatchers returns the watchers with a path and a valid pattern
+func compileLogWatchers(watchers []LogWatcher) []LogWatcher {
+	res := make([]LogWatcher, 0, len(watchers))
+	for _, w := range watchers {
+		if w.Path == "" {
+			log.Errorf("path of logwatcher %s is empty, ignore it", w.Name)
+			continue
+		}
+		if err := w.Compile(); err != nil {
+			log.Errorf("invalid pattern of logwatcher %s, ignore it: %s", w.Name, err)
+			continue
+		}
+		res = append(res, w)
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type rateSample struct {
+	value uint64
+	ts    float64
+}
+
+// RateTracker turns monotonic counters into per second rates. It keeps the
+// previous sample of every series, keyed by metric name and tags.
+type RateTracker struct {
+	lock sync.Mutex
+	last map[string]rateSample
+}
+
+func NewRateTracker() *RateTracker {
+	return &RateTracker{last: make(map[string]rateSample)}
+}
+
+// Rate returns the per second delta of the counter since the last call for
+// the same series, using RateNow as the time base
+func (t *RateTracker) Rate(name string, tags map[string]string, value uint64) (float64, bool) {
+	return t.RateAt(name, tags, value, RateNow())
+}
+
+// RateAt is Rate with the sample time (unit: second) given by the caller.
+// ok is false for the first sample of a series. A counter that went
+// backwards (wrapped or reset) yields 0.
+func (t *RateTracker) RateAt(name string, tags map[string]string, value uint64, ts float64) (rate float64, ok bool) {
+	key := seriesKey(name, tags)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	prev, ok := t.last[key]
+	t.last[key] = rateSample{value: value, ts: ts}
+	if !ok {
+		return 0, false
+	}
+	if value < prev.value || ts <= prev.ts {
+		return 0, true
+	}
+	return float64(value-prev.value) / (ts - prev.ts), true
+}
+
+// Prune forgets the series last sampled before ts and returns how many.
+// Collectors of short lived series, e.g. one per pid, call it after every
+// scan with the scan time so the series of exited processes are dropped.
+func (t *RateTracker) Prune(before float64) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	n := 0
+	for key, s := range t.last {
+		if s.ts < before {
+			delete(t.last, key)
+			n++
+		}
+	}
+	return n
+}
+
+// Len returns the number of tracked series
+func (t *RateTracker) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.last)
+}
+
+// seriesKey identifies a series by name and sorted tags
+func seriesKey(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys)+1)
+	parts = append(parts, name)
+	for _, k := range keys {
+		parts = append(parts, k+"="+tags[k])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_RateTracker(t *testing.T) {
+	rt := NewRateTracker()
+	tags := map[string]string{"device": "sda"}
+
+	if _, ok := rt.RateAt("disk.read", tags, 100, 10); ok {
+		t.Fatalf("first sample should have no rate")
+	}
+	if rate, ok := rt.RateAt("disk.read", tags, 600, 20); !ok || rate != 50 {
+		t.Fatalf("rate fatal: %f - %v", rate, ok)
+	}
+	// other tags are another series
+	if _, ok := rt.RateAt("disk.read", map[string]string{"device": "sdb"}, 600, 20); ok {
+		t.Fatalf("first sample of another series should have no rate")
+	}
+	// wrapped or reset counter
+	if rate, ok := rt.RateAt("disk.read", tags, 5, 30); !ok || rate != 0 {
+		t.Fatalf("wrapped counter rate fatal: %f - %v", rate, ok)
+	}
+	if rate, _ := rt.RateAt("disk.read", tags, 25, 40); rate != 2 {
+		t.Fatalf("rate after wrap fatal: %f", rate)
+	}
+}
+
+func Test_RateTrackerPrune(t *testing.T) {
+	rt := NewRateTracker()
+	rt.RateAt("proc.cpu", map[string]string{"pid": "1"}, 100, 10)
+	rt.RateAt("proc.cpu", map[string]string{"pid": "2"}, 100, 10)
+	rt.RateAt("proc.cpu", map[string]string{"pid": "1"}, 200, 20)
+
+	if n := rt.Prune(20); n != 1 || rt.Len() != 1 {
+		t.Fatalf("prune fatal: %d pruned, %d left", n, rt.Len())
+	}
+	if rate, ok := rt.RateAt("proc.cpu", map[string]string{"pid": "1"}, 300, 30); !ok || rate != 10 {
+		t.Fatalf("rate of a kept series fatal: %f - %v", rate, ok)
+	}
+	// a pruned series starts over
+	if _, ok := rt.RateAt("proc.cpu", map[string]string{"pid": "2"}, 300, 30); ok {
+		t.Fatalf("pruned series should have no rate")
+	}
+}
+
+func Test_RateTrackerConcurrent(t *testing.T) {
+	rt := NewRateTracker()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				rt.RateAt("concurrent", nil, uint64(j), float64(i*100+j))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func Test_seriesKey(t *testing.T) {
+	a := seriesKey("m", map[string]string{"a": "1", "b": "2"})
+	b := seriesKey("m", map[string]string{"b": "2", "a": "1"})
+	if a != b || a != "m,a=1,b=2" {
+		t.Fatalf("series key fatal: %s - %s", a, b)
+	}
+}
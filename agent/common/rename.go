@@ -0,0 +1,47 @@
+package common
+
+import (
+	"strings"
+)
+
+// RenameMetric maps a metric name through the rename rules (old name -> new
+// name). An exact rule wins; otherwise the longest rule ending with "*" whose
+// prefix matches is used, and a "*" at the end of the new name is replaced by
+// the rest of the matched name, e.g. "kernel.*" -> "system.*".
+func RenameMetric(name string, rules map[string]string) string {
+	if len(rules) == 0 {
+		return name
+	}
+	if to, ok := rules[name]; ok {
+		return to
+	}
+
+	var match, to string
+	found := false
+	for from, t := range rules {
+		if !strings.HasSuffix(from, "*") {
+			continue
+		}
+		prefix := strings.TrimSuffix(from, "*")
+		if strings.HasPrefix(name, prefix) && (!found || len(prefix) > len(match)) {
+			match, to, found = prefix, t, true
+		}
+	}
+	if !found {
+		return name
+	}
+	if strings.HasSuffix(to, "*") {
+		return strings.TrimSuffix(to, "*") + strings.TrimPrefix(name, match)
+	}
+	return to
+}
+
+// PrefixMetric prepends prefix and a dot to name, an empty prefix leaves
+// the name unchanged
+func PrefixMetric(name, prefix string) string {
+	prefix = strings.TrimSuffix(prefix, ".")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
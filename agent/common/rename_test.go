@@ -0,0 +1,30 @@
+package common
+
+import (
+	"testing"
+)
+
+func Test_RenameMetric(t *testing.T) {
+	rules := map[string]string{
+		"kernel.files.allocated.percent": "system.fd.used_pct",
+		"kernel.*":                       "system.kernel.*",
+		"kernel.files.*":                 "system.files.*",
+		"net.in*":                        "net.ingress",
+	}
+	cases := map[string]string{
+		"kernel.files.allocated.percent": "system.fd.used_pct",
+		"kernel.files.open.byfstype":     "system.files.open.byfstype",
+		"kernel.maxproc":                 "system.kernel.maxproc",
+		"net.in.dropped":                 "net.ingress",
+		"net.out":                        "net.out",
+	}
+	for name, want := range cases {
+		if got := RenameMetric(name, rules); got != want {
+			t.Fatalf("rename metric fatal: %s - %s, want %s", name, got, want)
+		}
+	}
+
+	if got := RenameMetric("cpu.idle", nil); got != "cpu.idle" {
+		t.Fatalf("rename metric without rules fatal: %s", got)
+	}
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// default max characters of a tag key or value
+const defaultSanitizeMaxLength = 256
+
+// SanitizeRules normalize the tags, and optionally the names, of the metrics
+// before they are sent. Control characters, e.g. NUL padding of utmp, and
+// invalid UTF-8 are always dropped.
+type SanitizeRules struct {
+	// characters replaced, e.g. " ,=" for backends splitting on them
+	Chars string `toml:"chars"`
+	// what Chars are replaced with, empty drops them
+	Replacement string `toml:"replacement"`
+	// max characters of a tag key or value, longer ones are cut, default 256
+	MaxLength int `toml:"maxlength"`
+	// apply the rules to metric names too
+	Names bool `toml:"names"`
+}
+
+func (r SanitizeRules) maxLength() int {
+	if r.MaxLength > 0 {
+		return r.MaxLength
+	}
+	return defaultSanitizeMaxLength
+}
+
+// clean applies the rules to s, s is returned as is if nothing changes
+func (r SanitizeRules) clean(s string) string {
+	max := r.maxLength()
+	dirty := len(s) > max
+	for _, c := range s {
+		if c == utf8.RuneError || unicode.IsControl(c) || strings.ContainsRune(r.Chars, c) {
+			dirty = true
+			break
+		}
+	}
+	if !dirty {
+		return s
+	}
+
+	var buf bytes.Buffer
+	n := 0
+	for _, c := range s {
+		if n >= max {
+			break
+		}
+		switch {
+		case c == utf8.RuneError, unicode.IsControl(c):
+			continue
+		case strings.ContainsRune(r.Chars, c):
+			buf.WriteString(r.Replacement)
+		default:
+			buf.WriteRune(c)
+		}
+		n++
+	}
+	return buf.String()
+}
+
+// Sanitize applies the rules to the tags of m and, with Names, its name
+func Sanitize(m *Metric, r SanitizeRules) {
+	if r.Names {
+		m.Name = r.clean(m.Name)
+	}
+	for k, v := range m.Tags {
+		key, value := r.clean(k), r.clean(v)
+		if key != k {
+			delete(m.Tags, k)
+		}
+		m.Tags[key] = value
+	}
+}
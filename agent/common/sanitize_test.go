@@ -0,0 +1,50 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Sanitize(t *testing.T) {
+	r := SanitizeRules{Chars: " ,=", Replacement: "_", MaxLength: 16}
+	m := &Metric{Name: "login.count", Tags: map[string]string{
+		"host": "evil host,x=1",
+		"user": "root\x00\x00\x00",
+		"tty":  "pts/0",
+		"a b":  "long-value-of-more-than-16",
+		"bad":  "in\xffvalid\n",
+	}}
+	Sanitize(m, r)
+	want := map[string]string{
+		"host": "evil_host_x_1",
+		"user": "root",
+		"tty":  "pts/0",
+		"a_b":  "long-value-of-mo",
+		"bad":  "invalid",
+	}
+	if len(m.Tags) != len(want) {
+		t.Fatalf("sanitize tags fatal: %v", m.Tags)
+	}
+	for k, v := range want {
+		if m.Tags[k] != v {
+			t.Fatalf("sanitize tag %s fatal: %q, want %q", k, m.Tags[k], v)
+		}
+	}
+	if m.Name != "login.count" {
+		t.Fatalf("names should be kept without the names rule: %s", m.Name)
+	}
+
+	// dropped without a replacement
+	m = &Metric{Name: "disk io,ops", Tags: map[string]string{"mount": "/mnt/my disk"}}
+	Sanitize(m, SanitizeRules{Chars: " ,", Names: true})
+	if m.Name != "diskioops" || m.Tags["mount"] != "/mnt/mydisk" {
+		t.Fatalf("sanitize without replacement fatal: %s", m.String())
+	}
+
+	// the default rules only drop control characters and cut at 256
+	m = &Metric{Name: "proc.cpu.percent", Tags: map[string]string{"comm": "tmux: server", "x": strings.Repeat("a", 300) + "\x00"}}
+	Sanitize(m, SanitizeRules{})
+	if m.Tags["comm"] != "tmux: server" || len(m.Tags["x"]) != 256 {
+		t.Fatalf("sanitize default rules fatal: %s", m.String())
+	}
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Uptime returns the seconds since boot, derived from the kern.boottime sysctl
+func Uptime() (float64, error) {
+	out, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+	if err != nil {
+		return 0, err
+	}
+	boot, err := parseBoottime(string(out))
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(boot).Seconds(), nil
+}
+
+// parseBoottime parses "{ sec = 1500000000, usec = 250000 } Fri Jul 14 02:40:00 2017"
+func parseBoottime(content string) (time.Time, error) {
+	var sec, usec int64
+	start, end := strings.Index(content, "{"), strings.Index(content, "}")
+	if start < 0 || end < start {
+		return time.Time{}, fmt.Errorf("invalid boottime content: %q", content)
+	}
+	for _, field := range strings.Split(content[start+1:end], ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid boottime content: %q", content)
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "sec":
+			sec = v
+		case "usec":
+			usec = v
+		}
+	}
+	if sec == 0 {
+		return time.Time{}, fmt.Errorf("invalid boottime content: %q", content)
+	}
+	return time.Unix(sec, usec*int64(time.Microsecond)), nil
+}
@@ -0,0 +1,18 @@
+package common
+
+import (
+	"testing"
+)
+
+func Test_parseBoottime(t *testing.T) {
+	boot, err := parseBoottime("{ sec = 1500000000, usec = 250000 } Fri Jul 14 02:40:00 2017\n")
+	if err != nil {
+		t.Fatalf("parse boottime fatal: %s", err)
+	}
+	if boot.Unix() != 1500000000 || boot.Nanosecond() != 250000000 {
+		t.Fatalf("parse boottime fatal: %s", boot)
+	}
+	if _, err := parseBoottime("kern.boottime: unknown oid"); err == nil {
+		t.Fatalf("parse invalid boottime should fail")
+	}
+}
@@ -0,0 +1,17 @@
+package common
+
+import (
+	"io/ioutil"
+)
+
+// seconds since boot, not affected by NTP steps or VM pauses of the wall clock
+const uptimeFile = "/proc/uptime"
+
+// Uptime returns the seconds since boot from /proc/uptime
+func Uptime() (float64, error) {
+	read, err := ioutil.ReadFile(uptimeFile)
+	if err != nil {
+		return 0, err
+	}
+	return parseUptime(string(read))
+}
@@ -0,0 +1,10 @@
+package common
+
+import (
+	"errors"
+)
+
+// Uptime is not supported on windows yet
+func Uptime() (float64, error) {
+	return 0, errors.New("uptime is not supported on windows")
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"math"
 	"os/exec"
 	"testing"
 	"time"
@@ -65,3 +66,21 @@ func Test_GitPath(t *testing.T) {
 		t.Fatalf("git path fatal: %s - %s", finnal, correct)
 	}
 }
+
+func Test_SetPrecision(t *testing.T) {
+	cases := []struct {
+		in, out float64
+	}{
+		{1.23456, 1.23},
+		{99.999, 99.99},
+		{-1.23456, -1.23},
+		{math.NaN(), 0},
+		{math.Inf(1), 0},
+		{math.Inf(-1), 0},
+	}
+	for _, c := range cases {
+		if v := SetPrecision(c.in, 2); v != c.out {
+			t.Fatalf("SetPrecision fatal: %v - %v, want %v", c.in, v, c.out)
+		}
+	}
+}
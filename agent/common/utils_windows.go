@@ -87,7 +87,12 @@ func Exists(path string) bool {
 	return false
 }
 
+// SetPrecision truncates from to precision decimals, NaN and Inf become 0
+// so they never reach a metric value.
 func SetPrecision(from float64, precision int) float64 {
+	if math.IsNaN(from) || math.IsInf(from, 0) {
+		return 0
+	}
 	base := math.Pow10(precision)
 	return float64(int64(from*base)) / base
 }
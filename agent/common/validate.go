@@ -0,0 +1,55 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lodastack/log"
+)
+
+// Validate returns an error if the metric would be rejected or misread by
+// the backend: no name, no timestamp, an empty tag or a nil, NaN or
+// infinite value
+func (m *Metric) Validate() error {
+	if m.Name == "" {
+		return errors.New("empty metric name")
+	}
+	if m.Timestamp <= 0 {
+		return fmt.Errorf("metric %s: timestamp not set", m.Name)
+	}
+	for k, v := range m.Tags {
+		if k == "" || v == "" {
+			return fmt.Errorf("metric %s: empty tag %q=%q", m.Name, k, v)
+		}
+	}
+	var f float64
+	switch v := m.Value.(type) {
+	case nil:
+		return fmt.Errorf("metric %s: nil value", m.Name)
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	default:
+		return nil
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("metric %s: invalid value %v", m.Name, f)
+	}
+	return nil
+}
+
+// FilterValid returns the valid metrics of L in order, the invalid ones
+// are logged and dropped
+func FilterValid(L []Metric) []Metric {
+	valid := L[:0]
+	for i := range L {
+		if err := L[i].Validate(); err != nil {
+			log.Error("drop invalid metric:", err)
+			continue
+		}
+		valid = append(valid, L[i])
+	}
+	return valid
+}
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Validate(t *testing.T) {
+	valid := Metric{Name: "cpu.idle", Timestamp: 1500000000, Tags: map[string]string{"core": "0"}, Value: 99.5}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("validate fatal: valid metric rejected: %s", err)
+	}
+
+	for name, m := range map[string]Metric{
+		"empty name":    {Timestamp: 1500000000, Value: 1},
+		"no timestamp":  {Name: "cpu.idle", Value: 1},
+		"nil value":     {Name: "cpu.idle", Timestamp: 1500000000},
+		"NaN value":     {Name: "cpu.idle", Timestamp: 1500000000, Value: math.NaN()},
+		"Inf value":     {Name: "cpu.idle", Timestamp: 1500000000, Value: math.Inf(1)},
+		"-Inf float32":  {Name: "cpu.idle", Timestamp: 1500000000, Value: float32(math.Inf(-1))},
+		"empty tag":     {Name: "cpu.idle", Timestamp: 1500000000, Value: 1, Tags: map[string]string{"user": ""}},
+		"empty tag key": {Name: "cpu.idle", Timestamp: 1500000000, Value: 1, Tags: map[string]string{"": "x"}},
+	} {
+		if err := m.Validate(); err == nil {
+			t.Fatalf("validate fatal: %s accepted", name)
+		}
+	}
+}
+
+func Test_FilterValid(t *testing.T) {
+	L := []Metric{
+		{Name: "a", Timestamp: 1500000000, Value: 1},
+		{Name: "", Timestamp: 1500000000, Value: 1},
+		{Name: "b", Timestamp: 1500000000, Value: math.NaN()},
+		{Name: "c", Timestamp: 1500000000, Value: "up"},
+	}
+	valid := FilterValid(L)
+	if len(valid) != 2 || valid[0].Name != "a" || valid[1].Name != "c" {
+		t.Fatalf("filter valid fatal: %v", valid)
+	}
+}
@@ -26,24 +26,32 @@ const (
 	TYPE_DEV  = "DEV"
 	TYPE_SYS  = "SYS"
 
-	TYPE_COREDUMP = "COREDUMP"
+	TYPE_COREDUMP  = "COREDUMP"
+	TYPE_POWER     = "POWER"
+	TYPE_CONTAINER = "CONTAINER"
+	TYPE_SYSTEMD   = "SYSTEMD"
+	TYPE_LOGIN     = "LOGIN"
 )
 
 var (
 	DEFAULT_INTERVAL = map[string]int{
-		TYPE_CPU:      10,
-		TYPE_DISK:     10,
-		TYPE_MEM:      10,
-		TYPE_NET:      10,
-		TYPE_PROC:     60,
-		TYPE_PORT:     60,
-		TYPE_FS:       300,
-		TYPE_TIME:     300,
-		TYPE_DEV:      300,
-		TYPE_COREDUMP: 60,
+		TYPE_CPU:       10,
+		TYPE_DISK:      10,
+		TYPE_MEM:       10,
+		TYPE_NET:       10,
+		TYPE_PROC:      60,
+		TYPE_PORT:      60,
+		TYPE_FS:        300,
+		TYPE_TIME:      300,
+		TYPE_DEV:       300,
+		TYPE_COREDUMP:  60,
+		TYPE_POWER:     60,
+		TYPE_CONTAINER: 60,
+		TYPE_SYSTEMD:   60,
+		TYPE_LOGIN:     300,
 	}
 
-	SYS_TYPES = []string{TYPE_CPU, TYPE_DISK, TYPE_MEM, TYPE_NET, TYPE_COREDUMP, TYPE_FS, TYPE_TIME, TYPE_DEV}
+	SYS_TYPES = []string{TYPE_CPU, TYPE_DISK, TYPE_MEM, TYPE_NET, TYPE_COREDUMP, TYPE_FS, TYPE_TIME, TYPE_DEV, TYPE_POWER, TYPE_CONTAINER, TYPE_SYSTEMD, TYPE_LOGIN}
 )
 
 var (
@@ -0,0 +1,79 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lodastack/agent/agent/sysinfo"
+
+	"github.com/lodastack/log"
+)
+
+// CollectorsHandler lists the registered collectors on GET /collectors and
+// toggles one on POST /collectors/{name} with a body like {"enabled":false}
+func CollectorsHandler(w http.ResponseWriter, req *http.Request) {
+	name := strings.Trim(strings.TrimPrefix(req.URL.Path, "/collectors"), "/")
+	switch {
+	case name == "" && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, sysinfo.CollectorStatuses())
+	case name != "" && req.Method == http.MethodPost:
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Enabled == nil {
+			http.Error(w, `body must be {"enabled":true|false}`, http.StatusBadRequest)
+			return
+		}
+		if err := sysinfo.SetEnabled(name, *body.Enabled); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Infof("collector %s enabled set to %v by admin API", name, *body.Enabled)
+		for _, s := range sysinfo.CollectorStatuses() {
+			if s.Name == name {
+				writeJSON(w, http.StatusOK, s)
+				return
+			}
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// adminMux routes the admin API, it is kept off the public listener
+func adminMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/collectors", CollectorsHandler)
+	mux.HandleFunc("/collectors/", CollectorsHandler)
+	return mux
+}
+
+// startAdmin serves the admin API on addr in a separate goroutine
+func (s *Service) startAdmin(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprint("Listening admin API on HTTP:", listener.Addr().String()))
+	go func() {
+		err := http.Serve(listener, adminMux())
+		if err != nil && !strings.Contains(err.Error(), "closed") {
+			s.err <- fmt.Errorf("admin listener failed: addr=%s, err=%s", listener.Addr(), err)
+		}
+	}()
+	return nil
+}
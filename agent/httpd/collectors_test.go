@@ -0,0 +1,55 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/sysinfo"
+)
+
+func Test_CollectorsHandler(t *testing.T) {
+	sysinfo.RegisterFunc("TEST", "AdminFakeMetrics", func() []*common.Metric { return nil })
+	defer sysinfo.SetEnabled("AdminFakeMetrics", true)
+	mux := adminMux()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/collectors/AdminFakeMetrics", strings.NewReader(`{"enabled":false}`)))
+	if rec.Code != http.StatusOK || sysinfo.Enabled("AdminFakeMetrics") {
+		t.Fatalf("disable collector fatal: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/collectors", nil))
+	var list []sysinfo.CollectorStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("list collectors fatal: %s", err)
+	}
+	found := false
+	for _, s := range list {
+		if s.Name == "AdminFakeMetrics" {
+			found = !s.Enabled && s.Type == "TEST"
+		}
+	}
+	if !found {
+		t.Fatalf("toggled collector not listed as disabled: %s", rec.Body.String())
+	}
+
+	for _, c := range []struct {
+		method, path, body string
+		code               int
+	}{
+		{"POST", "/collectors/NoSuchMetrics", `{"enabled":false}`, http.StatusNotFound},
+		{"POST", "/collectors/AdminFakeMetrics", `{}`, http.StatusBadRequest},
+		{"DELETE", "/collectors", "", http.StatusMethodNotAllowed},
+	} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+		if rec.Code != c.code {
+			t.Fatalf("%s %s fatal: %d", c.method, c.path, rec.Code)
+		}
+	}
+}
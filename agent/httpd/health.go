@@ -0,0 +1,36 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/sysinfo"
+)
+
+// default freshness window of the last collection cycle, unit: second
+const defaultHealthzWindow = 300
+
+// HealthzHandler answers 200 while the collection is healthy and 503
+// otherwise, the body lists the last success and errors of every collector
+func HealthzHandler(w http.ResponseWriter, req *http.Request) {
+	window := defaultHealthzWindow
+	if common.Conf != nil && common.Conf.HealthzWindow > 0 {
+		window = common.Conf.HealthzWindow
+	}
+	writeHealth(w, sysinfo.Health(time.Now(), time.Duration(window)*time.Second))
+}
+
+func writeHealth(w http.ResponseWriter, s sysinfo.HealthStatus) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !s.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(body)
+}
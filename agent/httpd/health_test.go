@@ -0,0 +1,42 @@
+package httpd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/sysinfo"
+)
+
+func Test_writeHealth(t *testing.T) {
+	now := time.Now()
+	for _, c := range []struct {
+		status sysinfo.HealthStatus
+		code   int
+	}{
+		{sysinfo.HealthStatus{
+			Healthy:    true,
+			LastCycle:  now,
+			Collectors: map[string]sysinfo.CollectorHealth{"PsMetrics": {LastSuccess: now}},
+		}, http.StatusOK},
+		{sysinfo.HealthStatus{
+			LastCycle:  now.Add(-time.Hour),
+			Collectors: map[string]sysinfo.CollectorHealth{"PsMetrics": {LastSuccess: now.Add(-time.Hour), Errors: 3}},
+		}, http.StatusServiceUnavailable},
+	} {
+		rec := httptest.NewRecorder()
+		writeHealth(rec, c.status)
+		if rec.Code != c.code {
+			t.Fatalf("healthz status code fatal: %d, want %d", rec.Code, c.code)
+		}
+		var got sysinfo.HealthStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("healthz body fatal: %s: %s", err, rec.Body.String())
+		}
+		if got.Healthy != c.status.Healthy || got.Collectors["PsMetrics"].Errors != c.status.Collectors["PsMetrics"].Errors {
+			t.Fatalf("healthz body fatal: %s", rec.Body.String())
+		}
+	}
+}
@@ -310,6 +310,8 @@ func (s *Service) Start() error {
 	http.HandleFunc("/update", UpdateHandlder)
 	http.HandleFunc("/me/ns", GetNsHandler)
 	http.HandleFunc("/me/status", GetStatusHandler)
+	http.HandleFunc("/metrics", PrometheusHandler)
+	http.HandleFunc("/healthz", HealthzHandler)
 	//http.HandleFunc("/log/offset", LogOffsetHandler)
 	//fmt.Println("starting collect module http listener... on ", common.Conf.Listen)
 
@@ -362,6 +364,13 @@ func (s *Service) Start() error {
 		go s.serveUnixSocket()
 	}
 
+	// The admin API is only served on its own address.
+	if common.Conf != nil && common.Conf.AdminListen != "" {
+		if err := s.startAdmin(common.Conf.AdminListen); err != nil {
+			return err
+		}
+	}
+
 	// Begin listening for requests in a separate goroutine.
 	go s.serveTCP()
 	return nil
@@ -0,0 +1,139 @@
+package httpd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/sysinfo"
+)
+
+// PrometheusHandler renders the metrics of the last scheduled cycle of
+// every collect type in the Prometheus text format, a scrape never runs
+// the collectors itself
+func PrometheusHandler(w http.ResponseWriter, req *http.Request) {
+	buf := new(bytes.Buffer)
+	WritePrometheus(buf, sysinfo.LastMetrics())
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
+
+// WritePrometheus writes the metrics grouped by name, every name with its
+// HELP and TYPE lines. A series, a name with its labels, is written once
+// with the last value. Metrics with a non numeric value are skipped.
+func WritePrometheus(w io.Writer, metrics []*common.Metric) {
+	groups := make(map[string]map[string]string)
+	helps := make(map[string]string)
+	for _, m := range metrics {
+		v, ok := prometheusValue(m.Value)
+		if !ok {
+			continue
+		}
+		name, prefix := m.Name, ""
+		if common.Conf != nil {
+			name = common.RenameMetric(name, common.Conf.MetricRename)
+			prefix = common.Conf.MetricPrefix
+		}
+		name = prometheusName(common.PrefixMetric(name, prefix))
+		// metas are registered by the name the collector reports
+		if meta, ok := common.GetMetricMeta(m.Name); ok && meta.Help != "" {
+			helps[name] = meta.Help
+		}
+		if groups[name] == nil {
+			groups[name] = make(map[string]string)
+		}
+		groups[name][prometheusLabels(m.Tags)] = v
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if help, ok := helps[name]; ok {
+			fmt.Fprintf(w, "# HELP %s %s\n", name, strings.Replace(help, "\n", " ", -1))
+		}
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		series := groups[name]
+		labels := make([]string, 0, len(series))
+		for l := range series {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			io.WriteString(w, name+l+" "+series[l]+"\n")
+		}
+	}
+}
+
+// prometheusName maps a name onto [a-zA-Z_:][a-zA-Z0-9_:]*, e.g. dots
+// become underscores
+func prometheusName(name string) string {
+	return sanitizeName(name, true)
+}
+
+func sanitizeName(name string, colon bool) string {
+	b := []byte(name)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		case c == ':' && colon:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// prometheusLabels renders the tags sorted by key, e.g. {core="0",host="a"}
+func prometheusLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	labels := make([]string, 0, len(keys))
+	for _, k := range keys {
+		labels = append(labels, sanitizeName(k, false)+`="`+prometheusEscaper.Replace(tags[k])+`"`)
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func prometheusValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	case int:
+		return strconv.FormatInt(int64(v), 10), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+package httpd
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// a sample line of the text format: name{label="value",...} value
+var prometheusLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{([a-zA-Z_][a-zA-Z0-9_]*="([^"\\]|\\.)*",?)*\})? \S+$`)
+
+func Test_WritePrometheus(t *testing.T) {
+	common.SetMetricMeta("mem.used.percent", "percent", "used memory")
+	metrics := []*common.Metric{
+		{Name: "mem.used.percent", Value: 42.5},
+		{Name: "cpu.idle.core", Value: 90.0, Tags: map[string]string{"core": "1"}},
+		{Name: "cpu.idle.core", Value: 80.0, Tags: map[string]string{"core": "0"}},
+		{Name: "kernel.user.login", Value: 1, Tags: map[string]string{"user": `a "quoted" \ name`, "host": "10.0.0.1"}},
+		{Name: "agent.status", Value: "running"},
+	}
+	buf := new(bytes.Buffer)
+	WritePrometheus(buf, metrics)
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+		if !prometheusLine.MatchString(line) {
+			t.Fatalf("invalid prometheus line: %s", line)
+		}
+	}
+	want := []string{
+		"# HELP mem_used_percent used memory\n# TYPE mem_used_percent gauge\nmem_used_percent 42.5\n",
+		"# TYPE cpu_idle_core gauge\ncpu_idle_core{core=\"0\"} 80\ncpu_idle_core{core=\"1\"} 90\n",
+		`kernel_user_login{host="10.0.0.1",user="a \"quoted\" \\ name"} 1`,
+	}
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Fatalf("prometheus output fatal, want %q in:\n%s", w, out)
+		}
+	}
+	if strings.Contains(out, "agent_status") {
+		t.Fatalf("non numeric metric should be skipped:\n%s", out)
+	}
+}
+
+func Test_prometheusName(t *testing.T) {
+	cases := map[string]string{
+		"net.if.in_bytes":           "net_if_in_bytes",
+		"agent.collect.duration_ms": "agent_collect_duration_ms",
+		"1min-load":                 "_min_load",
+	}
+	for in, out := range cases {
+		if name := prometheusName(in); name != out {
+			t.Fatalf("prometheus name fatal: %s - %s, want %s", in, name, out)
+		}
+	}
+}
+
+func Test_WritePrometheusDuplicateSeries(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{MetricRename: map[string]string{"mem.swap.used.percent": "swap.used"}}
+	common.SetMetricMeta("mem.swap.used.percent", "percent", "used swap")
+
+	tags := map[string]string{"user": "root", "host": "10.0.0.1"}
+	metrics := []*common.Metric{
+		{Name: "kernel.user.login", Value: 1, Tags: tags},
+		{Name: "kernel.user.login", Value: 1, Tags: tags},
+		{Name: "mem.swap.used.percent", Value: 10.0},
+	}
+	buf := new(bytes.Buffer)
+	WritePrometheus(buf, metrics)
+	out := buf.String()
+
+	if n := strings.Count(out, `kernel_user_login{host="10.0.0.1",user="root"}`); n != 1 {
+		t.Fatalf("duplicate series written %d times:\n%s", n, out)
+	}
+	if !strings.Contains(out, "# HELP swap_used used swap\n") {
+		t.Fatalf("help of a renamed metric fatal:\n%s", out)
+	}
+}
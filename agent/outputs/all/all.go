@@ -1,5 +1,6 @@
 package all
 
 import (
+	_ "github.com/lodastack/agent/agent/outputs/file"
 	_ "github.com/lodastack/agent/agent/outputs/nsq"
 )
@@ -4,4 +4,19 @@ type Config struct {
 	Name       string   `toml:"name"`
 	Servers    []string `toml:"servers"`
 	BufferSize int      `toml:"buffersize"`
+
+	// max points of one write to the backend, larger groups are split, default 1000
+	MaxBatchSize int `toml:"maxbatchsize"`
+
+	// attempts of every write to the backend, default 3
+	PushRetries int `toml:"pushretries"`
+	// delay before the first retry, doubled every retry, unit: millisecond, default 200
+	PushBackoff int `toml:"pushbackoff"`
+
+	// http or socks5 proxy to reach the backend, default from HTTP_PROXY/HTTPS_PROXY
+	ProxyURL string `toml:"proxyurl"`
+	// keep the data failed to deliver in this directory, disabled if empty
+	SpoolDir string `toml:"spooldir"`
+	// size cap of the spool directory, default 100MB
+	SpoolMaxBytes int64 `toml:"spoolmaxbytes"`
 }
@@ -0,0 +1,38 @@
+package outputs
+
+import (
+	"github.com/lodastack/agent/agent/common"
+)
+
+// decorate applies the config driven changes to a metric before it is
+// turned into a point, and stamps the host identity and global tags on it
+// without overwriting the tags set by the collector
+func decorate(metric *common.Metric, host, ip string) {
+	if metric.Tags == nil {
+		metric.Tags = make(map[string]string)
+	}
+	if h, ok := metric.Tags["host"]; !ok || h == "" {
+		metric.Tags["host"] = host
+	}
+	if _, ok := metric.Tags["ip"]; !ok && ip != "" {
+		metric.Tags["ip"] = ip
+	}
+	if common.Conf == nil {
+		return
+	}
+	for k, v := range common.Conf.GlobalTags {
+		if _, ok := metric.Tags[k]; !ok {
+			metric.Tags[k] = v
+		}
+	}
+	metric.Name = common.RenameMetric(metric.Name, common.Conf.MetricRename)
+	metric.Name = common.PrefixMetric(metric.Name, common.Conf.MetricPrefix)
+}
+
+// hostname returns the configured hostname, or the one of the system
+func hostname() (string, error) {
+	if common.Conf != nil && common.Conf.Hostname != "" {
+		return common.Conf.Hostname, nil
+	}
+	return common.Hostname()
+}
@@ -0,0 +1,83 @@
+package outputs
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_decorate(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{Hostname: "web-01"}
+
+	host, err := hostname()
+	if err != nil || host != "web-01" {
+		t.Fatalf("hostname override fatal: %s - %v", host, err)
+	}
+
+	m := &common.Metric{Name: "cpu.idle", Tags: map[string]string{}}
+	decorate(m, host, "10.0.0.1")
+	if m.Tags["host"] != "web-01" || m.Tags["ip"] != "10.0.0.1" {
+		t.Fatalf("decorate host identity fatal: %v", m.Tags)
+	}
+
+	m = &common.Metric{Name: "cpu.idle", Tags: map[string]string{"host": "db-01", "ip": "10.0.0.2"}}
+	decorate(m, host, "10.0.0.1")
+	if m.Tags["host"] != "db-01" || m.Tags["ip"] != "10.0.0.2" {
+		t.Fatalf("decorate should keep collector tags: %v", m.Tags)
+	}
+
+	m = &common.Metric{Name: "cpu.idle", Tags: map[string]string{}}
+	decorate(m, host, "")
+	if _, ok := m.Tags["ip"]; ok {
+		t.Fatalf("decorate without ip fatal: %v", m.Tags)
+	}
+}
+
+func Test_decorateMetricPrefix(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+
+	common.Conf = &common.AgentConfig{MetricPrefix: "prod1"}
+	m := &common.Metric{Name: "kernel.files.max", Tags: map[string]string{}}
+	decorate(m, "web-01", "")
+	if m.Name != "prod1.kernel.files.max" {
+		t.Fatalf("decorate metric prefix fatal: %s", m.Name)
+	}
+
+	common.Conf = &common.AgentConfig{
+		MetricPrefix: "prod1.",
+		MetricRename: map[string]string{"kernel.*": "system.*"},
+	}
+	m = &common.Metric{Name: "kernel.files.max", Tags: map[string]string{}}
+	decorate(m, "web-01", "")
+	if m.Name != "prod1.system.files.max" {
+		t.Fatalf("decorate prefix after rename fatal: %s", m.Name)
+	}
+
+	common.Conf = &common.AgentConfig{}
+	m = &common.Metric{Name: "kernel.files.max", Tags: map[string]string{}}
+	decorate(m, "web-01", "")
+	if m.Name != "kernel.files.max" {
+		t.Fatalf("decorate empty prefix fatal: %s", m.Name)
+	}
+}
+
+func Test_decorateGlobalTags(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{GlobalTags: map[string]string{"env": "prod", "dc": "bj01", "host": "global"}}
+
+	m := &common.Metric{Name: "cpu.idle", Tags: map[string]string{"dc": "sh02"}}
+	decorate(m, "web-01", "")
+	if m.Tags["env"] != "prod" || m.Tags["dc"] != "sh02" || m.Tags["host"] != "web-01" {
+		t.Fatalf("decorate global tags fatal: %v", m.Tags)
+	}
+
+	m = &common.Metric{Name: "cpu.idle"}
+	decorate(m, "web-01", "10.0.0.1")
+	if len(m.Tags) != 4 || m.Tags["env"] != "prod" || m.Tags["dc"] != "bj01" || m.Tags["ip"] != "10.0.0.1" {
+		t.Fatalf("decorate nil tags fatal: %v", m.Tags)
+	}
+}
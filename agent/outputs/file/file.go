@@ -0,0 +1,78 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/outputs"
+)
+
+const NAME = "file"
+
+// File appends every write as one JSON line to a file, a named pipe or
+// stdout, so the points can be inspected or piped into other tools
+type File struct {
+	// path written to, stdout if empty or "-"
+	Path string
+
+	lock sync.Mutex
+}
+
+// batch is one line of output
+type batch struct {
+	Namespace string          `json:"namespace"`
+	Points    []*common.Point `json:"points"`
+}
+
+func (f *File) Description() string {
+	return "Write measurements as JSON lines to a file or stdout"
+}
+
+// SetServers takes the path from the first server
+func (f *File) SetServers(servers []string) {
+	f.Path = ""
+	if len(servers) > 0 {
+		f.Path = servers[0]
+	}
+}
+
+func (f *File) Name() string {
+	return NAME
+}
+
+func (f *File) Write(data outputs.Data) error {
+	line := batch{Namespace: data.Namespace, Points: []*common.Point{}}
+	if data.Points != nil && data.Points.Points != nil {
+		line.Points = data.Points.Points
+	}
+	body, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("marshal datapoint failed: %s", err)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var w io.Writer = os.Stdout
+	if f.Path != "" && f.Path != "-" {
+		// opened on every write, so the file can be rotated and a pipe
+		// reader can come and go
+		file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
+
+func init() {
+	outputs.Add(NAME, func() outputs.OutputInf {
+		return &File{}
+	})
+}
@@ -0,0 +1,59 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/outputs"
+)
+
+func Test_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-output-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.json")
+
+	creator, ok := outputs.Outputs[NAME]
+	if !ok {
+		t.Fatalf("file output not registered")
+	}
+	f := creator()
+	f.SetServers([]string{path})
+	points := &common.Points{Database: "collect.test", Points: []*common.Point{
+		{Measurement: "cpu.idle", Timestamp: 1500000000, Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"value": 98.5}},
+		{Measurement: "mem.free", Timestamp: 1500000000, Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"value": 1024}},
+	}}
+	for i := 0; i < 2; i++ {
+		if err := f.Write(outputs.Data{Namespace: "collect.test", Points: points}); err != nil {
+			t.Fatalf("write fatal: %s", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open output fatal: %s", err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var b batch
+		if err := json.Unmarshal(scanner.Bytes(), &b); err != nil {
+			t.Fatalf("output line is no valid batch: %s", err)
+		}
+		if b.Namespace != "collect.test" || len(b.Points) != 2 || b.Points[0].Measurement != "cpu.idle" || b.Points[0].Fields["value"] != 98.5 {
+			t.Fatalf("output batch fatal: %+v", b)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Fatalf("output lines fatal: %d", lines)
+	}
+}
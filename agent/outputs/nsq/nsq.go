@@ -8,7 +8,7 @@ import (
 	"math/rand"
 	"net"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
 	"github.com/lodastack/agent/agent/outputs"
@@ -20,6 +20,7 @@ const NAME = "nsq"
 
 type NSQ struct {
 	Servers []string
+	Proxy   func(*http.Request) (*url.URL, error)
 }
 
 const timeout = 2 * time.Second
@@ -32,45 +33,41 @@ func (n *NSQ) SetServers(servers []string) {
 	n.Servers = servers
 }
 
-func (n *NSQ) Write(queue chan outputs.Data) {
-	for {
-		data := <-queue
-		body, err := json.Marshal(data.Points)
-		if err != nil {
-			log.Error("marshal datapoint:", data, " failed. error:", err)
-			continue
-		}
+func (n *NSQ) SetProxy(proxy func(*http.Request) (*url.URL, error)) {
+	n.Proxy = proxy
+}
 
-		l := len(n.Servers)
-		p := rand.Perm(l)
-		for i, idx := range p {
-			//log.Debug("send to " + Conf.NsqServers[idx])
-			err = httpPost(n.Servers[idx], body, data.Namespace)
-			if err == nil {
-				break
-			}
-			log.Warning("Publish to nsq failed: ", err)
-			if i == l-1 {
-				if !strings.Contains(err.Error(), "connection refused") {
-					log.Error("send to nsq failed:", err.Error(), "discard message, namespace: ", data.Namespace, " data: ", string(body))
-				} else {
-					select {
-					case queue <- data:
-					default:
-						log.Error("queue is full, discard message, namespace: ", data.Namespace, " data: ", string(body))
-					}
-				}
-			} else {
-				time.Sleep(time.Millisecond * time.Duration(100*i))
-			}
+func (n *NSQ) Name() string {
+	return NAME
+}
+
+func (n *NSQ) Write(data outputs.Data) error {
+	body, err := json.Marshal(data.Points)
+	if err != nil {
+		return fmt.Errorf("marshal datapoint failed: %s", err)
+	}
+
+	err = errors.New("no nsq server configured")
+	l := len(n.Servers)
+	for i, idx := range rand.Perm(l) {
+		//log.Debug("send to " + Conf.NsqServers[idx])
+		err = httpPost(n.Servers[idx], body, data.Namespace, n.Proxy)
+		if err == nil {
+			return nil
+		}
+		log.Warning("Publish to nsq failed: ", err)
+		if i < l-1 {
+			time.Sleep(time.Millisecond * time.Duration(100*i))
 		}
 	}
+	return err
 }
 
-func httpPost(addr string, data []byte, namespace string) error {
+func httpPost(addr string, data []byte, namespace string, proxy func(*http.Request) (*url.URL, error)) error {
 	url := fmt.Sprintf("http://%s/put?topic=%s", addr, namespace)
 	client := &http.Client{
 		Transport: &http.Transport{
+			Proxy: proxy,
 			Dial: func(netw, addr string) (net.Conn, error) {
 				deadline := time.Now().Add(timeout)
 				c, err := net.DialTimeout(netw, addr, timeout)
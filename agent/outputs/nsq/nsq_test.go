@@ -0,0 +1,38 @@
+package nsq
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/outputs"
+)
+
+func Test_WriteThroughProxy(t *testing.T) {
+	var target, body string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a proxied request carries the absolute URL of the backend
+		target = r.URL.String()
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	n := &NSQ{}
+	n.SetServers([]string{"nsq.test.invalid:4151"})
+	n.SetProxy(http.ProxyURL(proxyURL))
+	data := outputs.Data{Namespace: "collect.test", Points: &common.Points{Database: "collect.test"}}
+	if err := n.Write(data); err != nil {
+		t.Fatalf("write through proxy fatal: %s", err)
+	}
+	if target != "http://nsq.test.invalid:4151/put?topic=collect.test" {
+		t.Fatalf("proxied request fatal: %s", target)
+	}
+	if body == "" {
+		t.Fatalf("proxied request has no body")
+	}
+}
@@ -1,7 +1,11 @@
 package outputs
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/lodastack/agent/agent/common"
@@ -9,11 +13,18 @@ import (
 	"github.com/lodastack/log"
 )
 
+// OutputInf is the delivery side every output implements. Write takes a Data
+// rather than []*common.Metric: SendMetrics has already decorated, filtered
+// and converted the metrics to points, and the namespace they belong to is
+// the database or topic an output writes to.
 type OutputInf interface {
 	// SetServers sets backend servers
 	SetServers(servers []string)
-	// Write takes in group of points to be written to the Output
-	Write(chan Data)
+	// Write delivers one group of points to the backend, returning an
+	// error if it was not accepted
+	Write(data Data) error
+	// Name returns the name the Output is registered with
+	Name() string
 	// Description returns a one-sentence description on the Output
 	Description() string
 }
@@ -40,6 +51,20 @@ func SendMetrics(ctype string, namespace string, _metrics []*common.Metric) erro
 	if len(_metrics) == 0 || _metrics == nil {
 		return nil
 	}
+	host, err := hostname()
+	if err != nil {
+		log.Errorf("get hostname failed: %s", err.Error())
+		return err
+	}
+	strategy, rules := "", common.SanitizeRules{}
+	if common.Conf != nil {
+		strategy, rules = common.Conf.IPStrategy, common.Conf.SanitizeRules
+	}
+	ip, err := common.PrimaryIP(strategy)
+	if err != nil {
+		log.Debugf("no ip tag added: %s", err)
+	}
+
 	// avoid multi-NS panic, deep copy metrics
 	metrics := make([]common.Metric, len(_metrics))
 	for index, _metric := range _metrics {
@@ -53,6 +78,9 @@ func SendMetrics(ctype string, namespace string, _metrics []*common.Metric) erro
 		for k, v := range _metric.Tags {
 			metric.Tags[k] = v
 		}
+		common.ApplyPrecision(&metric)
+		decorate(&metric, host, ip)
+		common.Sanitize(&metric, rules)
 		metrics[index] = metric
 	}
 	// filter topic
@@ -60,23 +88,12 @@ func SendMetrics(ctype string, namespace string, _metrics []*common.Metric) erro
 
 	data := &common.Points{Database: namespace, RetentionPolicy: "default", Precision: "s"}
 	now := time.Now().Unix()
-	hostname, err := common.Hostname()
-	if err != nil {
-		log.Errorf("get hostname failed: %s", err.Error())
-		return err
-	}
-	for _, metric := range metrics {
-		if metric.Tags == nil {
-			metric.Tags = map[string]string{"host": hostname}
-		} else {
-			// Dont overwrite user's host
-			if host, ok := metric.Tags["host"]; !ok || host == "" {
-				metric.Tags["host"] = hostname
-			}
-		}
-		if metric.Timestamp < 1e9 || metric.Timestamp > 1e10 {
-			metric.Timestamp = now
+	for i := range metrics {
+		if metrics[i].Timestamp < 1e9 || metrics[i].Timestamp > 1e10 {
+			metrics[i].Timestamp = now
 		}
+	}
+	for _, metric := range common.Dedup(common.FilterValid(metrics)) {
 		log.Info("namespace:", namespace, " metric:", metric.String())
 		p := &common.Point{metric.Name, metric.Timestamp, metric.Tags, map[string]interface{}{"value": metric.Value}}
 		if ctype == common.TYPE_LOG {
@@ -84,6 +101,9 @@ func SendMetrics(ctype string, namespace string, _metrics []*common.Metric) erro
 		}
 		data.Points = append(data.Points, p)
 	}
+	if len(data.Points) == 0 {
+		return nil
+	}
 
 	select {
 	case queue <- Data{namespace, data}:
@@ -97,6 +117,9 @@ func SendMetrics(ctype string, namespace string, _metrics []*common.Metric) erro
 // Output runs collects data based on the given config.
 type Output struct {
 	Config *Config
+
+	proxy func(*http.Request) (*url.URL, error)
+	spool *spool
 }
 
 // New returns an Output struct based off the given Config.
@@ -107,6 +130,11 @@ func New(config *Config) (*Output, error) {
 	if o.Config.BufferSize <= 0 {
 		o.Config.BufferSize = 1 << 16
 	}
+	proxy, err := proxyFunc(o.Config)
+	if err != nil {
+		return nil, err
+	}
+	o.proxy = proxy
 	return o, nil
 }
 
@@ -118,5 +146,96 @@ func (o *Output) Start() {
 	}
 	output := creator()
 	output.SetServers(o.Config.Servers)
-	output.Write(queue)
+	if p, ok := output.(ProxyOutput); ok {
+		p.SetProxy(o.proxy)
+	}
+	if o.Config.SpoolDir != "" {
+		s, err := newSpool(o.Config.SpoolDir, o.Config.SpoolMaxBytes)
+		if err != nil {
+			log.Errorf("init spool dir %s failed, run without spool: %s", o.Config.SpoolDir, err)
+		} else {
+			o.spool = s
+		}
+	}
+	for data := range queue {
+		o.push(output, data)
+	}
+}
+
+// default max points of one write to the backend
+const defaultMaxBatchSize = 1000
+
+func (o *Output) maxBatchSize() int {
+	if o.Config.MaxBatchSize > 0 {
+		return o.Config.MaxBatchSize
+	}
+	return defaultMaxBatchSize
+}
+
+// push writes one queued group of points in batches of maxbatchsize, a
+// failed batch does not stop the following ones
+func (o *Output) push(output OutputInf, data Data) {
+	batches := splitData(data, o.maxBatchSize())
+	failed := 0
+	for _, batch := range batches {
+		if err := o.write(output, batch); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 && len(batches) > 1 {
+		log.Errorf("send to %s: %d of %d batches failed, namespace: %s", output.Name(), failed, len(batches), data.Namespace)
+	}
+}
+
+// splitData splits the points into groups of at most size points, all of
+// the same namespace and database
+func splitData(data Data, size int) []Data {
+	if size <= 0 || data.Points == nil || len(data.Points.Points) <= size {
+		return []Data{data}
+	}
+	var res []Data
+	points := data.Points.Points
+	for len(points) > 0 {
+		n := size
+		if len(points) < n {
+			n = len(points)
+		}
+		batch := *data.Points
+		batch.Points = points[:n:n]
+		res = append(res, Data{Namespace: data.Namespace, Points: &batch})
+		points = points[n:]
+	}
+	return res
+}
+
+// write hands one group of points to the output and decides what happens
+// to it when delivery fails, the delivery error is returned
+func (o *Output) write(output OutputInf, data Data) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pushRetryTimeout)
+	err := retryWrite(ctx, func() error { return output.Write(data) }, o.pushRetries(), o.pushBackoff())
+	cancel()
+	if err == nil {
+		if o.spool != nil {
+			o.spool.drain(output.Write)
+		}
+		return nil
+	}
+	if o.spool != nil {
+		log.Errorf("send to %s failed: %s, spool message, namespace: %s", output.Name(), err.Error(), data.Namespace)
+		if err := o.spool.push(data); err != nil {
+			log.Errorf("spool failed: %s, discard message, namespace: %s", err.Error(), data.Namespace)
+		}
+		return err
+	}
+	if strings.Contains(err.Error(), "connection refused") {
+		// backend is down, keep the data in queue and retry later
+		select {
+		case queue <- data:
+		default:
+			log.Errorf("queue is full, discard message, namespace: %s", data.Namespace)
+		}
+		return err
+	}
+	log.Errorf("send to %s failed: %s, discard message, namespace: %s", output.Name(), err.Error(), data.Namespace)
+	return err
 }
@@ -0,0 +1,72 @@
+package outputs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func testPoints(ns string, n int) Data {
+	points := make([]*common.Point, n)
+	for i := range points {
+		points[i] = &common.Point{Measurement: "cpu.idle", Timestamp: int64(1500000000 + i), Fields: map[string]interface{}{"value": float64(i)}}
+	}
+	return Data{Namespace: ns, Points: &common.Points{Database: ns, RetentionPolicy: "default", Precision: "s", Points: points}}
+}
+
+func Test_pushBatches(t *testing.T) {
+	o := &Output{Config: &Config{PushRetries: 1, MaxBatchSize: 1000}}
+	out := &fakeOutput{}
+	o.push(out, testPoints("collect.a", 2500))
+
+	if len(out.written) != 3 {
+		t.Fatalf("push batches fatal: %d writes", len(out.written))
+	}
+	next := int64(1500000000)
+	for i, want := range []int{1000, 1000, 500} {
+		batch := out.written[i]
+		if batch.Namespace != "collect.a" || batch.Points.Database != "collect.a" || batch.Points.Precision != "s" {
+			t.Fatalf("push batch %d header fatal: %+v", i, batch.Points)
+		}
+		if len(batch.Points.Points) != want {
+			t.Fatalf("push batch %d size fatal: %d, want %d", i, len(batch.Points.Points), want)
+		}
+		// in order, nothing lost or repeated
+		for _, p := range batch.Points.Points {
+			if p.Timestamp != next {
+				t.Fatalf("push batch %d order fatal: %d, want %d", i, p.Timestamp, next)
+			}
+			next++
+		}
+	}
+
+	out = &fakeOutput{}
+	o.push(out, testPoints("collect.a", 10))
+	if len(out.written) != 1 {
+		t.Fatalf("push small group fatal: %d writes", len(out.written))
+	}
+}
+
+// flakyOutput fails its nth write
+type flakyOutput struct {
+	fakeOutput
+	writes, failAt int
+}
+
+func (f *flakyOutput) Write(data Data) error {
+	f.writes++
+	if f.writes == f.failAt {
+		return errors.New("i/o timeout")
+	}
+	return f.fakeOutput.Write(data)
+}
+
+func Test_pushBatchFailure(t *testing.T) {
+	o := &Output{Config: &Config{PushRetries: 1, MaxBatchSize: 1000}}
+	out := &flakyOutput{failAt: 2}
+	o.push(out, testPoints("collect.a", 2500))
+	if out.writes != 3 || len(out.written) != 2 || len(out.written[1].Points.Points) != 500 {
+		t.Fatalf("push after failed batch fatal: %d writes, %d written", out.writes, len(out.written))
+	}
+}
@@ -0,0 +1,43 @@
+package outputs
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ProxyOutput is implemented by outputs which can reach the backend
+// through a proxy, proxy is meant for http.Transport.Proxy
+type ProxyOutput interface {
+	SetProxy(proxy func(*http.Request) (*url.URL, error))
+}
+
+// parseProxyURL validates a http, https or socks5 proxy URL
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxyurl %q: %s", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxyurl %q: unsupported scheme %q", raw, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxyurl %q: no host", raw)
+	}
+	return u, nil
+}
+
+// proxyFunc returns the proxy of the config, falling back to the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func proxyFunc(c *Config) (func(*http.Request) (*url.URL, error), error) {
+	if c.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := parseProxyURL(c.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(u), nil
+}
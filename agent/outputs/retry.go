@@ -0,0 +1,59 @@
+package outputs
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	// default attempts of every write, the first one included
+	defaultPushRetries = 3
+	// default delay before the first retry, doubled every retry
+	defaultPushBackoff = 200 * time.Millisecond
+	// retries of one group of points give up after this, the shortest
+	// collect interval, so they don't pile up with the next cycle
+	pushRetryTimeout = 10 * time.Second
+)
+
+func (o *Output) pushRetries() int {
+	if o.Config.PushRetries > 0 {
+		return o.Config.PushRetries
+	}
+	return defaultPushRetries
+}
+
+func (o *Output) pushBackoff() time.Duration {
+	if o.Config.PushBackoff > 0 {
+		return time.Duration(o.Config.PushBackoff) * time.Millisecond
+	}
+	return defaultPushBackoff
+}
+
+// retryWrite calls write up to attempts times, sleeping backoff with
+// jitter between tries and doubling it each time. It returns the last error
+// once the attempts are used up or the next sleep would pass the deadline
+// of ctx.
+func retryWrite(ctx context.Context, write func() error, attempts int, backoff time.Duration) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = write(); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		// 50% - 150% of the backoff
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff)+1))
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(sleep).After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(sleep):
+		}
+		backoff *= 2
+	}
+	return err
+}
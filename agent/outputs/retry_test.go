@@ -0,0 +1,46 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyWrite fails the first n calls
+func flakyWrite(n int, calls *int) func() error {
+	return func() error {
+		*calls++
+		if *calls <= n {
+			return errors.New("i/o timeout")
+		}
+		return nil
+	}
+}
+
+func Test_retryWrite(t *testing.T) {
+	calls := 0
+	if err := retryWrite(context.Background(), flakyWrite(2, &calls), 3, time.Millisecond); err != nil || calls != 3 {
+		t.Fatalf("retry write fatal: %v - %d", err, calls)
+	}
+
+	calls = 0
+	if err := retryWrite(context.Background(), flakyWrite(5, &calls), 3, time.Millisecond); err == nil || calls != 3 {
+		t.Fatalf("retry write should give up after 3 attempts: %v - %d", err, calls)
+	}
+}
+
+func Test_retryWriteDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := retryWrite(ctx, flakyWrite(5, &calls), 5, 40*time.Millisecond)
+	if err == nil || calls >= 5 {
+		t.Fatalf("retry write should stop at the deadline: %v - %d", err, calls)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("retry write passed the deadline: %s", time.Since(start))
+	}
+}
@@ -0,0 +1,169 @@
+package outputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/log"
+)
+
+// default size cap of the spool directory, 100MB
+const defaultSpoolMaxBytes = 100 << 20
+
+const spoolSuffix = ".json"
+
+// spoolFile is the content of one spooled group of points
+type spoolFile struct {
+	Namespace string          `json:"namespace"`
+	Metrics   json.RawMessage `json:"metrics"`
+}
+
+// spool keeps the data an output failed to deliver on disk, one file per
+// group named by the time it was spooled so they drain oldest-first
+type spool struct {
+	lock     sync.Mutex
+	dir      string
+	maxBytes int64
+	seq      uint64
+}
+
+func newSpool(dir string, maxBytes int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultSpoolMaxBytes
+	}
+	return &spool{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// push writes data to a new spool file and drops the oldest files if the
+// spool grows over its cap
+func (s *spool) push(data Data) error {
+	body, err := marshalSpool(data)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.seq++
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, spoolSuffix))
+	// write then rename so drain never reads a partial file
+	if err = ioutil.WriteFile(name+".tmp", body, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+	s.enforceCap()
+	return nil
+}
+
+// drain hands the spooled data to write oldest-first, removing every file
+// delivered. It stops at the first failure and keeps the rest.
+func (s *spool) drain(write func(Data) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, name := range s.files() {
+		path := filepath.Join(s.dir, name)
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Errorf("read spool file %s failed: %s", path, err)
+			continue
+		}
+		data, err := unmarshalSpool(body)
+		if err != nil {
+			log.Errorf("invalid spool file %s, remove it: %s", path, err)
+			os.Remove(path)
+			continue
+		}
+		if err = write(data); err != nil {
+			return
+		}
+		os.Remove(path)
+	}
+}
+
+// enforceCap removes the oldest files until the spool fits maxBytes
+func (s *spool) enforceCap() {
+	names := s.files()
+	sizes := make([]int64, len(names))
+	var total int64
+	for i, name := range names {
+		if fi, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+			sizes[i] = fi.Size()
+			total += sizes[i]
+		}
+	}
+	for i := 0; i < len(names) && total > s.maxBytes; i++ {
+		log.Errorf("spool is over %d bytes, drop the oldest file %s", s.maxBytes, names[i])
+		if err := os.Remove(filepath.Join(s.dir, names[i])); err == nil {
+			total -= sizes[i]
+		}
+	}
+}
+
+// files returns the spool files sorted oldest-first
+func (s *spool) files() []string {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		log.Errorf("read spool dir %s failed: %s", s.dir, err)
+		return nil
+	}
+	var names []string
+	for _, fi := range fis {
+		if !fi.IsDir() && strings.HasSuffix(fi.Name(), spoolSuffix) {
+			names = append(names, fi.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func marshalSpool(data Data) ([]byte, error) {
+	var metrics []*common.Metric
+	if data.Points != nil {
+		for _, p := range data.Points.Points {
+			m := &common.Metric{Name: p.Measurement, Timestamp: p.Timestamp, Tags: p.Tags, Value: p.Fields["value"]}
+			if offset, ok := p.Fields["offset"].(int64); ok {
+				m.Offset = offset
+			}
+			metrics = append(metrics, m)
+		}
+	}
+	body, err := common.MarshalBatch(metrics)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(spoolFile{Namespace: data.Namespace, Metrics: body})
+}
+
+func unmarshalSpool(body []byte) (Data, error) {
+	var f spoolFile
+	if err := json.Unmarshal(body, &f); err != nil {
+		return Data{}, err
+	}
+	metrics, err := common.UnmarshalBatch(f.Metrics)
+	if err != nil {
+		return Data{}, err
+	}
+	points := &common.Points{Database: f.Namespace, RetentionPolicy: "default", Precision: "s"}
+	for _, m := range metrics {
+		p := &common.Point{Measurement: m.Name, Timestamp: m.Timestamp, Tags: m.Tags, Fields: map[string]interface{}{"value": m.Value}}
+		if m.Offset != 0 {
+			p.Fields["offset"] = m.Offset
+		}
+		points.Points = append(points.Points, p)
+	}
+	return Data{Namespace: f.Namespace, Points: points}, nil
+}
@@ -0,0 +1,98 @@
+package outputs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type fakeOutput struct {
+	down    bool
+	written []Data
+}
+
+func (f *fakeOutput) SetServers(servers []string) {}
+func (f *fakeOutput) Name() string                { return "fake" }
+func (f *fakeOutput) Description() string         { return "fake output for test" }
+
+func (f *fakeOutput) Write(data Data) error {
+	if f.down {
+		return errors.New("i/o timeout")
+	}
+	f.written = append(f.written, data)
+	return nil
+}
+
+func testData(ns string, value float64) Data {
+	return Data{Namespace: ns, Points: &common.Points{Database: ns, Points: []*common.Point{
+		{Measurement: "cpu.idle", Timestamp: 1500000000, Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"value": value}},
+	}}}
+}
+
+func Test_spool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("new spool fatal: %s", err)
+	}
+	o := &Output{Config: &Config{PushRetries: 1}, spool: s}
+	out := &fakeOutput{down: true}
+
+	// spill on failure
+	o.write(out, testData("collect.a", 1))
+	o.write(out, testData("collect.b", 2))
+	if files := s.files(); len(files) != 2 {
+		t.Fatalf("spool on failure fatal: %d", len(files))
+	}
+
+	// drain on recovery, oldest first
+	out.down = false
+	o.write(out, testData("collect.c", 3))
+	if len(out.written) != 3 || len(s.files()) != 0 {
+		t.Fatalf("drain spool fatal: %d - %d", len(out.written), len(s.files()))
+	}
+	if out.written[1].Namespace != "collect.a" || out.written[2].Namespace != "collect.b" {
+		t.Fatalf("drain spool order fatal: %s %s", out.written[1].Namespace, out.written[2].Namespace)
+	}
+	p := out.written[1].Points.Points[0]
+	if p.Measurement != "cpu.idle" || p.Tags["host"] != "a" || p.Fields["value"].(float64) != 1 {
+		t.Fatalf("spooled point fatal: %v", p)
+	}
+}
+
+func Test_spoolCap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	body, _ := marshalSpool(testData("collect.a", 1))
+	// room for two files
+	s, err := newSpool(dir, int64(len(body))*2)
+	if err != nil {
+		t.Fatalf("new spool fatal: %s", err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := s.push(testData("collect.a", float64(i))); err != nil {
+			t.Fatalf("push spool fatal: %s", err)
+		}
+	}
+	if len(s.files()) != 2 {
+		t.Fatalf("spool cap fatal: %d", len(s.files()))
+	}
+
+	out := &fakeOutput{}
+	s.drain(out.Write)
+	if len(out.written) != 2 || out.written[0].Points.Points[0].Fields["value"].(float64) != 2 {
+		t.Fatalf("spool cap should drop the oldest: %d", len(out.written))
+	}
+}
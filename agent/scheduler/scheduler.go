@@ -126,33 +126,11 @@ func updateGoPlugin(collectors map[string]goplugin.Collector) {
 	}
 }
 
-func getFuncsByType(t string) (ret []func() []*common.Metric) {
-	switch t {
-	case common.TYPE_CPU:
-		ret = append(ret, sysinfo.AgentMetrics, sysinfo.CpuMetrics, sysinfo.PsMetrics)
-	case common.TYPE_DISK:
-		ret = append(ret, sysinfo.IOStatsMetrics)
-	case common.TYPE_MEM:
-		ret = append(ret, sysinfo.MemMetrics)
-	case common.TYPE_FS:
-		ret = append(ret, sysinfo.FsKernelMetrics, sysinfo.FsRWMetrics, sysinfo.FsSpaceMetrics)
-	case common.TYPE_TIME:
-		ret = append(ret, sysinfo.TimeMetrics)
-	case common.TYPE_DEV:
-		ret = append(ret, sysinfo.PcapMetrics)
-	case common.TYPE_NET:
-		ret = append(ret, sysinfo.NetMetrics, sysinfo.SocketStatSummaryMetrics)
-	case common.TYPE_COREDUMP:
-		ret = append(ret, sysinfo.CoreDumpMetrics)
-	}
-	return ret
-}
-
 func updateSys(intervals map[string]int) {
 	mutex.Lock()
 	defer mutex.Unlock()
 	for _, t := range append(common.SYS_TYPES, common.TYPE_PORT, common.TYPE_PROC) {
-		interval := intervals[t]
+		interval := groupInterval(t, intervals[t])
 		s := sysSchedulers[t]
 		if s == nil {
 			if t == common.TYPE_PORT {
@@ -160,7 +138,7 @@ func updateSys(intervals map[string]int) {
 			} else if t == common.TYPE_PROC {
 				s = NewScheduler(interval, sysinfo.ProcCollector{})
 			} else {
-				s = NewScheduler(interval, sysinfo.Collector{t, interval, getFuncsByType(t)})
+				s = NewScheduler(interval, sysinfo.Collector{Name: t, Cycle: interval})
 			}
 			sysSchedulers[t] = s
 			go s.run()
@@ -172,6 +150,19 @@ func updateSys(intervals map[string]int) {
 	}
 }
 
+// groupInterval returns the interval of a collect type, the collectors of a
+// type run together as one group: the groupintervals of the config wins over
+// the interval from the registry, which wins over DEFAULT_INTERVAL
+func groupInterval(t string, registry int) int {
+	if common.Conf != nil && common.Conf.GroupIntervals[t] > 0 {
+		return common.Conf.GroupIntervals[t]
+	}
+	if registry > 0 {
+		return registry
+	}
+	return common.DEFAULT_INTERVAL[t]
+}
+
 func DeleteAll() {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type fakeTicker struct {
+	period time.Duration
+	next   time.Duration
+	c      chan time.Time
+}
+
+// fakeClock ticks its tickers only when advanced
+type fakeClock struct {
+	now     time.Duration
+	tickers []*fakeTicker
+}
+
+func (f *fakeClock) newTicker(d time.Duration) *time.Ticker {
+	t := &fakeTicker{period: d, next: d, c: make(chan time.Time)}
+	f.tickers = append(f.tickers, t)
+	return &time.Ticker{C: t.c}
+}
+
+// advance moves the clock by d in steps of a second, the ticks are
+// unbuffered so every due tick is received before the clock moves on
+func (f *fakeClock) advance(d time.Duration) {
+	for end := f.now + d; f.now < end; {
+		f.now += time.Second
+		for _, t := range f.tickers {
+			if f.now >= t.next {
+				t.c <- time.Unix(0, 0).Add(f.now)
+				t.next += t.period
+			}
+		}
+	}
+}
+
+type countCollector struct {
+	runs int32
+}
+
+func (c *countCollector) Run() {
+	atomic.AddInt32(&c.runs, 1)
+}
+
+func (c *countCollector) Description() string {
+	return "count collector"
+}
+
+func Test_groupInterval(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{GroupIntervals: map[string]int{common.TYPE_DEV: 600}}
+
+	if i := groupInterval(common.TYPE_CPU, 0); i != 10 {
+		t.Fatalf("default group interval fatal: %d", i)
+	}
+	if i := groupInterval(common.TYPE_CPU, 30); i != 30 {
+		t.Fatalf("registry group interval fatal: %d", i)
+	}
+	if i := groupInterval(common.TYPE_DEV, 30); i != 600 {
+		t.Fatalf("configured group interval fatal: %d", i)
+	}
+}
+
+func Test_groupSchedule(t *testing.T) {
+	old, oldTicker := common.Conf, newTicker
+	defer func() { common.Conf, newTicker = old, oldTicker }()
+	common.Conf = &common.AgentConfig{GroupIntervals: map[string]int{common.TYPE_LOGIN: 120}}
+	clock := &fakeClock{}
+	newTicker = clock.newTicker
+
+	groups := map[string]*countCollector{}
+	var schedulers []*Scheduler
+	for _, group := range []string{common.TYPE_CPU, common.TYPE_PROC, common.TYPE_DEV, common.TYPE_LOGIN} {
+		c := &countCollector{}
+		groups[group] = c
+		s := NewScheduler(groupInterval(group, 0), c)
+		schedulers = append(schedulers, s)
+		go s.run()
+	}
+	clock.advance(600 * time.Second)
+	for _, s := range schedulers {
+		// returns once the last run is done
+		s.quit <- 1
+	}
+
+	want := map[string]int32{common.TYPE_CPU: 60, common.TYPE_PROC: 10, common.TYPE_DEV: 2, common.TYPE_LOGIN: 5}
+	for group, runs := range want {
+		if n := atomic.LoadInt32(&groups[group].runs); n != runs {
+			t.Fatalf("group %s runs fatal: %d, want %d", group, n, runs)
+		}
+	}
+}
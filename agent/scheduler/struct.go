@@ -4,12 +4,16 @@ import (
 	"time"
 
 	"github.com/lodastack/agent/agent/common"
+	"github.com/lodastack/agent/agent/sysinfo"
 	"github.com/lodastack/log"
 )
 
 // min interval, unit second
 const MinInterval = 10
 
+// newTicker is replaced by a fake clock in tests
+var newTicker = time.NewTicker
+
 type Scheduler struct {
 	ticker    *time.Ticker
 	quit      chan int
@@ -25,7 +29,7 @@ func NewScheduler(interval int, collector common.Collector) *Scheduler {
 	}
 	scheduler := Scheduler{collector: collector, running: false}
 	scheduler.interval = interval
-	scheduler.ticker = time.NewTicker(time.Duration(interval) * time.Second)
+	scheduler.ticker = newTicker(time.Duration(interval) * time.Second)
 	scheduler.quit = make(chan int)
 	return &scheduler
 }
@@ -35,7 +39,7 @@ func (self *Scheduler) setTicker(interval int) {
 		return
 	}
 	self.interval = interval
-	self.ticker = time.NewTicker(time.Duration(interval) * time.Second)
+	self.ticker = newTicker(time.Duration(interval) * time.Second)
 }
 
 func (self *Scheduler) stop() {
@@ -55,9 +59,20 @@ func (self *Scheduler) run() {
 	for {
 		select {
 		case <-self.ticker.C:
-			self.collector.Run()
+			self.runOnce()
 		case <-self.quit:
 			return
 		}
 	}
 }
+
+// runOnce runs the collector, a panic is recovered and counted so one buggy
+// collector does not take down the agent
+func (self *Scheduler) runOnce() {
+	defer func() {
+		if r := recover(); r != nil {
+			sysinfo.RecordPanic(self.collector.Description(), r)
+		}
+	}()
+	self.collector.Run()
+}
@@ -1,10 +1,48 @@
 package sysinfo
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+
 	"github.com/lodastack/agent/agent/common"
 )
 
+func init() {
+	RegisterFunc(common.TYPE_CPU, "AgentMetrics", AgentMetrics)
+	RegisterFunc(common.TYPE_CPU, "AgentRuntimeMetrics", AgentRuntimeMetrics)
+}
+
 // AgentMetrics report agent alive metric
 func AgentMetrics() []*common.Metric {
-	return []*common.Metric{toMetric("agent.alive", 1, nil)}
+	return append([]*common.Metric{toMetric("agent.alive", 1, nil)}, panicMetrics()...)
+}
+
+// AgentRuntimeMetrics report the resources the agent itself uses, so it can
+// be told apart from the workloads it watches
+func AgentRuntimeMetrics() (L []*common.Metric) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	L = append(L, toMetric("agent.goroutines", runtime.NumGoroutine(), nil))
+	// pause of the latest GC, PauseNs is a circular buffer
+	var pause uint64
+	if ms.NumGC > 0 {
+		pause = ms.PauseNs[(ms.NumGC+255)%256]
+	}
+	L = append(L, toMetric("agent.gc.pause_ms", common.SetPrecision(float64(pause)/1e6, 2), nil))
+
+	self := procPath("self")
+	if content, err := ioutil.ReadFile(filepath.Join(self, "status")); err == nil {
+		if kb, ok := parseKBField(string(content), "VmRSS:"); ok {
+			L = append(L, toMetric("agent.mem.rss", kb*1024, nil))
+		}
+	} else {
+		collectorLog("AgentRuntimeMetrics").Debugf("skip agent rss: %s", err)
+	}
+	if fds, err := ioutil.ReadDir(filepath.Join(self, "fd")); err == nil {
+		L = append(L, toMetric("agent.open_fds", len(fds), nil))
+	} else {
+		collectorLog("AgentRuntimeMetrics").Debugf("skip agent open fds: %s", err)
+	}
+	return
 }
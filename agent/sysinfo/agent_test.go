@@ -0,0 +1,36 @@
+package sysinfo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func Test_AgentRuntimeMetrics(t *testing.T) {
+	runtime.GC()
+	got := map[string]float64{}
+	for _, m := range AgentRuntimeMetrics() {
+		switch v := m.Value.(type) {
+		case int:
+			got[m.Name] = float64(v)
+		case uint64:
+			got[m.Name] = float64(v)
+		case float64:
+			got[m.Name] = v
+		default:
+			t.Fatalf("agent metric %s value type fatal: %T", m.Name, m.Value)
+		}
+	}
+	names := []string{"agent.goroutines", "agent.gc.pause_ms"}
+	if runtime.GOOS == "linux" {
+		names = append(names, "agent.mem.rss", "agent.open_fds")
+	}
+	for _, name := range names {
+		v, ok := got[name]
+		if !ok || v < 0 {
+			t.Fatalf("agent metric %s fatal: %v", name, got)
+		}
+	}
+	if got["agent.goroutines"] < 1 || (runtime.GOOS == "linux" && got["agent.mem.rss"] == 0) {
+		t.Fatalf("agent metrics fatal: %v", got)
+	}
+}
@@ -0,0 +1,134 @@
+package sysinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// cgroup v1 reports no memory limit as a page aligned max int64
+const cgroupUnlimited = 1 << 62
+
+type cgroupStats struct {
+	MemUsed   uint64
+	MemLimit  uint64  // 0 if unlimited
+	CPUQuota  float64 // cores, 0 if unlimited
+	CPUUsage  uint64  // nanoseconds
+	Throttled uint64  // periods throttled
+}
+
+var cgroupRates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_CONTAINER, "CgroupMetrics", CgroupMetrics)
+}
+
+// CgroupMetrics report memory and CPU usage against the limits of the
+// cgroup the agent runs in, which are what matters inside a container
+func CgroupMetrics() []*common.Metric {
+	stats, err := readCgroup(sysPath("fs", "cgroup"))
+	if err != nil {
+		collectorLog("CgroupMetrics").Debugf("skip cgroup metrics: %s", err)
+		return nil
+	}
+	return cgroupMetrics(cgroupRates, stats, common.RateNow())
+}
+
+func cgroupMetrics(rates *common.RateTracker, s cgroupStats, now float64) (L []*common.Metric) {
+	L = append(L, toMetric("cgroup.mem.used", s.MemUsed, nil))
+	if s.MemLimit > 0 {
+		L = append(L, toMetric("cgroup.mem.limit", s.MemLimit, nil))
+		L = append(L, toMetric("cgroup.mem.used.percent", common.SetPrecision(float64(s.MemUsed)*100/float64(s.MemLimit), 2), nil))
+	}
+	if s.CPUQuota > 0 {
+		L = append(L, toMetric("cgroup.cpu.quota.cores", common.SetPrecision(s.CPUQuota, 2), nil))
+	}
+	if rate, ok := rates.RateAt("cgroup.cpu.usage", nil, s.CPUUsage, now); ok {
+		cores := rate / 1e9
+		L = append(L, toMetric("cgroup.cpu.usage.cores", common.SetPrecision(cores, 2), nil))
+		if s.CPUQuota > 0 {
+			L = append(L, toMetric("cgroup.cpu.usage.percent", common.SetPrecision(cores*100/s.CPUQuota, 2), nil))
+		}
+	}
+	if rate, ok := rates.RateAt("cgroup.cpu.throttled", nil, s.Throttled, now); ok {
+		L = append(L, toMetric("cgroup.cpu.throttled.rate", common.SetPrecision(rate, 2), nil))
+	}
+	return
+}
+
+// readCgroup reads the unified hierarchy if dir is a cgroup v2 mount,
+// else the v1 memory, cpu and cpuacct controllers below dir
+func readCgroup(dir string) (cgroupStats, error) {
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err == nil {
+		return readCgroupV2(dir)
+	}
+	return readCgroupV1(dir)
+}
+
+func readCgroupV2(dir string) (s cgroupStats, err error) {
+	if s.MemUsed, err = readFileUint(filepath.Join(dir, "memory.current")); err != nil {
+		return
+	}
+	// "max" when unlimited
+	s.MemLimit, _ = readFileUint(filepath.Join(dir, "memory.max"))
+
+	// "$MAX $PERIOD", $MAX is "max" when unlimited
+	if content, err := readFileString(filepath.Join(dir, "cpu.max")); err == nil {
+		if fields := strings.Fields(content); len(fields) == 2 {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 {
+				s.CPUQuota = quota / period
+			}
+		}
+	}
+	stat, err := readFileString(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return
+	}
+	cpu := parseFlatKeyed(stat)
+	s.CPUUsage = cpu["usage_usec"] * 1000
+	s.Throttled = cpu["nr_throttled"]
+	return s, nil
+}
+
+func readCgroupV1(dir string) (s cgroupStats, err error) {
+	if s.MemUsed, err = readFileUint(filepath.Join(dir, "memory", "memory.usage_in_bytes")); err != nil {
+		return
+	}
+	if limit, err := readFileUint(filepath.Join(dir, "memory", "memory.limit_in_bytes")); err == nil && limit < cgroupUnlimited {
+		s.MemLimit = limit
+	}
+
+	// cfs_quota_us is -1 when unlimited
+	quota, err1 := readFileString(filepath.Join(dir, "cpu", "cpu.cfs_quota_us"))
+	period, err2 := readFileUint(filepath.Join(dir, "cpu", "cpu.cfs_period_us"))
+	if err1 == nil && err2 == nil && period > 0 {
+		if q, err := strconv.ParseInt(quota, 10, 64); err == nil && q > 0 {
+			s.CPUQuota = float64(q) / float64(period)
+		}
+	}
+	s.CPUUsage, _ = readFileUint(filepath.Join(dir, "cpuacct", "cpuacct.usage"))
+	if stat, err := readFileString(filepath.Join(dir, "cpu", "cpu.stat")); err == nil {
+		s.Throttled = parseFlatKeyed(stat)["nr_throttled"]
+	}
+	return s, nil
+}
+
+// parseFlatKeyed parses the "key value" lines of cgroup stat files
+func parseFlatKeyed(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			res[fields[0]] = v
+		}
+	}
+	return res
+}
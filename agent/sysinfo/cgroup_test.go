@@ -0,0 +1,90 @@
+package sysinfo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func cgroupValues(L []*common.Metric) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, m := range L {
+		values[m.Name] = m.Value
+	}
+	return values
+}
+
+func Test_cgroupV2(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"cgroup.controllers": "cpuset cpu io memory pids\n",
+		"memory.current":     "268435456\n",
+		"memory.max":         "1073741824\n",
+		"cpu.max":            "200000 100000\n",
+		"cpu.stat":           "usage_usec 10000000\nuser_usec 8000000\nsystem_usec 2000000\nnr_periods 100\nnr_throttled 10\nthrottled_usec 500000\n",
+	})
+	defer os.RemoveAll(dir)
+
+	s, err := readCgroup(dir)
+	if err != nil {
+		t.Fatalf("read cgroup v2 fatal: %s", err)
+	}
+	if s.MemLimit != 1<<30 || s.CPUQuota != 2 || s.CPUUsage != 10e9 || s.Throttled != 10 {
+		t.Fatalf("read cgroup v2 fatal: %+v", s)
+	}
+
+	rates := common.NewRateTracker()
+	values := cgroupValues(cgroupMetrics(rates, s, 100))
+	if values["cgroup.mem.used.percent"] != 25.0 || values["cgroup.cpu.quota.cores"] != 2.0 {
+		t.Fatalf("cgroup v2 metrics fatal: %v", values)
+	}
+	if _, ok := values["cgroup.cpu.throttled.rate"]; ok {
+		t.Fatalf("cgroup throttled rate of first sample fatal")
+	}
+
+	s.CPUUsage += 10e9
+	s.Throttled += 20
+	values = cgroupValues(cgroupMetrics(rates, s, 110))
+	if values["cgroup.cpu.usage.cores"] != 1.0 || values["cgroup.cpu.usage.percent"] != 50.0 || values["cgroup.cpu.throttled.rate"] != 2.0 {
+		t.Fatalf("cgroup v2 rates fatal: %v", values)
+	}
+}
+
+func Test_cgroupV1(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"memory/memory.usage_in_bytes": "104857600\n",
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		"cpu/cpu.cfs_quota_us":         "-1\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"cpu/cpu.stat":                 "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n",
+		"cpuacct/cpuacct.usage":        "123456789\n",
+	})
+	defer os.RemoveAll(dir)
+
+	s, err := readCgroup(dir)
+	if err != nil {
+		t.Fatalf("read cgroup v1 fatal: %s", err)
+	}
+	if s.MemUsed != 100<<20 || s.MemLimit != 0 || s.CPUQuota != 0 || s.CPUUsage != 123456789 {
+		t.Fatalf("read cgroup v1 fatal: %+v", s)
+	}
+	values := cgroupValues(cgroupMetrics(common.NewRateTracker(), s, 100))
+	if _, ok := values["cgroup.mem.used.percent"]; ok {
+		t.Fatalf("unlimited cgroup should have no used percent: %v", values)
+	}
+
+	limited := writeSysFixture(t, map[string]string{
+		"memory/memory.usage_in_bytes": "104857600\n",
+		"memory/memory.limit_in_bytes": "419430400\n",
+		"cpu/cpu.cfs_quota_us":         "50000\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+	})
+	defer os.RemoveAll(limited)
+	if s, err = readCgroup(limited); err != nil || s.MemLimit != 400<<20 || s.CPUQuota != 0.5 {
+		t.Fatalf("read limited cgroup v1 fatal: %+v %v", s, err)
+	}
+
+	if _, err := readCgroup(os.TempDir() + "/no-such-cgroup"); err == nil {
+		t.Fatalf("read missing cgroup should fail")
+	}
+}
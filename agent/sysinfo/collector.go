@@ -1,6 +1,7 @@
 package sysinfo
 
 import (
+	"context"
 	"time"
 
 	"github.com/lodastack/agent/agent/common"
@@ -25,21 +26,112 @@ func init() {
 	}()
 }
 
+// Collector runs the registered collectors of the collect type Name
 type Collector struct {
 	Name  string
 	Cycle int
-	Fns   []func() []*common.Metric
 }
 
 func (self Collector) Run() {
+	m := self.collect()
+	recordSnapshot(self.Name, m)
+	for _, ns := range common.GetNamespaces() {
+		outputs.SendMetrics(self.Name, ns, m)
+	}
+}
+
+// Collect runs one cycle of the collect type like Run does, sampling and
+// deadlines included, and returns the metrics instead of sending them
+func (self Collector) Collect() []*common.Metric {
+	return self.collect()
+}
+
+// collect runs every collector of the type and adds the agent's own
+// success, duration and count metrics of each
+func (self Collector) collect() []*common.Metric {
 	m := []*common.Metric{}
-	for _, fn := range self.Fns {
-		m = append(m, fn()...)
+	// the agent's own metrics are kept out of maxmetricspercycle
+	var own []*common.Metric
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), collectDeadline(self.Cycle))
+	defer cancel()
+	collectors := Collectors(self.Name)
+	results := runCollectors(ctx, collectors, collectConcurrency(), collectorTimeout(self.Cycle), func(c MetricCollector) collectResult {
+		begin := time.Now()
+		var panicked bool
+		res, ran := sampled(c.Name(), func() []*common.Metric {
+			L, p := tryCollect(c.Name(), collectFunc(c, self.Cycle))
+			panicked = p
+			return L
+		})
+		return collectResult{metrics: res, ran: ran, elapsed: time.Since(begin), failed: panicked || hasCollectorError(res)}
+	})
+	for i, r := range results {
+		m = append(m, r.metrics...)
+		name := collectors[i].Name()
+		if r.timedOut {
+			recordSuccess(name, false)
+			own = append(own, timeoutMetric(name))
+			continue
+		}
+		if !r.ran {
+			continue
+		}
+
+		tags := map[string]string{"collector": name}
+		// an empty result is a collector with nothing to report, e.g. no
+		// battery, only errors, panics and timeouts count as failures;
+		// collectors report a failing read or command as collector.error
+		ratio := recordSuccess(name, !r.failed)
+		own = append(own, toMetric("agent.collector.success.ratio", common.SetPrecision(ratio, 2), tags))
+		own = append(own, toMetric("agent.collect.duration_ms", durationMs(r.elapsed), tags))
+		own = append(own, toMetric("agent.collect.metrics.count", len(r.metrics), tags))
+	}
+	typeTags := map[string]string{"type": self.Name}
+	if max := maxMetricsPerCycle(); max > 0 {
+		var dropped int
+		m, dropped = capMetrics(m, max)
+		if dropped > 0 {
+			log.Errorf("collect type %s emitted more than %d metrics, dropped %d", self.Name, max, dropped)
+		}
+		own = append(own, toMetric("agent.dropped.count", dropped, typeTags))
 	}
+	own = append(own, toMetric("agent.collect.total_ms", durationMs(time.Since(start)), typeTags))
+	m = append(m, own...)
+	stampTimestamps(m, start)
+	recordCycle(time.Now())
+	return m
+}
 
-	for _, ns := range common.GetNamespaces() {
-		outputs.SendMetrics(self.Name, ns, m)
+func maxMetricsPerCycle() int {
+	if common.Conf != nil {
+		return common.Conf.MaxMetricsPerCycle
+	}
+	return 0
+}
+
+// capMetrics keeps the first max metrics, in collector register order, and
+// returns the number of the dropped ones
+func capMetrics(L []*common.Metric, max int) ([]*common.Metric, int) {
+	if len(L) <= max {
+		return L, 0
 	}
+	return L[:max], len(L) - max
+}
+
+// stampTimestamps sets the collection time on the metrics without a
+// timestamp, the ones a collector stamped itself, e.g. logins, are kept
+func stampTimestamps(L []*common.Metric, t time.Time) {
+	ts := t.Unix()
+	for _, m := range L {
+		if m != nil && m.Timestamp == 0 {
+			m.Timestamp = ts
+		}
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return common.SetPrecision(float64(d)/float64(time.Millisecond), 2)
 }
 
 func (self Collector) Description() string {
@@ -0,0 +1,141 @@
+package sysinfo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_CollectorDuration(t *testing.T) {
+	const timingType = "TIMING_TEST"
+	RegisterFunc(timingType, "SlowMetrics", func() []*common.Metric {
+		time.Sleep(5 * time.Millisecond)
+		return []*common.Metric{toMetric("slow.metric", 1, nil)}
+	})
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{}
+
+	var duration, total, count *common.Metric
+	for _, m := range (Collector{Name: timingType}).collect() {
+		switch m.Name {
+		case "agent.collect.duration_ms":
+			duration = m
+		case "agent.collect.total_ms":
+			total = m
+		case "agent.collect.metrics.count":
+			count = m
+		}
+	}
+	if duration == nil || duration.Tags["collector"] != "SlowMetrics" || duration.Value.(float64) <= 0 {
+		t.Fatalf("collect duration metric fatal: %v", duration)
+	}
+	if total == nil || total.Tags["type"] != timingType || total.Value.(float64) < duration.Value.(float64) {
+		t.Fatalf("collect total metric fatal: %v", total)
+	}
+	if count == nil || count.Value.(int) != 1 {
+		t.Fatalf("collect metrics count fatal: %v", count)
+	}
+}
+
+func Test_stampTimestamps(t *testing.T) {
+	login := toMetric("login.count", 1, nil)
+	login.Timestamp = 1500000000
+	L := []*common.Metric{toMetric("cpu.idle", 99, nil), login, nil}
+
+	now := time.Now()
+	stampTimestamps(L, now)
+	if L[0].Timestamp != now.Unix() {
+		t.Fatalf("stamp zero timestamp fatal: %d", L[0].Timestamp)
+	}
+	if L[1].Timestamp != 1500000000 {
+		t.Fatalf("explicit timestamp overwritten fatal: %d", L[1].Timestamp)
+	}
+
+	const stampType = "STAMP_TEST"
+	RegisterFunc(stampType, "UnstampedMetrics", func() []*common.Metric {
+		return []*common.Metric{toMetric("unstamped.metric", 1, nil)}
+	})
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{}
+	for _, m := range (Collector{Name: stampType}).collect() {
+		if m.Timestamp == 0 {
+			t.Fatalf("collect left zero timestamp fatal: %s", m.String())
+		}
+	}
+}
+
+func Test_MaxMetricsPerCycle(t *testing.T) {
+	const capType = "CAP_TEST"
+	RegisterFunc(capType, "FirstMetrics", func() []*common.Metric {
+		return []*common.Metric{toMetric("first.a", 1, nil), toMetric("first.b", 2, nil)}
+	})
+	RegisterFunc(capType, "SecondMetrics", func() []*common.Metric {
+		return []*common.Metric{toMetric("second.a", 3, nil), toMetric("second.b", 4, nil)}
+	})
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{MaxMetricsPerCycle: 3}
+
+	var names []string
+	var dropped *common.Metric
+	for _, m := range (Collector{Name: capType}).collect() {
+		switch {
+		case m.Name == "agent.dropped.count":
+			dropped = m
+		case !strings.HasPrefix(m.Name, "agent."):
+			names = append(names, m.Name)
+		}
+	}
+	if strings.Join(names, ",") != "first.a,first.b,second.a" {
+		t.Fatalf("cap metrics per cycle fatal: %v", names)
+	}
+	if dropped == nil || dropped.Value.(int) != 1 || dropped.Tags["type"] != capType {
+		t.Fatalf("dropped count fatal: %v", dropped)
+	}
+
+	common.Conf = &common.AgentConfig{}
+	for _, m := range (Collector{Name: capType}).collect() {
+		if m.Name == "agent.dropped.count" {
+			t.Fatalf("dropped count without cap fatal: %s", m.String())
+		}
+	}
+}
+
+func Test_CollectorSuccess(t *testing.T) {
+	const successType = "SUCCESS_TEST"
+	RegisterFunc(successType, "EmptySuccessMetrics", func() []*common.Metric { return nil })
+	RegisterFunc(successType, "ErrorSuccessMetrics", func() []*common.Metric {
+		return collectorError("ErrorSuccessMetrics", errReasonRead)
+	})
+	RegisterFunc(successType, "PanicSuccessMetrics", func() []*common.Metric {
+		panic("broken collector")
+	})
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{}
+
+	ratios := make(map[string]interface{})
+	for _, m := range (Collector{Name: successType}).collect() {
+		if m.Name == "agent.collector.success.ratio" {
+			ratios[m.Tags["collector"]] = m.Value
+		}
+	}
+	// nothing to report is not a failure
+	want := map[string]interface{}{"EmptySuccessMetrics": 1.0, "ErrorSuccessMetrics": 0.0, "PanicSuccessMetrics": 0.0}
+	for name, v := range want {
+		if ratios[name] != v {
+			t.Fatalf("success ratio of %s fatal: %v, want %v", name, ratios[name], v)
+		}
+	}
+	health := Health(time.Now(), time.Minute).Collectors
+	if health["EmptySuccessMetrics"].Errors != 0 || health["ErrorSuccessMetrics"].Errors != 1 || health["PanicSuccessMetrics"].Errors != 1 {
+		t.Fatalf("collector errors fatal: %+v", health)
+	}
+}
@@ -0,0 +1,29 @@
+package sysinfo
+
+import (
+	"github.com/lodastack/agent/agent/common"
+)
+
+// reasons of collector.error
+const (
+	// the command or the query a collector runs failed
+	errReasonExec = "exec"
+	// the file or table a collector reads failed
+	errReasonRead = "read"
+)
+
+// collectorError is the result of a collector that could not collect at
+// all, so a broken collector is told apart from an agent that is down
+func collectorError(collector, reason string) []*common.Metric {
+	return []*common.Metric{toMetric("collector.error", 1, map[string]string{"collector": collector, "reason": reason})}
+}
+
+// hasCollectorError reports whether L contains a collector.error
+func hasCollectorError(L []*common.Metric) bool {
+	for _, m := range L {
+		if m != nil && m.Name == "collector.error" {
+			return true
+		}
+	}
+	return false
+}
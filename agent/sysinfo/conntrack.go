@@ -0,0 +1,37 @@
+package sysinfo
+
+import (
+	"path/filepath"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_NET, "ConntrackMetrics", ConntrackMetrics)
+}
+
+// ConntrackMetrics report the usage of the nf_conntrack table
+func ConntrackMetrics() []*common.Metric {
+	return conntrackMetrics(procPath("sys", "net", "netfilter"))
+}
+
+func conntrackMetrics(dir string) (L []*common.Metric) {
+	count, err := readFileUint(filepath.Join(dir, "nf_conntrack_count"))
+	if err != nil {
+		collectorLog("ConntrackMetrics").Debugf("read conntrack count failed, nf_conntrack may not be loaded: %s", err)
+		return
+	}
+	max, err := readFileUint(filepath.Join(dir, "nf_conntrack_max"))
+	if err != nil {
+		collectorLog("ConntrackMetrics").Debugf("read conntrack max failed, nf_conntrack may not be loaded: %s", err)
+		return
+	}
+
+	L = append(L, toMetric("kernel.conntrack.count", count, nil))
+	L = append(L, toMetric("kernel.conntrack.max", max, nil))
+	if max > 0 {
+		v := common.SetPrecision(float64(count)*100/float64(max), 2)
+		L = append(L, toMetric("kernel.conntrack.percent", v, nil))
+	}
+	return
+}
@@ -0,0 +1,30 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_conntrackMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conntrack-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if L := conntrackMetrics(dir); len(L) != 0 {
+		t.Fatalf("conntrack metrics without module fatal: %d", len(L))
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "nf_conntrack_count"), []byte("1234\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "nf_conntrack_max"), []byte("65536\n"), 0644)
+	L := conntrackMetrics(dir)
+	if len(L) != 3 {
+		t.Fatalf("conntrack metrics fatal: %d", len(L))
+	}
+	if L[2].Name != "kernel.conntrack.percent" || L[2].Value.(float64) != 1.88 {
+		t.Fatalf("conntrack percent fatal: %s", L[2].String())
+	}
+}
@@ -0,0 +1,165 @@
+package sysinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// dial and request timeout of a container runtime socket, a hung runtime
+// must not stall the collection
+const containerTimeout = 3 * time.Second
+
+var defaultContainerSockets = []string{
+	"/var/run/docker.sock",
+	"/run/containerd/containerd.sock",
+}
+
+const defaultDockerSocket = "/var/run/docker.sock"
+
+func init() {
+	RegisterFunc(common.TYPE_CONTAINER, "ContainerMetrics", ContainerMetrics)
+	RegisterFunc(common.TYPE_CONTAINER, "DockerMetrics", DockerMetrics)
+}
+
+// ContainerMetrics report whether the container runtimes on this host
+// accept connections, plus the docker engine version
+func ContainerMetrics() (L []*common.Metric) {
+	sockets := defaultContainerSockets
+	if common.Conf != nil && len(common.Conf.ContainerSockets) > 0 {
+		sockets = common.Conf.ContainerSockets
+	}
+	for _, sock := range sockets {
+		// no such runtime on this host
+		if _, err := os.Stat(sock); err != nil {
+			continue
+		}
+		runtime := strings.TrimSuffix(filepath.Base(sock), ".sock")
+		tags := map[string]string{"runtime": runtime}
+
+		up, version := 0, ""
+		if runtime == "docker" {
+			if v, err := dockerVersion(sock); err == nil {
+				up, version = 1, v
+			}
+		} else if conn, err := net.DialTimeout("unix", sock, containerTimeout); err == nil {
+			// containerd speaks gRPC, a successful connect is the best cheap check
+			conn.Close()
+			up = 1
+		}
+		L = append(L, toMetric("container.runtime.up", up, tags))
+		if version != "" {
+			L = append(L, toMetric("container.runtime.version", 1, map[string]string{"runtime": runtime, "version": version}))
+		}
+	}
+	return
+}
+
+// DockerMetrics report the containers of the docker engine by state
+func DockerMetrics() (L []*common.Metric) {
+	sock := defaultDockerSocket
+	if common.Conf != nil && common.Conf.DockerSocket != "" {
+		sock = common.Conf.DockerSocket
+	}
+	// not a docker host
+	if _, err := os.Stat(sock); err != nil {
+		return
+	}
+	containers, err := dockerContainers(sock)
+	if err != nil {
+		collectorLog("DockerMetrics").Errorf("failed to list docker containers: %s", err)
+		return
+	}
+	return dockerMetrics(containers)
+}
+
+type dockerContainer struct {
+	Names []string
+	State string
+}
+
+func dockerMetrics(containers []dockerContainer) (L []*common.Metric) {
+	running, restarting := 0, 0
+	for _, c := range containers {
+		up := 0
+		switch c.State {
+		case "running":
+			running++
+			up = 1
+		case "restarting":
+			restarting++
+		}
+		if len(c.Names) > 0 {
+			name := strings.TrimPrefix(c.Names[0], "/")
+			L = append(L, toMetric("docker.container.running", up, map[string]string{"name": name}))
+		}
+	}
+	L = append(L, toMetric("docker.containers.running", running, nil))
+	L = append(L, toMetric("docker.containers.restarting", restarting, nil))
+	L = append(L, toMetric("docker.containers.total", len(containers), nil))
+	return
+}
+
+// dockerContainers lists all containers, stopped ones included
+func dockerContainers(sock string) ([]dockerContainer, error) {
+	resp, err := dockerClient(sock).Get("http://docker/containers/json?all=1")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docker containers status: %d", resp.StatusCode)
+	}
+	var containers []dockerContainer
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// dockerClient talks HTTP to the docker engine API over its unix socket
+func dockerClient(sock string) *http.Client {
+	return &http.Client{
+		Timeout: containerTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: containerTimeout}
+				return d.DialContext(ctx, "unix", sock)
+			},
+		},
+	}
+}
+
+// dockerVersion pings the docker engine API and returns its version
+func dockerVersion(sock string) (string, error) {
+	client := dockerClient(sock)
+	resp, err := client.Get("http://docker/_ping")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("docker ping status: %d", resp.StatusCode)
+	}
+
+	resp, err = client.Get("http://docker/version")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var v struct {
+		Version string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return "", err
+	}
+	return v.Version, nil
+}
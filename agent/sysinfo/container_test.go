@@ -0,0 +1,67 @@
+package sysinfo
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const dockerContainersSample = `[
+{"Id":"8dfafdbc3a40","Names":["/web"],"Image":"nginx","State":"running","Status":"Up 2 days"},
+{"Id":"9cd87474be90","Names":["/worker"],"Image":"app","State":"restarting","Status":"Restarting (1) 5 seconds ago"},
+{"Id":"3176a2479c92","Names":["/job"],"Image":"app","State":"exited","Status":"Exited (0) 3 hours ago"}
+]`
+
+func Test_DockerMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "docker.sock")
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{DockerSocket: sock}
+
+	if L := DockerMetrics(); len(L) != 0 {
+		t.Fatalf("docker metrics without socket fatal: %d", len(L))
+	}
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen unix socket fatal: %s", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" || r.URL.Query().Get("all") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, dockerContainersSample)
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	values, running := map[string]int{}, map[string]int{}
+	for _, m := range DockerMetrics() {
+		if m.Name == "docker.container.running" {
+			running[m.Tags["name"]] = m.Value.(int)
+			continue
+		}
+		values[m.Name] = m.Value.(int)
+	}
+	if values["docker.containers.running"] != 1 || values["docker.containers.restarting"] != 1 || values["docker.containers.total"] != 3 {
+		t.Fatalf("docker container counts fatal: %v", values)
+	}
+	if len(running) != 3 || running["web"] != 1 || running["job"] != 0 {
+		t.Fatalf("docker container status fatal: %v", running)
+	}
+}
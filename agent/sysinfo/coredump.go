@@ -2,8 +2,10 @@ package sysinfo
 
 import (
 	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lodastack/agent/agent/common"
@@ -16,6 +18,16 @@ const (
 	COREDUMP_INTERVAL = 60
 )
 
+var (
+	lastCoreCheck    time.Time
+	lastCoreRateTime float64
+)
+
+func init() {
+	RegisterFunc(common.TYPE_COREDUMP, "CoreDumpMetrics", CoreDumpMetrics)
+	RegisterFunc(common.TYPE_COREDUMP, "KernelCoreDumpMetrics", KernelCoreDumpMetrics)
+}
+
 func CoreDumpMetrics() (L []*common.Metric) {
 	name := "app.service.coredump"
 	fis, err := ioutil.ReadDir(COREDUMP_DIR)
@@ -38,3 +50,53 @@ func CoreDumpMetrics() (L []*common.Metric) {
 	}
 	return
 }
+
+// KernelCoreDumpMetrics report the kernel core_pattern and how many core
+// files exist in the core dump directory and how fast new ones appear
+func KernelCoreDumpMetrics() (L []*common.Metric) {
+	pattern, err := readFileString(procPath("sys", "kernel", "core_pattern"))
+	if err != nil {
+		collectorLog("KernelCoreDumpMetrics").Debugf("read core_pattern failed: %s", err)
+		return
+	}
+	L = append(L, toMetric("kernel.core_pattern", 1, map[string]string{"pattern": pattern}))
+
+	dir := coreDumpDir(pattern)
+	if dir == "" {
+		return
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		collectorLog("KernelCoreDumpMetrics").Debugf("read core dump dir %s failed: %s", dir, err)
+		return
+	}
+
+	now, rateNow := time.Now(), common.RateNow()
+	newCores := 0
+	for _, fi := range fis {
+		if !fi.IsDir() && fi.ModTime().After(lastCoreCheck) {
+			newCores++
+		}
+	}
+	L = append(L, toMetric("kernel.coredumps.total", len(fis), nil))
+	if !lastCoreCheck.IsZero() && rateNow > lastCoreRateTime {
+		rate := common.SetPrecision(float64(newCores)/(rateNow-lastCoreRateTime), 2)
+		L = append(L, toMetric("kernel.coredumps.rate", rate, nil))
+	}
+	lastCoreCheck, lastCoreRateTime = now, rateNow
+	return
+}
+
+// coreDumpDir returns the directory core files are written to. The
+// coredumpdir config wins; a piped core_pattern ("|/usr/...") hands cores
+// to a helper and a relative one writes into the crashing process cwd,
+// neither can be counted.
+func coreDumpDir(pattern string) string {
+	if common.Conf != nil && common.Conf.CoreDumpDir != "" {
+		return common.Conf.CoreDumpDir
+	}
+	if strings.HasPrefix(pattern, "|") || !filepath.IsAbs(pattern) {
+		return ""
+	}
+	return filepath.Dir(pattern)
+}
@@ -0,0 +1,100 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "CpuFreqMetrics", CpuFreqMetrics)
+}
+
+// CpuFreqMetrics report the current clock of every core and their average,
+// read from cpufreq in sysfs or from /proc/cpuinfo if there is no cpufreq
+func CpuFreqMetrics() []*common.Metric {
+	freqs := sysfsCpuFreqs(sysPath("devices", "system", "cpu"))
+	if len(freqs) == 0 {
+		content, err := ioutil.ReadFile(procPath("cpuinfo"))
+		if err != nil {
+			collectorLog("CpuFreqMetrics").Errorf("failed to read cpuinfo: %s", err)
+			return nil
+		}
+		freqs = parseCpuinfoFreqs(string(content))
+	}
+	return cpuFreqMetrics(freqs)
+}
+
+func cpuFreqMetrics(freqs map[int]float64) (L []*common.Metric) {
+	if len(freqs) == 0 {
+		return
+	}
+	cores := make([]int, 0, len(freqs))
+	for core := range freqs {
+		cores = append(cores, core)
+	}
+	sort.Ints(cores)
+
+	var sum float64
+	for _, core := range cores {
+		sum += freqs[core]
+		tags := map[string]string{"core": strconv.Itoa(core)}
+		L = append(L, toMetric("cpu.freq.mhz", common.SetPrecision(freqs[core], 2), tags))
+	}
+	L = append(L, toMetric("cpu.freq.avg_mhz", common.SetPrecision(sum/float64(len(freqs)), 2), nil))
+	return
+}
+
+// sysfsCpuFreqs reads cpu<N>/cpufreq/scaling_cur_freq in kHz, CPUs without
+// cpufreq, e.g. on most VMs, are skipped
+func sysfsCpuFreqs(dir string) map[int]float64 {
+	res := make(map[int]float64)
+	files, _ := filepath.Glob(filepath.Join(dir, "cpu[0-9]*", "cpufreq", "scaling_cur_freq"))
+	for _, file := range files {
+		core, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(filepath.Dir(file))), "cpu"))
+		if err != nil {
+			continue
+		}
+		khz, err := readFileUint(file)
+		if err != nil {
+			collectorLog("CpuFreqMetrics").Debugf("skip cpufreq of cpu%d: %s", core, err)
+			continue
+		}
+		res[core] = float64(khz) / 1000
+	}
+	return res
+}
+
+// parseCpuinfoFreqs reads the "cpu MHz" line of every processor block of
+// /proc/cpuinfo, architectures without it yield nothing
+func parseCpuinfoFreqs(content string) map[int]float64 {
+	res := make(map[int]float64)
+	core := -1
+	for _, line := range strings.Split(content, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "processor":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				core = -1
+				continue
+			}
+			core = n
+		case "cpu MHz":
+			mhz, err := strconv.ParseFloat(value, 64)
+			if err != nil || core < 0 {
+				continue
+			}
+			res[core] = mhz
+		}
+	}
+	return res
+}
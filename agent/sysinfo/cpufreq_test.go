@@ -0,0 +1,57 @@
+package sysinfo
+
+import (
+	"os"
+	"testing"
+)
+
+const cpuinfoSample = `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz
+cpu MHz		: 2399.998
+cache size	: 35840 KB
+
+processor	: 1
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz
+cpu MHz		: 1200.002
+cache size	: 35840 KB
+`
+
+func Test_parseCpuinfoFreqs(t *testing.T) {
+	freqs := parseCpuinfoFreqs(cpuinfoSample)
+	if len(freqs) != 2 || freqs[0] != 2399.998 || freqs[1] != 1200.002 {
+		t.Fatalf("parse cpuinfo freqs fatal: %v", freqs)
+	}
+	// arm64 cpuinfo has no cpu MHz
+	if freqs := parseCpuinfoFreqs("processor\t: 0\nBogoMIPS\t: 50.00\n"); len(freqs) != 0 {
+		t.Fatalf("parse cpuinfo without MHz fatal: %v", freqs)
+	}
+}
+
+func Test_sysfsCpuFreqs(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"cpu0/cpufreq/scaling_cur_freq": "2400000\n",
+		"cpu1/cpufreq/scaling_cur_freq": "1200500\n",
+		// cpu without cpufreq
+		"cpu2/topology/core_id":            "2\n",
+		"cpufreq/policy0/scaling_cur_freq": "2400000\n",
+	})
+	defer os.RemoveAll(dir)
+
+	freqs := sysfsCpuFreqs(dir)
+	if len(freqs) != 2 || freqs[0] != 2400 || freqs[1] != 1200.5 {
+		t.Fatalf("read sysfs cpu freqs fatal: %v", freqs)
+	}
+
+	L := cpuFreqMetrics(freqs)
+	if len(L) != 3 || L[0].Tags["core"] != "0" || L[1].Value.(float64) != 1200.5 {
+		t.Fatalf("cpu freq metrics fatal: %v", L)
+	}
+	if avg := L[2]; avg.Name != "cpu.freq.avg_mhz" || avg.Value.(float64) != 1800.25 {
+		t.Fatalf("cpu freq avg fatal: %s", avg.String())
+	}
+	if L := cpuFreqMetrics(sysfsCpuFreqs(dir + "/missing")); len(L) != 0 {
+		t.Fatalf("cpu freq metrics without cpufreq fatal: %v", L)
+	}
+}
@@ -19,6 +19,10 @@ var (
 	psLock          = new(sync.RWMutex)
 )
 
+func init() {
+	RegisterFunc(common.TYPE_CPU, "CpuMetrics", CpuMetrics)
+}
+
 func UpdateCpuStat() error {
 	ps, err := nux.CurrentProcStat()
 	if err != nil {
@@ -189,9 +193,19 @@ func CpuMetrics() []*common.Metric {
 		res = append(res, toMetric("cpu.idle.core", v, tags))
 	}
 
+	psLock.RLock()
+	cur, prev := procStatHistory[0], procStatHistory[1]
+	psLock.RUnlock()
+	res = append(res, cpuModeMetrics(cur.Cpu, prev.Cpu, nil)...)
+	if common.Conf != nil && common.Conf.CpuPerCore && len(cur.Cpus) == len(prev.Cpus) {
+		for i := range cur.Cpus {
+			res = append(res, cpuModeMetrics(cur.Cpus[i], prev.Cpus[i], map[string]string{"core": strconv.Itoa(i)})...)
+		}
+	}
+
 	load, err := nux.LoadAvg()
 	if err != nil {
-		log.Error("failed to collect LoadAvgMetrics:", err)
+		collectorLog("CpuMetrics").Errorf("failed to collect LoadAvgMetrics: %s", err)
 	} else {
 		res = append(res, toMetric("cpu.loadavg.1", load.Avg1min, nil))
 		res = append(res, toMetric("cpu.loadavg.5", load.Avg5min, nil))
@@ -199,3 +213,30 @@ func CpuMetrics() []*common.Metric {
 	}
 	return res
 }
+
+// cpuModeMetrics report the share of every CPU mode between two samples,
+// plus cpu.busy.percent. cpu.idle is reported by CpuMetrics itself.
+func cpuModeMetrics(cur, prev *nux.CpuUsage, tags map[string]string) (L []*common.Metric) {
+	if cur == nil || prev == nil || cur.Total <= prev.Total {
+		return
+	}
+	dt := float64(cur.Total - prev.Total)
+	percent := func(c, p uint64) float64 {
+		if c < p {
+			return 0
+		}
+		return common.SetPrecision(float64(c-p)*100/dt, 2)
+	}
+	L = append(L, toMetric("cpu.user", percent(cur.User, prev.User), tags))
+	L = append(L, toMetric("cpu.nice", percent(cur.Nice, prev.Nice), tags))
+	L = append(L, toMetric("cpu.system", percent(cur.System, prev.System), tags))
+	L = append(L, toMetric("cpu.iowait", percent(cur.Iowait, prev.Iowait), tags))
+	L = append(L, toMetric("cpu.irq", percent(cur.Irq, prev.Irq), tags))
+	L = append(L, toMetric("cpu.softirq", percent(cur.SoftIrq, prev.SoftIrq), tags))
+	L = append(L, toMetric("cpu.steal", percent(cur.Steal, prev.Steal), tags))
+	if tags != nil {
+		L = append(L, toMetric("cpu.idle", percent(cur.Idle, prev.Idle), tags))
+	}
+	L = append(L, toMetric("cpu.busy.percent", common.SetPrecision(100-percent(cur.Idle, prev.Idle), 2), tags))
+	return
+}
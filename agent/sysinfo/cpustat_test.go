@@ -0,0 +1,53 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/nux"
+)
+
+// newCpuUsage builds a sample like a "cpu" line of /proc/stat:
+// user nice system idle iowait irq softirq steal
+func newCpuUsage(v ...uint64) *nux.CpuUsage {
+	c := &nux.CpuUsage{User: v[0], Nice: v[1], System: v[2], Idle: v[3], Iowait: v[4], Irq: v[5], SoftIrq: v[6], Steal: v[7]}
+	for _, n := range v {
+		c.Total += n
+	}
+	return c
+}
+
+func Test_cpuModeMetrics(t *testing.T) {
+	prev := newCpuUsage(1000, 10, 500, 8000, 100, 0, 20, 0)
+	cur := newCpuUsage(1250, 10, 600, 8600, 120, 0, 30, 20)
+
+	got := map[string]float64{}
+	for _, m := range cpuModeMetrics(cur, prev, nil) {
+		got[m.Name] = m.Value.(float64)
+	}
+	want := map[string]float64{
+		"cpu.user":         25,
+		"cpu.nice":         0,
+		"cpu.system":       10,
+		"cpu.iowait":       2,
+		"cpu.softirq":      1,
+		"cpu.steal":        2,
+		"cpu.busy.percent": 40,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("cpu mode %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+	if _, ok := got["cpu.idle"]; ok {
+		t.Fatalf("aggregate cpu.idle should be left to CpuMetrics")
+	}
+
+	core := cpuModeMetrics(cur, prev, map[string]string{"core": "0"})
+	if len(core) != len(want)+2 || core[0].Tags["core"] != "0" {
+		t.Fatalf("per core cpu mode fatal: %d", len(core))
+	}
+
+	if L := cpuModeMetrics(prev, prev, nil); len(L) != 0 {
+		t.Fatalf("cpu mode without delta fatal: %d", len(L))
+	}
+}
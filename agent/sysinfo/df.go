@@ -0,0 +1,142 @@
+package sysinfo
+
+import (
+	"github.com/lodastack/agent/agent/common"
+)
+
+// fsStat is the statfs result of a mount, sizes in bytes
+type fsStat struct {
+	Total     uint64
+	Free      uint64
+	Avail     uint64
+	Files     uint64
+	FilesFree uint64
+}
+
+// statFs is replaced in tests
+var statFs = platformStatFs
+
+// pseudo filesystems without meaningful space or inode usage
+var pseudoFsTypes = map[string]bool{
+	"autofs":      true,
+	"binfmt_misc": true,
+	"bpf":         true,
+	"cgroup":      true,
+	"cgroup2":     true,
+	"configfs":    true,
+	"debugfs":     true,
+	"devpts":      true,
+	"fusectl":     true,
+	"hugetlbfs":   true,
+	"mqueue":      true,
+	"nsfs":        true,
+	"proc":        true,
+	"pstore":      true,
+	"rpc_pipefs":  true,
+	"securityfs":  true,
+	"sysfs":       true,
+	"tracefs":     true,
+}
+
+func init() {
+	RegisterFunc(common.TYPE_FS, "InodeMetrics", InodeMetrics)
+	RegisterFunc(common.TYPE_FS, "DiskUsageMetrics", DiskUsageMetrics)
+}
+
+// dfMounts returns the real filesystems passing the fstype white and black
+// lists, each mount point once; name is the collector asking
+func dfMounts(name string) []mountInfo {
+	mounts, err := listMounts()
+	if err != nil {
+		collectorLog(name).Errorf("failed to list mounts: %s", err)
+		return nil
+	}
+	return filterDfMounts(mounts)
+}
+
+func filterDfMounts(mounts []mountInfo) (res []mountInfo) {
+	var whitelist, blacklist []string
+	if common.Conf != nil {
+		whitelist, blacklist = common.Conf.FsTypeWhitelist, common.Conf.FsTypeBlacklist
+	}
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if pseudoFsTypes[m.FsType] || seen[m.MountPoint] {
+			continue
+		}
+		if len(whitelist) > 0 && !inStrings(m.FsType, whitelist) {
+			continue
+		}
+		if inStrings(m.FsType, blacklist) {
+			continue
+		}
+		seen[m.MountPoint] = true
+		res = append(res, m)
+	}
+	return
+}
+
+// InodeMetrics report the inode usage of every real filesystem
+func InodeMetrics() []*common.Metric {
+	return inodeMetrics(dfMounts("InodeMetrics"))
+}
+
+func inodeMetrics(mounts []mountInfo) (L []*common.Metric) {
+	for _, m := range mounts {
+		st, err := statFs(m.MountPoint)
+		if err != nil {
+			collectorLog("InodeMetrics").Debugf("statfs %s failed: %s", m.MountPoint, err)
+			continue
+		}
+		// some filesystems (btrfs, vfat) do not report inodes
+		if st.Files == 0 {
+			continue
+		}
+		used := st.Files - st.FilesFree
+		tags := map[string]string{"mount": m.MountPoint, "fstype": m.FsType}
+		L = append(L, toMetric("df.inodes.total", st.Files, tags))
+		L = append(L, toMetric("df.inodes.used", used, tags))
+		L = append(L, toMetric("df.inodes.free", st.FilesFree, tags))
+		L = append(L, toMetric("df.inodes.used.percent", common.SetPrecision(float64(used)*100/float64(st.Files), 2), tags))
+	}
+	return
+}
+
+// DiskUsageMetrics report the space usage of every real filesystem
+func DiskUsageMetrics() []*common.Metric {
+	return diskUsageMetrics(dfMounts("DiskUsageMetrics"))
+}
+
+func diskUsageMetrics(mounts []mountInfo) (L []*common.Metric) {
+	for _, m := range mounts {
+		st, err := statFs(m.MountPoint)
+		if err != nil {
+			collectorLog("DiskUsageMetrics").Debugf("statfs %s failed: %s", m.MountPoint, err)
+			continue
+		}
+		if st.Total == 0 {
+			continue
+		}
+		used := st.Total - st.Free
+		// like df, space reserved for root counts as neither used nor available
+		percent := 0.0
+		if used+st.Avail > 0 {
+			percent = common.SetPrecision(float64(used)*100/float64(used+st.Avail), 2)
+		}
+		tags := map[string]string{"mount": m.MountPoint, "fstype": m.FsType}
+		L = append(L, toMetric("df.bytes.total", st.Total, tags))
+		L = append(L, toMetric("df.bytes.used", used, tags))
+		L = append(L, toMetric("df.bytes.free", st.Avail, tags))
+		L = append(L, toMetric("df.bytes.used.percent", percent, tags))
+	}
+	return
+}
+
+func inStrings(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package sysinfo
+
+import (
+	"syscall"
+)
+
+func platformStatFs(path string) (fsStat, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return fsStat{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return fsStat{
+		Total:     st.Blocks * bsize,
+		Free:      st.Bfree * bsize,
+		Avail:     st.Bavail * bsize,
+		Files:     st.Files,
+		FilesFree: st.Ffree,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package sysinfo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const mountsSample = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sdb1 /data xfs rw,noatime 0 0
+/dev/sdc1 /broken ext4 rw 0 0
+`
+
+func stubStatFs(stats map[string]fsStat) func() {
+	old := statFs
+	statFs = func(path string) (fsStat, error) {
+		st, ok := stats[path]
+		if !ok {
+			return fsStat{}, errors.New("no such mount")
+		}
+		return st, nil
+	}
+	return func() { statFs = old }
+}
+
+func Test_inodeMetrics(t *testing.T) {
+	defer stubStatFs(map[string]fsStat{
+		"/":     {Files: 1000, FilesFree: 250},
+		"/data": {Files: 0},
+	})()
+
+	mounts := filterDfMounts(parseMounts(mountsSample))
+	if len(mounts) != 3 {
+		t.Fatalf("filter df mounts fatal: %d", len(mounts))
+	}
+
+	L := inodeMetrics(mounts)
+	if len(L) != 4 {
+		t.Fatalf("inode metrics fatal: %d", len(L))
+	}
+	want := map[string]interface{}{
+		"df.inodes.total":        uint64(1000),
+		"df.inodes.used":         uint64(750),
+		"df.inodes.free":         uint64(250),
+		"df.inodes.used.percent": 75.0,
+	}
+	for _, m := range L {
+		if m.Value != want[m.Name] || m.Tags["mount"] != "/" || m.Tags["fstype"] != "ext4" {
+			t.Fatalf("inode metric fatal: %s", m.String())
+		}
+	}
+}
+
+func Test_diskUsageMetrics(t *testing.T) {
+	defer stubStatFs(map[string]fsStat{
+		// 5% reserved: 100 total, 30 free of which 25 available
+		"/": {Total: 100, Free: 30, Avail: 25},
+	})()
+
+	mounts := filterDfMounts(parseMounts(mountsSample))
+	L := diskUsageMetrics(mounts)
+	if len(L) != 4 {
+		t.Fatalf("disk usage metrics fatal: %d", len(L))
+	}
+	want := map[string]interface{}{
+		"df.bytes.total":        uint64(100),
+		"df.bytes.used":         uint64(70),
+		"df.bytes.free":         uint64(25),
+		"df.bytes.used.percent": 73.68,
+	}
+	for _, m := range L {
+		if m.Value != want[m.Name] {
+			t.Fatalf("disk usage metric fatal: %s", m.String())
+		}
+	}
+}
+
+func Test_filterDfMountsByFsType(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	mounts := parseMounts(mountsSample)
+
+	common.Conf = &common.AgentConfig{FsTypeBlacklist: []string{"ext4"}}
+	if res := filterDfMounts(mounts); len(res) != 1 || res[0].MountPoint != "/data" {
+		t.Fatalf("fstype blacklist fatal: %v", res)
+	}
+
+	common.Conf = &common.AgentConfig{FsTypeWhitelist: []string{"ext4"}}
+	if res := filterDfMounts(mounts); len(res) != 2 || res[0].MountPoint != "/" {
+		t.Fatalf("fstype whitelist fatal: %v", res)
+	}
+}
@@ -0,0 +1,9 @@
+package sysinfo
+
+import (
+	"errors"
+)
+
+func platformStatFs(path string) (fsStat, error) {
+	return fsStat{}, errors.New("statfs is not supported on windows")
+}
@@ -16,6 +16,10 @@ var (
 	dsLock       = new(sync.RWMutex)
 )
 
+func init() {
+	RegisterFunc(common.TYPE_DISK, "IOStatsMetrics", IOStatsMetrics)
+}
+
 func UpdateDiskStats() error {
 	dsList, err := nux.ListDiskStats()
 	if err != nil {
@@ -91,7 +95,7 @@ func IODelta(device string, f func([2]*nux.DiskStats) uint64) uint64 {
 func DiskIOMetrics() (L []*common.Metric) {
 	dsList, err := nux.ListDiskStats()
 	if err != nil {
-		log.Error("error when collect disk io:", err)
+		collectorLog("DiskIOMetrics").Errorf("error when collect disk io: %s", err)
 		return
 	}
 
@@ -117,11 +121,14 @@ func DiskIOMetrics() (L []*common.Metric) {
 	return
 }
 
+// IOStatsMetrics report the disk.io.* rates of the last two samples of
+// UpdateDiskStats, both the iostat style names and those of diskBytesMetrics
 func IOStatsMetrics() (L []*common.Metric) {
 	dsLock.RLock()
 	defer dsLock.RUnlock()
 
 	for device := range diskStatsMap {
+		L = append(L, diskBytesMetrics(device, diskStatsMap[device])...)
 		if !ShouldHandleDevice(device) {
 			continue
 		}
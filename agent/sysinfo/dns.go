@@ -0,0 +1,69 @@
+package sysinfo
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const defaultDnsTimeout = 2 * time.Second
+
+func init() {
+	RegisterFunc(common.TYPE_NET, "DnsMetrics", DnsMetrics)
+}
+
+// hostResolver is the part of net.Resolver the dns probes use
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DnsMetrics report resolve latency and success of the hostnames
+// configured in Conf.DnsProbes
+func DnsMetrics() (L []*common.Metric) {
+	if common.Conf == nil || len(common.Conf.DnsProbes) == 0 {
+		return
+	}
+	timeout := defaultDnsTimeout
+	if common.Conf.DnsTimeout > 0 {
+		timeout = time.Duration(common.Conf.DnsTimeout) * time.Millisecond
+	}
+	return dnsMetrics(net.DefaultResolver, common.Conf.DnsProbes, timeout)
+}
+
+// dnsMetrics resolves all hosts in parallel, each one bounded by timeout,
+// so a black-holed resolver stalls the collection for timeout at most
+func dnsMetrics(r hostResolver, hosts []string, timeout time.Duration) (L []*common.Metric) {
+	metrics := make([][]*common.Metric, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := r.LookupHost(ctx, host)
+			elapsed := time.Since(start)
+
+			success := 1
+			if err != nil {
+				collectorLog("DnsMetrics").Debugf("resolve %s failed: %s", host, err)
+				success = 0
+			}
+			tags := map[string]string{"host": host}
+			metrics[i] = []*common.Metric{
+				toMetric("net.dns.resolve_ms", durationMs(elapsed), tags),
+				toMetric("net.dns.resolve.success", success, tags),
+			}
+		}(i, host)
+	}
+	wg.Wait()
+	for _, m := range metrics {
+		L = append(L, m...)
+	}
+	return
+}
@@ -0,0 +1,62 @@
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubResolver answers after delay, or blocks until the deadline for
+// hosts it does not know
+type stubResolver struct {
+	delay time.Duration
+	hosts map[string][]string
+}
+
+func (r stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := r.hosts[host]
+	if !ok {
+		<-ctx.Done()
+		return nil, errors.New("no such host")
+	}
+	select {
+	case <-time.After(r.delay):
+		return addrs, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func Test_dnsMetrics(t *testing.T) {
+	r := stubResolver{
+		delay: 20 * time.Millisecond,
+		hosts: map[string][]string{"example.com": {"93.184.216.34"}},
+	}
+	start := time.Now()
+	L := dnsMetrics(r, []string{"example.com", "blackhole.invalid"}, 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("dns probes not bounded by timeout fatal: %v", elapsed)
+	}
+	if len(L) != 4 {
+		t.Fatalf("dns metrics fatal: %d metrics", len(L))
+	}
+
+	got := map[string]map[string]interface{}{}
+	for _, m := range L {
+		if got[m.Tags["host"]] == nil {
+			got[m.Tags["host"]] = map[string]interface{}{}
+		}
+		got[m.Tags["host"]][m.Name] = m.Value
+	}
+	good, bad := got["example.com"], got["blackhole.invalid"]
+	if good["net.dns.resolve.success"] != 1 || bad["net.dns.resolve.success"] != 0 {
+		t.Fatalf("dns success fatal: %v %v", good, bad)
+	}
+	if ms := good["net.dns.resolve_ms"].(float64); ms < 20 || ms >= 200 {
+		t.Fatalf("dns latency of good lookup fatal: %v", ms)
+	}
+	if ms := bad["net.dns.resolve_ms"].(float64); ms < 200 {
+		t.Fatalf("dns latency of failed lookup fatal: %v", ms)
+	}
+}
@@ -0,0 +1,35 @@
+package sysinfo
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// default timeout of external commands run by collectors
+const execTimeout = 10 * time.Second
+
+// execCommand runs the named program and returns its stdout, the program
+// is killed if it does not exit within timeout
+func execCommand(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin, args...).Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+	return out, err
+}
+
+// execNotFound reports whether err of execCommand is a missing program,
+// the host just lacks the tool and the collector has nothing to report
+func execNotFound(err error) bool {
+	e, ok := err.(*exec.Error)
+	return ok && e.Err == exec.ErrNotFound
+}
@@ -0,0 +1,58 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_FS, "FdFsTypeMetrics", FdFsTypeMetrics)
+}
+
+// FdFsTypeMetrics report open file descriptors of all processes grouped by
+// the filesystem type they live on, e.g. how many fds hang on a NFS mount.
+// Walking every fd is expensive, so it only runs with fdbyfstype enabled.
+func FdFsTypeMetrics() (L []*common.Metric) {
+	if common.Conf == nil || !common.Conf.FdByFsType {
+		return
+	}
+	mounts, err := listMounts()
+	if err != nil {
+		collectorLog("FdFsTypeMetrics").Errorf("failed to read mounts: %s", err)
+		return collectorError("FdFsTypeMetrics", errReasonRead)
+	}
+	pids, err := listPids()
+	if err != nil {
+		collectorLog("FdFsTypeMetrics").Errorf("failed to list processes: %s", err)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, pid := range pids {
+		fdDir := pidPath(pid, "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// process exited or we lack permission
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			// sockets, pipes and anon inodes are not backed by a filesystem
+			if err != nil || !strings.HasPrefix(target, "/") {
+				continue
+			}
+			if m, ok := mountOf(target, mounts); ok {
+				counts[m.FsType]++
+			}
+		}
+	}
+
+	for fstype, cnt := range counts {
+		L = append(L, toMetric("kernel.files.open.byfstype", cnt, map[string]string{"fstype": fstype}))
+	}
+	return
+}
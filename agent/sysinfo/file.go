@@ -0,0 +1,25 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// readFileString returns the trimmed content of a /proc or /sys file
+func readFileString(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// readFileUint parses a /proc or /sys file holding a single unsigned integer
+func readFileUint(path string) (uint64, error) {
+	s, err := readFileString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
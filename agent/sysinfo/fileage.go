@@ -0,0 +1,45 @@
+package sysinfo
+
+import (
+	"os"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_FS, "FileAgeMetrics", FileAgeMetrics)
+}
+
+// FileAgeMetrics report whether the files of Conf.WatchedFiles exist and
+// how long ago they were modified, to catch a stuck cron or a dead writer
+func FileAgeMetrics() []*common.Metric {
+	if common.Conf == nil || len(common.Conf.WatchedFiles) == 0 {
+		return nil
+	}
+	return fileAgeMetrics(common.Conf.WatchedFiles, time.Now())
+}
+
+// fileAgeMetrics follows symlinks, the age of a link is the one of its
+// target and a dangling link is not present
+func fileAgeMetrics(paths []string, now time.Time) (L []*common.Metric) {
+	for _, path := range paths {
+		tags := map[string]string{"path": path}
+		fi, err := os.Stat(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				collectorLog("FileAgeMetrics").Errorf("failed to stat watched file: %s", err)
+			}
+			L = append(L, toMetric("file.present", 0, tags))
+			continue
+		}
+		L = append(L, toMetric("file.present", 1, tags))
+		age := now.Sub(fi.ModTime()).Seconds()
+		if age < 0 {
+			// modified in the future, e.g. after a clock step
+			age = 0
+		}
+		L = append(L, toMetric("file.age.seconds", common.SetPrecision(age, 2), tags))
+	}
+	return
+}
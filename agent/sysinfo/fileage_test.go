@@ -0,0 +1,47 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_fileAgeMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileage-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1500000000, 0)
+	heartbeat := filepath.Join(dir, "heartbeat")
+	ioutil.WriteFile(heartbeat, []byte("ok"), 0644)
+	os.Chtimes(heartbeat, now, now.Add(-90*time.Second))
+	link := filepath.Join(dir, "current")
+	os.Symlink(heartbeat, link)
+	dangling := filepath.Join(dir, "dangling")
+	os.Symlink(filepath.Join(dir, "gone"), dangling)
+	missing := filepath.Join(dir, "app.pid")
+
+	L := fileAgeMetrics([]string{heartbeat, link, dangling, missing}, now)
+	if len(L) != 6 {
+		t.Fatalf("file age metrics fatal: %d", len(L))
+	}
+	for i, path := range []string{heartbeat, link} {
+		present, age := L[i*2], L[i*2+1]
+		if present.Name != "file.present" || present.Value.(int) != 1 || present.Tags["path"] != path {
+			t.Fatalf("file present fatal: %s", present.String())
+		}
+		if age.Name != "file.age.seconds" || age.Value.(float64) != 90 || age.Tags["path"] != path {
+			t.Fatalf("file age fatal: %s", age.String())
+		}
+	}
+	for i, path := range []string{dangling, missing} {
+		m := L[4+i]
+		if m.Name != "file.present" || m.Value.(int) != 0 || m.Tags["path"] != path {
+			t.Fatalf("missing file fatal: %s", m.String())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package sysinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useFixtureRoot writes files under a temporary root, keyed like
+// "proc/meminfo" or "sys/class/thermal/thermal_zone0/temp", and points
+// procRoot and sysRoot at it; the returned func restores both
+func useFixtureRoot(t *testing.T, files map[string]string) func() {
+	dir := writeSysFixture(t, files)
+	oldProc, oldSys := procRoot, sysRoot
+	procRoot, sysRoot = filepath.Join(dir, "proc"), filepath.Join(dir, "sys")
+	return func() {
+		procRoot, sysRoot = oldProc, oldSys
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_fixtureRoot(t *testing.T) {
+	defer useFixtureRoot(t, map[string]string{
+		"proc/meminfo": "MemTotal: 1000 kB\nMemFree: 250 kB\nMemAvailable: 500 kB\n" +
+			"Buffers: 0 kB\nCached: 0 kB\nSwapTotal: 0 kB\nSwapFree: 0 kB\n",
+		"sys/class/thermal/thermal_zone0/type": "x86_pkg_temp\n",
+		"sys/class/thermal/thermal_zone0/temp": "45000\n",
+	})()
+
+	mem := make(map[string]interface{})
+	for _, m := range MemMetrics() {
+		mem[m.Name] = m.Value
+	}
+	if mem["mem.total"] != uint64(1024000) || mem["mem.used.percent"] != 50.0 {
+		t.Fatalf("meminfo from fixture root fatal: %v", mem)
+	}
+
+	found := false
+	for _, m := range ThermalMetrics() {
+		if m.Tags["type"] == "x86_pkg_temp" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("thermal zone from fixture root not read")
+	}
+}
@@ -9,16 +9,20 @@ import (
 
 	"github.com/lodastack/agent/agent/common"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
+func init() {
+	RegisterFunc(common.TYPE_FS, "FsRWMetrics", FsRWMetrics)
+	RegisterFunc(common.TYPE_FS, "FsSpaceMetrics", FsSpaceMetrics)
+}
+
 func FsSpaceMetrics() (L []*common.Metric) {
 	mountPoints, err := nux.ListMountPoint()
 
 	if err != nil {
-		log.Error("failed to call ListMountPoint:", err)
-		return
+		collectorLog("FsSpaceMetrics").Errorf("failed to call ListMountPoint: %s", err)
+		return collectorError("FsSpaceMetrics", errReasonRead)
 	}
 
 	for idx := range mountPoints {
@@ -28,7 +32,7 @@ func FsSpaceMetrics() (L []*common.Metric) {
 		var du *nux.DeviceUsage
 		du, err = nux.BuildDeviceUsage(mountPoints[idx][0], mountPoints[idx][1], mountPoints[idx][2])
 		if err != nil {
-			log.Error("failed to call BuildDeviceUsage:", err)
+			collectorLog("FsSpaceMetrics").Errorf("failed to call BuildDeviceUsage: %s", err)
 			continue
 		}
 
@@ -48,8 +52,8 @@ func FsRWMetrics() (L []*common.Metric) {
 	mountPoints, err := nux.ListMountPoint()
 
 	if err != nil {
-		log.Error("failed to call ListMountPoint:", err)
-		return
+		collectorLog("FsRWMetrics").Errorf("failed to call ListMountPoint: %s", err)
+		return collectorError("FsRWMetrics", errReasonRead)
 	}
 
 	for idx := range mountPoints {
@@ -57,7 +61,7 @@ func FsRWMetrics() (L []*common.Metric) {
 		var res int
 		du, err = nux.BuildDeviceUsage(mountPoints[idx][0], mountPoints[idx][1], mountPoints[idx][2])
 		if err != nil {
-			log.Error("failed to call BuildDeviceUsage:", err)
+			collectorLog("FsRWMetrics").Errorf("failed to call BuildDeviceUsage: %s", err)
 			continue
 		}
 		file := filepath.Join(du.FsFile, ".loda-fs-detect")
@@ -84,29 +88,29 @@ func CheckFS(file string, content string, t string) error {
 		fd, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE, 0644)
 		defer fd.Close()
 		if err != nil {
-			log.Error("Open file failed: ", err)
+			collectorLog("FsRWMetrics").Errorf("Open file failed: %s", err)
 			return err
 		}
 		buf := []byte(content)
 		count, err := fd.Write(buf)
 		if err != nil || count != len(buf) {
-			log.Error("Write file failed: ", err)
+			collectorLog("FsRWMetrics").Errorf("Write file failed: %s", err)
 			return err
 		}
 		//read test
 		read, err := ioutil.ReadFile(file)
 		if err != nil {
-			log.Error("Read file failed: ", err)
+			collectorLog("FsRWMetrics").Errorf("Read file failed: %s", err)
 			return err
 		}
 		if string(read) != content {
-			log.Error("Read content failed: ", string(read))
+			collectorLog("FsRWMetrics").Errorf("Read content failed: %s", read)
 			return errors.New("Read content failed")
 		}
 		//clean the file
 		err = os.Remove(file)
 		if err != nil {
-			log.Error("Remove file filed: ", err)
+			collectorLog("FsRWMetrics").Errorf("Remove file filed: %s", err)
 			return err
 		}
 	}
@@ -0,0 +1,60 @@
+package sysinfo
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type ifaceInfo struct {
+	Name string
+	MAC  string
+	Up   bool
+}
+
+func init() {
+	RegisterFunc(common.TYPE_DEV, "IfInventoryMetrics", IfInventoryMetrics)
+}
+
+// IfInventoryMetrics report the link state of the monitored interfaces and
+// an inventory entry with their hardware address and link speed
+func IfInventoryMetrics() []*common.Metric {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		collectorLog("IfInventoryMetrics").Errorf("collect net interfaces error: %s", err)
+		return nil
+	}
+	infos := make([]ifaceInfo, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		infos = append(infos, ifaceInfo{Name: iface.Name, MAC: iface.HardwareAddr.String(), Up: iface.Flags&net.FlagUp != 0})
+	}
+	return ifInventoryMetrics(infos)
+}
+
+func ifInventoryMetrics(ifaces []ifaceInfo) (L []*common.Metric) {
+	for _, iface := range ifaces {
+		if !common.IsMonitoredInterface(iface.Name) {
+			continue
+		}
+		up := 0
+		if iface.Up {
+			up = 1
+		}
+		L = append(L, toMetric("net.if.up", up, map[string]string{"iface": iface.Name}))
+
+		tags := map[string]string{"iface": iface.Name, "mac": iface.MAC, "speed_mbps": "unknown"}
+		// reading speed fails with EINVAL on a down link, and is -1 on
+		// virtual interfaces
+		if s, err := readFileString(sysPath("class", "net", iface.Name, "speed")); err == nil {
+			if speed, err := strconv.Atoi(s); err == nil && speed > 0 {
+				tags["speed_mbps"] = s
+			}
+		}
+		L = append(L, toMetric("net.if.inventory", 1, tags))
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_ifInventoryMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sysnet-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := sysRoot
+	defer func() { sysRoot = oldDir }()
+	sysRoot = dir
+
+	speeds := map[string]string{"eth0": "1000\n", "eth1": "-1\n", "docker0": "10000\n"}
+	for iface, speed := range speeds {
+		os.MkdirAll(filepath.Join(dir, "class", "net", iface), 0755)
+		ioutil.WriteFile(filepath.Join(dir, "class", "net", iface, "speed"), []byte(speed), 0644)
+	}
+	os.MkdirAll(filepath.Join(dir, "class", "net", "eth2"), 0755)
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{IfacePrefix: []string{"eth"}}
+
+	ifaces := []ifaceInfo{
+		{Name: "eth0", MAC: "aa:bb:cc:dd:ee:00", Up: true},
+		{Name: "eth1", MAC: "aa:bb:cc:dd:ee:01", Up: true},
+		{Name: "eth2", MAC: "aa:bb:cc:dd:ee:02", Up: false},
+		{Name: "docker0", MAC: "02:42:00:00:00:00", Up: true},
+	}
+	ups, inventory := map[string]int{}, map[string]map[string]string{}
+	for _, m := range ifInventoryMetrics(ifaces) {
+		switch m.Name {
+		case "net.if.up":
+			ups[m.Tags["iface"]] = m.Value.(int)
+		case "net.if.inventory":
+			inventory[m.Tags["iface"]] = m.Tags
+		}
+	}
+	if len(ups) != 3 || ups["eth0"] != 1 || ups["eth2"] != 0 {
+		t.Fatalf("interface up metrics fatal: %v", ups)
+	}
+	if _, ok := inventory["docker0"]; ok {
+		t.Fatalf("interface without prefix should be skipped")
+	}
+	if inventory["eth0"]["mac"] != "aa:bb:cc:dd:ee:00" || inventory["eth0"]["speed_mbps"] != "1000" {
+		t.Fatalf("interface inventory fatal: %v", inventory["eth0"])
+	}
+	if inventory["eth1"]["speed_mbps"] != "unknown" || inventory["eth2"]["speed_mbps"] != "unknown" {
+		t.Fatalf("interface without speed fatal: %v %v", inventory["eth1"], inventory["eth2"])
+	}
+}
@@ -1,11 +1,8 @@
 package sysinfo
 
 import (
-	"time"
-
 	"github.com/lodastack/agent/agent/common"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
@@ -22,41 +19,47 @@ type CumIfStat struct {
 
 var (
 	historyIfStat map[string]CumIfStat
-	lastTime      time.Time
+	lastTime      float64
 )
 
+func init() {
+	RegisterFunc(common.TYPE_NET, "NetMetrics", NetMetrics)
+}
+
+// NetMetrics report the traffic of the interfaces as net.in, net.out and
+// friends in bits, and as the net.if.* rates of netDevMetrics
 func NetMetrics() (ret []*common.Metric) {
 	netIfs, err := nux.NetIfs(common.Conf.IfacePrefix)
 	if err != nil {
-		log.Error("collect net metric accurs error:", err)
+		collectorLog("NetMetrics").Errorf("collect net metric accurs error: %s", err)
 		return
 	}
-	now := time.Now()
+	now := common.RateNow()
 	newIfStat := make(map[string]CumIfStat)
 	for _, netIf := range netIfs {
 		newIfStat[netIf.Iface] = CumIfStat{netIf.InBytes, netIf.OutBytes, netIf.InDropped, netIf.OutDropped, netIf.Speed}
 	}
-	interval := now.Unix() - lastTime.Unix()
+	interval := now - lastTime
 	lastTime = now
 
 	if historyIfStat != nil {
 		for iface, stat := range newIfStat {
 			tags := map[string]string{"interface": iface}
 			oldStat := historyIfStat[iface]
-			netIn := common.SetPrecision(float64(stat.inBytes-oldStat.inBytes)*BITS_PER_BYTE/float64(interval), 2)
+			netIn := common.SetPrecision(float64(stat.inBytes-oldStat.inBytes)*BITS_PER_BYTE/interval, 2)
 			if netIn >= 0 {
 				ret = append(ret, toMetric("net.in", netIn, tags))
 			}
 
-			netOut := common.SetPrecision(float64(stat.outBytes-oldStat.outBytes)*BITS_PER_BYTE/float64(interval), 2)
+			netOut := common.SetPrecision(float64(stat.outBytes-oldStat.outBytes)*BITS_PER_BYTE/interval, 2)
 			if netOut >= 0 {
 				ret = append(ret, toMetric("net.out", netOut, tags))
 			}
 
-			v := common.SetPrecision(float64(stat.inDrop-oldStat.inDrop)/float64(interval), 2)
+			v := common.SetPrecision(float64(stat.inDrop-oldStat.inDrop)/interval, 2)
 			ret = append(ret, toMetric("net.in.dropped", v, tags))
 
-			v = common.SetPrecision(float64(stat.outDrop-oldStat.outDrop)/float64(interval), 2)
+			v = common.SetPrecision(float64(stat.outDrop-oldStat.outDrop)/interval, 2)
 			ret = append(ret, toMetric("net.out.dropped", v, tags))
 
 			if stat.speed != 0 {
@@ -76,5 +79,6 @@ func NetMetrics() (ret []*common.Metric) {
 
 	}
 	historyIfStat = newIfStat
+	ret = append(ret, netDevMetrics(netDevRates, netIfCounters(netIfs), now)...)
 	return
 }
@@ -0,0 +1,117 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// default interrupts per second an IRQ must exceed on a CPU to be reported
+const defaultIrqRateThreshold = 10
+
+var irqRates = common.NewRateTracker()
+
+// irqCounter is the count of one IRQ on one CPU
+type irqCounter struct {
+	irq   string
+	cpu   string
+	value uint64
+}
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "InterruptMetrics", InterruptMetrics)
+}
+
+func irqRateThreshold() int {
+	if common.Conf != nil && common.Conf.IrqRateThreshold > 0 {
+		return common.Conf.IrqRateThreshold
+	}
+	return defaultIrqRateThreshold
+}
+
+// InterruptMetrics report the interrupts per second of every IRQ on every
+// CPU from /proc/interrupts, only the ones above irqratethreshold to bound
+// the series on hosts with hundreds of MSI vectors
+func InterruptMetrics() []*common.Metric {
+	content, err := ioutil.ReadFile(procPath("interrupts"))
+	if err != nil {
+		collectorLog("InterruptMetrics").Errorf("failed to read interrupts: %s", err)
+		return collectorError("InterruptMetrics", errReasonRead)
+	}
+	return irqRateMetrics(irqRates, parseInterrupts(string(content)), float64(irqRateThreshold()), common.RateNow())
+}
+
+func irqRateMetrics(rates *common.RateTracker, counters []irqCounter, threshold float64, now float64) (L []*common.Metric) {
+	for _, c := range counters {
+		tags := map[string]string{"irq": c.irq, "cpu": c.cpu}
+		rate, ok := rates.RateAt("kernel.irq.rate", tags, c.value, now)
+		if ok && rate > threshold {
+			L = append(L, toMetric("kernel.irq.rate", common.SetPrecision(rate, 2), tags))
+		}
+	}
+	return
+}
+
+// parseInterrupts parses the per CPU columns of /proc/interrupts. The
+// header names the online CPUs; a row is "IRQ: n n n chip hwirq device".
+// A numbered IRQ is named after its device, e.g. eth0-rx-0, prefixed with
+// the number if several IRQs share the name; the others keep their key,
+// e.g. LOC. Rows without a column per CPU, e.g. ERR, are skipped.
+func parseInterrupts(content string) (res []irqCounter) {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 {
+		return
+	}
+	var cpus []string
+	for _, f := range strings.Fields(lines[0]) {
+		cpus = append(cpus, strings.TrimPrefix(f, "CPU"))
+	}
+	if len(cpus) == 0 {
+		return
+	}
+
+	type irqRow struct {
+		key, device string
+		counts      []uint64
+	}
+	var rows []irqRow
+	devices := make(map[string]int)
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < len(cpus)+1 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		row := irqRow{key: strings.TrimSuffix(fields[0], ":")}
+		for _, f := range fields[1 : len(cpus)+1] {
+			n, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				break
+			}
+			row.counts = append(row.counts, n)
+		}
+		if len(row.counts) != len(cpus) {
+			continue
+		}
+		if _, err := strconv.Atoi(row.key); err == nil && len(fields) > len(cpus)+1 {
+			row.device = fields[len(fields)-1]
+			devices[row.device]++
+		}
+		rows = append(rows, row)
+	}
+
+	for _, row := range rows {
+		name := row.key
+		if row.device != "" {
+			name = row.device
+			if devices[row.device] > 1 {
+				name = row.key + "-" + row.device
+			}
+		}
+		for i, n := range row.counts {
+			res = append(res, irqCounter{irq: name, cpu: cpus[i], value: n})
+		}
+	}
+	return
+}
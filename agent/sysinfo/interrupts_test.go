@@ -0,0 +1,95 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// captured on a 4 CPU host, CPU2 offline
+const interruptsSample = `           CPU0       CPU1       CPU3
+  0:         36          0          0   IO-APIC   2-edge      timer
+  9:          0          0          0   IO-APIC   9-fasteoi   acpi
+ 16:        120          0          0   IO-APIC  16-fasteoi   ehci_hcd:usb1, i801_smbus
+ 24:    1000000        100          0   PCI-MSI 524288-edge      eth0-rx-0
+ 25:         10     500000          0   PCI-MSI 524289-edge      eth0-tx-0
+ 26:          0          0          0   PCI-MSI 65536-edge      nvme0q0
+ 27:          5          0          0   PCI-MSI 65537-edge      nvme0q0
+NMI:         12         13         14   Non-maskable interrupts
+LOC:    2000000    1900000    1800000   Local timer interrupts
+ERR:          0
+MIS:          0
+`
+
+const interruptsSample2 = `           CPU0       CPU1       CPU3
+  0:         36          0          0   IO-APIC   2-edge      timer
+  9:          0          0          0   IO-APIC   9-fasteoi   acpi
+ 16:        120          0          0   IO-APIC  16-fasteoi   ehci_hcd:usb1, i801_smbus
+ 24:    1100000        100          0   PCI-MSI 524288-edge      eth0-rx-0
+ 25:         10     500050          0   PCI-MSI 524289-edge      eth0-tx-0
+ 26:          0          0          0   PCI-MSI 65536-edge      nvme0q0
+ 27:       5005          0          0   PCI-MSI 65537-edge      nvme0q0
+NMI:         12         13         14   Non-maskable interrupts
+LOC:    2010000    1910000    1800000   Local timer interrupts
+ERR:          0
+MIS:          0
+`
+
+func Test_parseInterrupts(t *testing.T) {
+	counters := parseInterrupts(interruptsSample)
+	// 9 rows of 3 CPUs, ERR and MIS have no per CPU columns
+	if len(counters) != 27 {
+		t.Fatalf("parse interrupts fatal: %d", len(counters))
+	}
+	got := make(map[string]uint64)
+	for _, c := range counters {
+		got[c.irq+"@"+c.cpu] = c.value
+	}
+	for key, want := range map[string]uint64{
+		"timer@0":      36,
+		"eth0-rx-0@0":  1000000,
+		"eth0-rx-0@1":  100,
+		"eth0-tx-0@1":  500000,
+		"i801_smbus@0": 120,
+		"26-nvme0q0@0": 0,
+		"27-nvme0q0@0": 5,
+		"LOC@3":        1800000,
+		"NMI@1":        13,
+	} {
+		if v, ok := got[key]; !ok || v != want {
+			t.Fatalf("parse interrupts %s fatal: %d, want %d", key, v, want)
+		}
+	}
+	if _, ok := got["ERR@0"]; ok {
+		t.Fatalf("parse interrupts kept ERR")
+	}
+	if L := parseInterrupts(""); len(L) != 0 {
+		t.Fatalf("parse empty interrupts fatal: %d", len(L))
+	}
+}
+
+func Test_irqRateMetrics(t *testing.T) {
+	rates := common.NewRateTracker()
+	if L := irqRateMetrics(rates, parseInterrupts(interruptsSample), 10, 100); len(L) != 0 {
+		t.Fatalf("first sample fatal: %d", len(L))
+	}
+	got := make(map[string]interface{})
+	for _, m := range irqRateMetrics(rates, parseInterrupts(interruptsSample2), 10, 110) {
+		got[m.Tags["irq"]+"@"+m.Tags["cpu"]] = m.Value
+	}
+	// eth0-tx-0 on CPU1 is 5/s, below the threshold
+	want := map[string]interface{}{
+		"eth0-rx-0@0":  10000.0,
+		"27-nvme0q0@0": 500.0,
+		"LOC@0":        1000.0,
+		"LOC@1":        1000.0,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("irq rate metrics fatal: %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("irq rate %s fatal: %v, want %v", k, got[k], v)
+		}
+	}
+}
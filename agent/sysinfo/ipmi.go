@@ -0,0 +1,81 @@
+package sysinfo
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// in-band devices of the OpenIPMI driver, absent on VMs
+var ipmiDevices = []string{"/dev/ipmi0", "/dev/ipmi/0", "/dev/ipmidev/0"}
+
+func init() {
+	RegisterFunc(common.TYPE_DEV, "IPMIMetrics", IPMIMetrics)
+}
+
+// IPMIMetrics report BMC temperature, fan, voltage and power sensors
+func IPMIMetrics() (L []*common.Metric) {
+	if common.Conf == nil || !common.Conf.EnableIPMI || !hasIPMIDevice() {
+		return
+	}
+	out, err := execCommand(execTimeout, "ipmitool", "sdr")
+	if execNotFound(err) {
+		collectorLog("IPMIMetrics").Debugf("ipmitool not found: %s", err)
+		return
+	} else if err != nil {
+		collectorLog("IPMIMetrics").Errorf("run ipmitool failed: %s", err)
+		return collectorError("IPMIMetrics", errReasonExec)
+	}
+	return parseIPMISdr(string(out))
+}
+
+func hasIPMIDevice() bool {
+	for _, dev := range ipmiDevices {
+		if _, err := os.Stat(dev); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPMISdr parses `ipmitool sdr` output of "name | value unit | status"
+// lines, sensors without a reading are skipped
+func parseIPMISdr(out string) (L []*common.Metric) {
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Split(line, "|")
+		if len(cols) != 3 {
+			continue
+		}
+		sensor := strings.TrimSpace(cols[0])
+		reading := strings.Fields(cols[1])
+		if sensor == "" || len(reading) < 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(reading[0], 64)
+		if err != nil {
+			continue
+		}
+		name := ipmiMetricName(strings.Join(reading[1:], " "))
+		if name == "" {
+			continue
+		}
+		L = append(L, toMetric(name, common.SetPrecision(value, 2), map[string]string{"sensor": sensor}))
+	}
+	return
+}
+
+func ipmiMetricName(unit string) string {
+	switch strings.ToLower(unit) {
+	case "degrees c":
+		return "ipmi.temp.celsius"
+	case "rpm":
+		return "ipmi.fan.rpm"
+	case "volts":
+		return "ipmi.voltage"
+	case "watts":
+		return "ipmi.power.watts"
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+const ipmiSdrSample = `CPU1 Temp        | 45 degrees C      | ok
+FAN1             | 5400 RPM          | ok
+FAN2             | no reading        | ns
+Vcore            | 0.88 Volts        | ok
+PS1 Status       | 0x01              | ok
+Inlet Temp       | 95 degrees C      | cr
+Pwr Consumption  | 168 Watts         | ok
+`
+
+func Test_parseIPMISdr(t *testing.T) {
+	L := parseIPMISdr(ipmiSdrSample)
+	if len(L) != 5 {
+		t.Fatalf("parse ipmi sdr fatal: %d", len(L))
+	}
+	expected := []struct {
+		name, sensor string
+		value        float64
+	}{
+		{"ipmi.temp.celsius", "CPU1 Temp", 45},
+		{"ipmi.fan.rpm", "FAN1", 5400},
+		{"ipmi.voltage", "Vcore", 0.88},
+		// a critical sensor still has a reading
+		{"ipmi.temp.celsius", "Inlet Temp", 95},
+		{"ipmi.power.watts", "Pwr Consumption", 168},
+	}
+	for i, e := range expected {
+		if L[i].Name != e.name || L[i].Tags["sensor"] != e.sensor || L[i].Value.(float64) != e.value {
+			t.Fatalf("parse ipmi sdr %s fatal: %s", e.sensor, L[i].String())
+		}
+	}
+}
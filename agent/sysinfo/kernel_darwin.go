@@ -1,19 +1,23 @@
 package sysinfo
 
 import (
-	"bytes"
-	"os/exec"
+	"context"
 
 	"github.com/lodastack/agent/agent/common"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
+func init() {
+	RegisterFunc(common.TYPE_CPU, "PsMetrics", PsMetrics)
+	RegisterFunc(common.TYPE_FS, "FsKernelMetrics", FsKernelMetrics)
+}
+
 func FsKernelMetrics() (L []*common.Metric) {
+	clog := collectorLog("FsKernelMetrics")
 	maxFiles, err := nux.KernelMaxFiles()
 	if err != nil {
-		log.Error("failed collect kernel metrics:", err)
+		clog.Errorf("failed to read the max open files: %s", err)
 		return
 	}
 
@@ -21,7 +25,7 @@ func FsKernelMetrics() (L []*common.Metric) {
 
 	allocateFiles, err := nux.KernelAllocateFiles()
 	if err != nil {
-		log.Error("failed to call KernelAllocateFiles:", err)
+		clog.Errorf("failed to read the allocated files: %s", err)
 		return
 	}
 
@@ -34,59 +38,28 @@ func FsKernelMetrics() (L []*common.Metric) {
 
 // exec `ps` to get all process states
 func PsMetrics() (L []*common.Metric) {
-	out, err := execPS()
+	ctx, cancel := context.WithTimeout(context.Background(), psTimeout())
+	defer cancel()
+	out, err := execPSContext(ctx)
 	if err != nil {
-		log.Error("failed to call ps command:", err)
-		return
-	}
-	fields := make(map[string]int64)
-	for i, status := range bytes.Fields(out) {
-		if i == 0 && string(status) == "STAT" {
-			// This is a header, skip it
-			continue
-		}
-		switch status[0] {
-		case 'W':
-			fields["wait"] = fields["wait"] + int64(1)
-		case 'U', 'D', 'L':
-			// Also known as uninterruptible sleep or disk sleep
-			fields["blocked"] = fields["blocked"] + int64(1)
-		case 'Z':
-			fields["zombies"] = fields["zombies"] + int64(1)
-		case 'T':
-			fields["stopped"] = fields["stopped"] + int64(1)
-		case 'R':
-			fields["running"] = fields["running"] + int64(1)
-		case 'S':
-			fields["sleeping"] = fields["sleeping"] + int64(1)
-		case 'I':
-			fields["idle"] = fields["idle"] + int64(1)
-		case 'X':
-			fields["exit"] = fields["exit"] + int64(1)
-		case '?':
-			fields["unknown"] = fields["unknown"] + int64(1)
-		default:
-			log.Errorf("processes: Unknown state [ %s ] from ps",
-				string(status[0]))
+		collectorLog("PsMetrics").Errorf("failed to call ps command: %s", err)
+		if len(out) == 0 {
+			return collectorError("PsMetrics", errReasonExec)
 		}
-		fields["total"] = fields["total"] + int64(1)
 	}
-	L = append(L, toMetric("ps.zombies.num", fields["zombies"], nil))
-	L = append(L, toMetric("ps.running.num", fields["running"], nil))
-	L = append(L, toMetric("ps.total.num", fields["total"], nil))
+	fields := parsePsStates(out)
+	L = psStateMetrics(fields)
 	return
 }
 
-func execPS() ([]byte, error) {
-	bin, err := exec.LookPath("ps")
-	if err != nil {
-		return nil, err
-	}
+func WtmpMetrics() (L []*common.Metric) {
+	return nil
+}
 
-	out, err := exec.Command(bin, "axo", "state").Output()
-	if err != nil {
-		return nil, err
-	}
+func LoggedInUsersMetrics() (L []*common.Metric) {
+	return nil
+}
 
-	return out, err
+func BtmpMetrics() (L []*common.Metric) {
+	return nil
 }
@@ -0,0 +1,18 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+func Test_parseSysctlLoadavg(t *testing.T) {
+	load, err := parseSysctlLoadavg("{ 1.53 1.71 1.80 }\n")
+	if err != nil {
+		t.Fatalf("parse vm.loadavg fatal: %s", err)
+	}
+	if load.Avg1 != 1.53 || load.Avg5 != 1.71 || load.Avg15 != 1.80 || load.Total != 0 {
+		t.Fatalf("parse vm.loadavg fatal: %+v", load)
+	}
+	if _, err := parseSysctlLoadavg("{ }"); err == nil {
+		t.Fatalf("parse invalid vm.loadavg should fail")
+	}
+}
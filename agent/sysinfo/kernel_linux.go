@@ -2,18 +2,32 @@ package sysinfo
 
 import (
 	"bytes"
-	"os/exec"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/lodastack/agent/agent/common"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
+func init() {
+	RegisterFunc(common.TYPE_CPU, "PsMetrics", PsMetrics)
+	RegisterFunc(common.TYPE_FS, "FsKernelMetrics", FsKernelMetrics)
+	RegisterFunc(common.TYPE_FS, "EntropyMetrics", EntropyMetrics)
+	RegisterFunc(common.TYPE_LOGIN, "WtmpMetrics", WtmpMetrics)
+	RegisterFunc(common.TYPE_LOGIN, "LoggedInUsersMetrics", LoggedInUsersMetrics)
+	RegisterFunc(common.TYPE_LOGIN, "BtmpMetrics", BtmpMetrics)
+	RegisterFunc(common.TYPE_LOGIN, "UserProcMetrics", UserProcMetrics)
+}
+
 func FsKernelMetrics() (L []*common.Metric) {
+	clog := collectorLog("FsKernelMetrics")
 	maxFiles, err := nux.KernelMaxFiles()
 	if err != nil {
-		log.Error("failed collect kernel metrics:", err)
+		clog.Errorf("failed to read the max open files: %s", err)
 		return
 	}
 
@@ -21,72 +35,148 @@ func FsKernelMetrics() (L []*common.Metric) {
 
 	allocateFiles, err := nux.KernelAllocateFiles()
 	if err != nil {
-		log.Error("failed to call KernelAllocateFiles:", err)
+		clog.Errorf("failed to read the allocated files: %s", err)
 		return
 	}
 
-	v := common.SetPrecision(float64(allocateFiles)*100/float64(maxFiles), 2)
+	v := 0.0
+	if maxFiles == 0 {
+		clog.Warningf("kernel files max is 0, report allocated percent as 0")
+	} else {
+		v = common.SetPrecision(float64(allocateFiles)*100/float64(maxFiles), 2)
+	}
 	L = append(L, toMetric("kernel.files.allocated", allocateFiles, nil))
 	L = append(L, toMetric("kernel.files.allocated.percent", v, nil))
 	L = append(L, toMetric("kernel.files.left", maxFiles-allocateFiles, nil))
 	return
 }
 
-// exec `ps` to get all process states
-func PsMetrics() (L []*common.Metric) {
-	out, err := execPS()
+// EntropyMetrics report the available kernel entropy and the pool size
+func EntropyMetrics() []*common.Metric {
+	return entropyMetrics(procPath("sys/kernel/random"))
+}
+
+func entropyMetrics(dir string) (L []*common.Metric) {
+	avail, err := readFileUint(filepath.Join(dir, "entropy_avail"))
 	if err != nil {
-		log.Error("failed to call ps command:", err)
+		collectorLog("EntropyMetrics").Errorf("failed to read entropy_avail: %s", err)
+		return collectorError("EntropyMetrics", errReasonRead)
+	}
+	L = append(L, toMetric("kernel.entropy.avail", avail, nil))
+
+	// poolsize is gone since linux 5.18
+	poolsize, err := readFileUint(filepath.Join(dir, "poolsize"))
+	if err != nil {
+		collectorLog("EntropyMetrics").Debugf("skip entropy poolsize: %s", err)
 		return
 	}
+	L = append(L, toMetric("kernel.entropy.poolsize", poolsize, nil))
+	return
+}
+
+// read /proc to get all process states, exec `ps` if /proc is unavailable
+func PsMetrics() (L []*common.Metric) {
+	fields, err := procPsStates()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), psTimeout())
+		defer cancel()
+		out, err := execPSContext(ctx)
+		if err != nil {
+			collectorLog("PsMetrics").Errorf("failed to call ps command: %s", err)
+			if len(out) == 0 {
+				return collectorError("PsMetrics", errReasonExec)
+			}
+		}
+		fields = parsePsStates(out)
+	}
+	L = psStateMetrics(fields)
+	if fields["zombies"] > int64(zombieThreshold()) {
+		L = append(L, zombieParentMetrics()...)
+	}
+	return
+}
+
+// procPsStates counts the processes per state from the third field of
+// /proc/<pid>/stat
+func procPsStates() (map[string]int64, error) {
+	pids, err := listPids()
+	if err != nil {
+		return nil, err
+	}
 	fields := make(map[string]int64)
-	for i, status := range bytes.Fields(out) {
-		if i == 0 && string(status) == "STAT" {
-			// This is a header, skip it
+	for _, pid := range pids {
+		stat, err := ioutil.ReadFile(pidPath(pid, "stat"))
+		if err != nil {
+			// process exited
 			continue
 		}
-		switch status[0] {
-		case 'W':
-			fields["wait"] = fields["wait"] + int64(1)
-		case 'U', 'D', 'L':
-			// Also known as uninterruptible sleep or disk sleep
-			fields["blocked"] = fields["blocked"] + int64(1)
-		case 'Z':
-			fields["zombies"] = fields["zombies"] + int64(1)
-		case 'T':
-			fields["stopped"] = fields["stopped"] + int64(1)
-		case 'R':
-			fields["running"] = fields["running"] + int64(1)
-		case 'S':
-			fields["sleeping"] = fields["sleeping"] + int64(1)
-		case 'I':
-			fields["idle"] = fields["idle"] + int64(1)
-		case 'X':
-			fields["exit"] = fields["exit"] + int64(1)
-		case '?':
-			fields["unknown"] = fields["unknown"] + int64(1)
-		default:
-			log.Errorf("processes: Unknown state [ %s ] from ps",
-				string(status[0]))
+		// comm may contain spaces and parentheses, the state follows the last ')'
+		i := bytes.LastIndexByte(stat, ')')
+		if i < 0 || i+2 >= len(stat) {
+			continue
 		}
-		fields["total"] = fields["total"] + int64(1)
+		countPsState(fields, stat[i+2])
 	}
-	L = append(L, toMetric("ps.zombies.num", fields["zombies"], nil))
-	L = append(L, toMetric("ps.running.num", fields["running"], nil))
-	L = append(L, toMetric("ps.total.num", fields["total"], nil))
-	return
+	return fields, nil
 }
 
-func execPS() ([]byte, error) {
-	bin, err := exec.LookPath("ps")
+const (
+	utmpFile = "/var/run/utmp"
+	wtmpFile = "/var/log/wtmp"
+	btmpFile = "/var/log/btmp"
+)
+
+// LoggedInUsersMetrics report the currently logged in sessions from utmp
+func LoggedInUsersMetrics() (L []*common.Metric) {
+	utmps, err := readUtmpFile(utmpFile)
 	if err != nil {
-		return nil, err
+		collectorLog("LoggedInUsersMetrics").Errorf("failed to read utmp: %s", err)
+		return
 	}
+	return loggedInMetrics(utmps)
+}
 
-	out, err := exec.Command(bin, "axo", "state").Output()
+// UserProcMetrics report the processes and sessions of every user
+func UserProcMetrics() (L []*common.Metric) {
+	counts, err := procUidCounts()
 	if err != nil {
-		return nil, err
+		collectorLog("UserProcMetrics").Errorf("failed to list processes: %s", err)
+		return
 	}
+	utmps, err := readUtmpFile(utmpFile)
+	if err != nil {
+		collectorLog("UserProcMetrics").Errorf("failed to read utmp: %s", err)
+	}
+	return userProcMetrics(utmps, counts)
+}
 
-	return out, err
+var (
+	wtmpTail = new(utmpTail)
+	btmpTail = new(utmpTail)
+)
+
+// WtmpMetrics report user logins within the login window from wtmp
+func WtmpMetrics() (L []*common.Metric) {
+	path := wtmpFile
+	if common.Conf != nil && common.Conf.WtmpPath != "" {
+		path = common.Conf.WtmpPath
+	}
+	utmps, err := wtmpTail.read(path)
+	if err != nil {
+		collectorLog("WtmpMetrics").Errorf("failed to read wtmp: %s", err)
+	}
+	return loginMetrics(utmps, time.Now(), loginWindow())
+}
+
+// BtmpMetrics report failed logins within the login window from btmp
+func BtmpMetrics() (L []*common.Metric) {
+	utmps, err := btmpTail.read(btmpFile)
+	if os.IsPermission(err) {
+		collectorLog("BtmpMetrics").Errorf("no permission to read btmp, it is usually readable by root only: %s", err)
+		return
+	}
+	if err != nil {
+		collectorLog("BtmpMetrics").Errorf("failed to read btmp: %s", err)
+	}
+	return failedLoginMetrics(utmps, time.Now(), loginWindow())
 }
@@ -0,0 +1,40 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Benchmark_procPsStates(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := procPsStates(); err != nil {
+			b.Skip("read /proc failed: ", err)
+		}
+	}
+}
+
+func Test_entropyMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "entropy-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if L := entropyMetrics(dir); len(L) != 1 || L[0].Name != "collector.error" {
+		t.Fatalf("entropy metrics without files fatal: %d", len(L))
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "entropy_avail"), []byte("256\n"), 0644)
+	L := entropyMetrics(dir)
+	if len(L) != 1 || L[0].Name != "kernel.entropy.avail" || L[0].Value.(uint64) != 256 {
+		t.Fatalf("entropy metrics without poolsize fatal: %d", len(L))
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "poolsize"), []byte("4096\n"), 0644)
+	L = entropyMetrics(dir)
+	if len(L) != 2 || L[1].Name != "kernel.entropy.poolsize" || L[1].Value.(uint64) != 4096 {
+		t.Fatalf("entropy metrics fatal: %d", len(L))
+	}
+}
@@ -1,14 +1,86 @@
 package sysinfo
 
 import (
+	"syscall"
+	"unsafe"
+
 	"github.com/lodastack/agent/agent/common"
 )
 
+func init() {
+	RegisterFunc(common.TYPE_CPU, "PsMetrics", PsMetrics)
+}
+
 func FsKernelMetrics() (L []*common.Metric) {
 	return nil
 }
 
-// exec `ps` to get all process states
+// winProcess is the part of a toolhelp process entry PsMetrics needs
+type winProcess struct {
+	Pid     uint32
+	Threads uint32
+}
+
+// PsMetrics report process counts from a toolhelp snapshot
 func PsMetrics() (L []*common.Metric) {
+	procs, err := snapshotProcesses()
+	if err != nil {
+		collectorLog("PsMetrics").Errorf("failed to snapshot processes: %s", err)
+		return collectorError("PsMetrics", errReasonRead)
+	}
+	fields := bucketWinProcesses(procs)
+	L = psStateMetrics(fields)
+	return
+}
+
+// bucketWinProcesses maps processes onto the ps states. Windows keeps no
+// scheduler state per process, so a process with threads is running and
+// one without is exited but still referenced by an open handle.
+func bucketWinProcesses(procs []winProcess) map[string]int64 {
+	fields := make(map[string]int64)
+	for _, p := range procs {
+		if p.Threads == 0 {
+			fields["exit"]++
+		} else {
+			fields["running"]++
+		}
+		fields["total"]++
+	}
+	return fields
+}
+
+func snapshotProcesses() ([]winProcess, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err = syscall.Process32First(snapshot, &entry); err != nil {
+		return nil, err
+	}
+	var procs []winProcess
+	for {
+		procs = append(procs, winProcess{Pid: entry.ProcessID, Threads: entry.Threads})
+		if err = syscall.Process32Next(snapshot, &entry); err != nil {
+			if err == syscall.ERROR_NO_MORE_FILES {
+				return procs, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+func WtmpMetrics() (L []*common.Metric) {
+	return nil
+}
+
+func LoggedInUsersMetrics() (L []*common.Metric) {
+	return nil
+}
+
+func BtmpMetrics() (L []*common.Metric) {
 	return nil
 }
@@ -0,0 +1,21 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+func Test_bucketWinProcesses(t *testing.T) {
+	procs := []winProcess{
+		{Pid: 0, Threads: 8},
+		{Pid: 4, Threads: 200},
+		{Pid: 1000, Threads: 12},
+		{Pid: 1001, Threads: 0},
+	}
+	fields := bucketWinProcesses(procs)
+	if fields["total"] != 4 || fields["running"] != 3 || fields["exit"] != 1 {
+		t.Fatalf("bucket windows processes fatal: %v", fields)
+	}
+	if L := len(bucketWinProcesses(nil)); L != 0 {
+		t.Fatalf("bucket no processes fatal: %d", L)
+	}
+}
@@ -0,0 +1,126 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_DEV, "ListenPortMetrics", ListenPortMetrics)
+}
+
+type listenSocket struct {
+	Proto string
+	Port  int
+	Inode uint64
+}
+
+// ListenPortMetrics report every listening tcp port with the command of
+// the process owning the socket, the comm tag is left out if the owner can
+// not be found, e.g. without permission to read its fds
+func ListenPortMetrics() []*common.Metric {
+	var sockets []listenSocket
+	for _, proto := range []string{"tcp", "tcp6"} {
+		content, err := ioutil.ReadFile(procPath("net", proto))
+		if err != nil {
+			collectorLog("ListenPortMetrics").Debugf("skip listen ports of %s: %s", proto, err)
+			continue
+		}
+		for _, c := range parseTCPTable(string(content)) {
+			if c.State == tcpListen {
+				sockets = append(sockets, listenSocket{Proto: proto, Port: c.LocalPort, Inode: c.Inode})
+			}
+		}
+	}
+	if len(sockets) == 0 {
+		return nil
+	}
+	inodes := make(map[uint64]bool, len(sockets))
+	for _, s := range sockets {
+		if s.Inode != 0 {
+			inodes[s.Inode] = true
+		}
+	}
+	return listenPortMetrics(sockets, socketOwners(inodes))
+}
+
+func listenPortMetrics(sockets []listenSocket, owners map[uint64]string) (L []*common.Metric) {
+	// a port bound to several addresses or by several workers is one series
+	seen := make(map[string]bool)
+	for _, s := range sockets {
+		tags := map[string]string{"port": strconv.Itoa(s.Port), "proto": s.Proto}
+		if comm := owners[s.Inode]; comm != "" {
+			tags["comm"] = comm
+		}
+		key := tags["proto"] + "/" + tags["port"] + "/" + tags["comm"]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		L = append(L, toMetric("net.listen.port", 1, tags))
+	}
+	sort.SliceStable(L, func(i, j int) bool {
+		a, b := L[i].Tags, L[j].Tags
+		if a["proto"] != b["proto"] {
+			return a["proto"] < b["proto"]
+		}
+		pa, _ := strconv.Atoi(a["port"])
+		pb, _ := strconv.Atoi(b["port"])
+		return pa < pb
+	})
+	return
+}
+
+// socketOwners maps the socket inodes to the comm of a process holding
+// them, found through the "socket:[inode]" links of /proc/<pid>/fd
+func socketOwners(inodes map[uint64]bool) map[uint64]string {
+	owners := make(map[uint64]string)
+	if len(inodes) == 0 {
+		return owners
+	}
+	pids, err := listPids()
+	if err != nil {
+		collectorLog("ListenPortMetrics").Errorf("failed to list processes: %s", err)
+		return owners
+	}
+	for _, pid := range pids {
+		fdDir := pidPath(pid, "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// process exited or we lack permission
+			continue
+		}
+		comm := ""
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+			if err != nil || !inodes[inode] {
+				continue
+			}
+			if _, ok := owners[inode]; ok {
+				continue
+			}
+			if comm == "" {
+				content, err := ioutil.ReadFile(pidPath(pid, "comm"))
+				if err != nil {
+					break
+				}
+				comm = strings.TrimSpace(string(content))
+			}
+			owners[inode] = comm
+		}
+		if len(owners) == len(inodes) {
+			break
+		}
+	}
+	return owners
+}
@@ -0,0 +1,56 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ListenPortMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	old := procRoot
+	defer func() { procRoot = old }()
+	procRoot = dir
+
+	os.MkdirAll(filepath.Join(dir, "net"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "net", "tcp"), []byte(tcpSample), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "net", "tcp6"), []byte(tcp6Sample), 0644)
+	fdFixture := func(pid, comm string, links map[string]string) {
+		os.MkdirAll(filepath.Join(dir, pid, "fd"), 0755)
+		ioutil.WriteFile(filepath.Join(dir, pid, "comm"), []byte(comm+"\n"), 0644)
+		for fd, target := range links {
+			if err := os.Symlink(target, filepath.Join(dir, pid, "fd", fd)); err != nil {
+				t.Fatalf("create fd fixture fatal: %s", err)
+			}
+		}
+	}
+	fdFixture("100", "mysqld", map[string]string{"0": "/dev/null", "3": "socket:[23456]", "4": "socket:[34567]"})
+	fdFixture("200", "sshd", map[string]string{"3": "socket:[19876]", "4": "pipe:[111]"})
+	// the owner of 20123 is not readable
+
+	L := ListenPortMetrics()
+	if len(L) != 3 {
+		t.Fatalf("listen port metrics fatal: %d", len(L))
+	}
+	want := []map[string]string{
+		{"port": "3306", "proto": "tcp", "comm": "mysqld"},
+		{"port": "6379", "proto": "tcp"},
+		{"port": "22", "proto": "tcp6", "comm": "sshd"},
+	}
+	for i, tags := range want {
+		m := L[i]
+		if m.Name != "net.listen.port" || m.Value.(int) != 1 || len(m.Tags) != len(tags) {
+			t.Fatalf("listen port %d fatal: %s", i, m.String())
+		}
+		for k, v := range tags {
+			if m.Tags[k] != v {
+				t.Fatalf("listen port %d tag %s fatal: %s", i, k, m.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sysinfo
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type loadAvg struct {
+	Avg1, Avg5, Avg15 float64
+	Running, Total    int
+}
+
+// LoadMetrics report load averages and runnable/total tasks, it is registered
+// only on linux and darwin. load.* is the canonical load average,
+// cpu.loadavg.* of CpuMetrics is kept for existing dashboards
+func LoadMetrics() (L []*common.Metric) {
+	load, err := readLoadavg()
+	if err != nil {
+		collectorLog("LoadMetrics").Errorf("failed to read loadavg: %s", err)
+		return collectorError("LoadMetrics", errReasonRead)
+	}
+	L = append(L, toMetric("load.1min", load.Avg1, nil))
+	L = append(L, toMetric("load.5min", load.Avg5, nil))
+	L = append(L, toMetric("load.15min", load.Avg15, nil))
+	// not known on darwin
+	if load.Total > 0 {
+		L = append(L, toMetric("load.running", load.Running, nil))
+		L = append(L, toMetric("load.total", load.Total, nil))
+	}
+	L = append(L, toMetric("load.1min.percore", common.SetPrecision(load.Avg1/float64(runtime.NumCPU()), 2), nil))
+	return
+}
+
+// parseLoadavg parses "0.20 0.18 0.12 1/80 11206"
+func parseLoadavg(content string) (load loadAvg, err error) {
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return load, fmt.Errorf("invalid loadavg content: %q", content)
+	}
+	avgs := []*float64{&load.Avg1, &load.Avg5, &load.Avg15}
+	for i, p := range avgs {
+		if *p, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, err
+		}
+	}
+	tasks := strings.SplitN(fields[3], "/", 2)
+	if len(tasks) != 2 {
+		return load, fmt.Errorf("invalid loadavg tasks: %q", fields[3])
+	}
+	if load.Running, err = strconv.Atoi(tasks[0]); err != nil {
+		return load, err
+	}
+	if load.Total, err = strconv.Atoi(tasks[1]); err != nil {
+		return load, err
+	}
+	return load, nil
+}
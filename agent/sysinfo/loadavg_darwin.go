@@ -0,0 +1,37 @@
+package sysinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "LoadMetrics", LoadMetrics)
+}
+
+// readLoadavg reads the vm.loadavg sysctl, it has no task counts
+func readLoadavg() (loadAvg, error) {
+	out, err := execCommand(execTimeout, "sysctl", "-n", "vm.loadavg")
+	if err != nil {
+		return loadAvg{}, err
+	}
+	return parseSysctlLoadavg(string(out))
+}
+
+// parseSysctlLoadavg parses "{ 1.53 1.71 1.80 }"
+func parseSysctlLoadavg(content string) (load loadAvg, err error) {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(content), "{}"))
+	if len(fields) < 3 {
+		return load, fmt.Errorf("invalid vm.loadavg content: %q", content)
+	}
+	avgs := []*float64{&load.Avg1, &load.Avg5, &load.Avg15}
+	for i, p := range avgs {
+		if *p, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, err
+		}
+	}
+	return load, nil
+}
@@ -0,0 +1,22 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// load is read from procfs here, it is cheap enough to sub-sample
+func init() {
+	RegisterFunc(common.TYPE_CPU, "LoadMetrics", LoadMetrics)
+	subSampleSources["LoadMetrics"] = LoadMetrics
+}
+
+// readLoadavg reads /proc/loadavg
+func readLoadavg() (loadAvg, error) {
+	content, err := ioutil.ReadFile(procPath("loadavg"))
+	if err != nil {
+		return loadAvg{}, err
+	}
+	return parseLoadavg(string(content))
+}
@@ -0,0 +1,22 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+func Test_parseLoadavg(t *testing.T) {
+	load, err := parseLoadavg("0.20 0.18 0.12 1/80 11206\n")
+	if err != nil {
+		t.Fatalf("parse loadavg fatal: %s", err)
+	}
+	want := loadAvg{Avg1: 0.20, Avg5: 0.18, Avg15: 0.12, Running: 1, Total: 80}
+	if load != want {
+		t.Fatalf("parse loadavg fatal: %+v - %+v", load, want)
+	}
+
+	for _, c := range []string{"", "0.20 0.18", "0.20 0.18 0.12 180 11206", "a 0.18 0.12 1/80 1"} {
+		if _, err := parseLoadavg(c); err == nil {
+			t.Fatalf("parse invalid loadavg fatal: %q", c)
+		}
+	}
+}
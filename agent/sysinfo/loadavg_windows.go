@@ -0,0 +1,10 @@
+package sysinfo
+
+import (
+	"errors"
+)
+
+// windows has no load average, LoadMetrics is not registered here
+func readLoadavg() (loadAvg, error) {
+	return loadAvg{}, errors.New("loadavg is not supported on windows")
+}
@@ -0,0 +1,63 @@
+package sysinfo
+
+import (
+	"fmt"
+
+	"github.com/lodastack/log"
+)
+
+// collectorLogger tags the messages of one collector with its name and
+// severity, e.g. "collector=ConntrackMetrics level=debug read ... failed";
+// the [log] loglevel drops the messages below it
+type collectorLogger struct {
+	name string
+}
+
+func collectorLog(name string) collectorLogger {
+	return collectorLogger{name: name}
+}
+
+// severities of collector messages
+const (
+	levelDebug   = "debug"
+	levelInfo    = "info"
+	levelWarning = "warning"
+	levelError   = "error"
+)
+
+// logOutput writes a tagged message to the agent log, replaced in tests
+var logOutput = func(level string, msg string) {
+	switch level {
+	case levelDebug:
+		log.Debug(msg)
+	case levelInfo:
+		log.Info(msg)
+	case levelWarning:
+		log.Warning(msg)
+	default:
+		log.Error(msg)
+	}
+}
+
+func (l collectorLogger) logf(level string, format string, args ...interface{}) {
+	logOutput(level, fmt.Sprintf("collector=%s level=%s %s", l.name, level, fmt.Sprintf(format, args...)))
+}
+
+// Debugf logs conditions expected on some hosts, e.g. an absent kernel module
+func (l collectorLogger) Debugf(format string, args ...interface{}) {
+	l.logf(levelDebug, format, args...)
+}
+
+func (l collectorLogger) Infof(format string, args ...interface{}) {
+	l.logf(levelInfo, format, args...)
+}
+
+// Warningf logs transient failures, the next cycle may succeed
+func (l collectorLogger) Warningf(format string, args ...interface{}) {
+	l.logf(levelWarning, format, args...)
+}
+
+// Errorf logs failures that keep the collector from reporting
+func (l collectorLogger) Errorf(format string, args ...interface{}) {
+	l.logf(levelError, format, args...)
+}
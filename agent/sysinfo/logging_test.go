@@ -0,0 +1,58 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func captureLog() (*[]string, func()) {
+	var lines []string
+	old := logOutput
+	logOutput = func(level string, msg string) {
+		lines = append(lines, level+": "+msg)
+	}
+	return &lines, func() { logOutput = old }
+}
+
+func Test_collectorLogFailing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	// a directory in place of pressure/cpu fails to read
+	os.Mkdir(filepath.Join(dir, "cpu"), 0755)
+
+	lines, restore := captureLog()
+	defer restore()
+	if L := psiMetrics(dir); len(L) != 0 {
+		t.Fatalf("psi metrics of a failing collector fatal: %d", len(L))
+	}
+	if len(*lines) != 3 {
+		t.Fatalf("log lines fatal: %v", *lines)
+	}
+	if !strings.HasPrefix((*lines)[0], "error: collector=PsiMetrics level=error failed to read pressure/cpu: ") {
+		t.Fatalf("error line fatal: %s", (*lines)[0])
+	}
+	if !strings.HasPrefix((*lines)[1], "debug: collector=PsiMetrics level=debug skip pressure/memory") {
+		t.Fatalf("debug line fatal: %s", (*lines)[1])
+	}
+}
+
+func Test_collectorLogLevel(t *testing.T) {
+	lines, restore := captureLog()
+	defer restore()
+	// conntrack module not loaded is debug noise, the [log] loglevel drops it
+	conntrackMetrics("/nonexistent")
+	if len(*lines) != 1 || !strings.HasPrefix((*lines)[0], "debug: collector=ConntrackMetrics level=debug ") {
+		t.Fatalf("absent module line fatal: %v", *lines)
+	}
+	*lines = nil
+	collectorLog("TestMetrics").Warningf("retry %d", 1)
+	if len(*lines) != 1 || (*lines)[0] != "warning: collector=TestMetrics level=warning retry 1" {
+		t.Fatalf("warning line fatal: %v", *lines)
+	}
+}
@@ -0,0 +1,113 @@
+package sysinfo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// max bytes read from one file per cycle, the rest is read next cycle
+const logTailMaxRead = 4 << 20
+
+var (
+	logTailsLock sync.Mutex
+	// name|path -> how far the file was read
+	logTails = make(map[string]*logTail)
+)
+
+func init() {
+	RegisterFunc(common.TYPE_FS, "LogWatchMetrics", LogWatchMetrics)
+}
+
+// LogWatchMetrics report the lines appended to the files of
+// Conf.LogWatchers since the last cycle that match their pattern
+func LogWatchMetrics() (L []*common.Metric) {
+	if common.Conf == nil || len(common.Conf.LogWatchers) == 0 {
+		return
+	}
+	logTailsLock.Lock()
+	defer logTailsLock.Unlock()
+	for i := range common.Conf.LogWatchers {
+		w := &common.Conf.LogWatchers[i]
+		key := w.Name + "|" + w.Path
+		t, ok := logTails[key]
+		if !ok {
+			t = new(logTail)
+			logTails[key] = t
+		}
+		count, err := t.count(w.Path, w.Match)
+		if err != nil {
+			if os.IsNotExist(err) {
+				collectorLog("LogWatchMetrics").Debugf("skip logwatcher %s: %s", w.Name, err)
+			} else {
+				collectorLog("LogWatchMetrics").Errorf("failed to read log of logwatcher %s: %s", w.Name, err)
+			}
+			continue
+		}
+		L = append(L, toMetric("log.match.count", count, map[string]string{"name": w.Name, "path": w.Path}))
+	}
+	return
+}
+
+// logTail remembers the file and the offset of the last complete line read,
+// like utmpTail does for wtmp
+type logTail struct {
+	file   os.FileInfo
+	offset int64
+}
+
+// count returns the number of new complete lines matching match. A file seen
+// the first time is read from its end, a rotated or truncated one from its
+// start.
+func (t *logTail) count(path string, match func([]byte) bool) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case t.file == nil:
+		t.file, t.offset = fi, fi.Size()
+		return 0, nil
+	case !os.SameFile(t.file, fi), fi.Size() < t.offset:
+		t.offset = 0
+	}
+	t.file = fi
+
+	if _, err = f.Seek(t.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	size := fi.Size() - t.offset
+	if size > logTailMaxRead {
+		size = logTailMaxRead
+	}
+	buf := make([]byte, size)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+	// a partial last line is read again once it is complete
+	end := bytes.LastIndexByte(buf[:n], '\n')
+	if end < 0 {
+		return 0, nil
+	}
+	t.offset += int64(end + 1)
+
+	count := 0
+	for _, line := range bytes.Split(buf[:end], []byte{'\n'}) {
+		// preallocated log files are padded with NUL
+		line = bytes.Trim(line, "\x00")
+		if len(line) > 0 && match(line) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,83 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_LogWatchMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logwatch-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendLog := func(content string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatalf("open log fatal: %s", err)
+		}
+		f.WriteString(content)
+		f.Close()
+	}
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	w := common.LogWatcher{Name: "app-error", Path: path, Pattern: "ERROR"}
+	if err := w.Compile(); err != nil {
+		t.Fatalf("compile logwatcher fatal: %s", err)
+	}
+	common.Conf = &common.AgentConfig{LogWatchers: []common.LogWatcher{w}}
+	defer func() {
+		logTailsLock.Lock()
+		delete(logTails, "app-error|"+path)
+		logTailsLock.Unlock()
+	}()
+
+	counts := func() int {
+		L := LogWatchMetrics()
+		if len(L) != 1 || L[0].Name != "log.match.count" || L[0].Tags["name"] != "app-error" || L[0].Tags["path"] != path {
+			t.Fatalf("log watch metrics fatal: %v", L)
+		}
+		return L[0].Value.(int)
+	}
+
+	// the history before the agent started is not counted
+	appendLog("ERROR old\n")
+	if n := counts(); n != 0 {
+		t.Fatalf("first read should start at the end: %d", n)
+	}
+	appendLog("INFO ok\nERROR one\nERROR two\nERROR partial")
+	if n := counts(); n != 2 {
+		t.Fatalf("count of new lines fatal: %d", n)
+	}
+	appendLog(" line\n\x00\x00")
+	if n := counts(); n != 1 {
+		t.Fatalf("count of the completed line fatal: %d", n)
+	}
+
+	// rotate: move away and start a new file
+	os.Rename(path, path+".1")
+	appendLog("ERROR after rotate\nINFO ok\n")
+	if n := counts(); n != 1 {
+		t.Fatalf("count after rotate fatal: %d", n)
+	}
+	// truncated in place by copytruncate
+	os.Truncate(path, 0)
+	appendLog("ERROR a\nERROR b\n")
+	if n := counts(); n != 2 {
+		t.Fatalf("count after truncate fatal: %d", n)
+	}
+	if n := counts(); n != 0 {
+		t.Fatalf("count without new lines fatal: %d", n)
+	}
+
+	os.Remove(path)
+	if L := LogWatchMetrics(); len(L) != 0 {
+		t.Fatalf("missing log should be skipped: %v", L)
+	}
+}
@@ -1,44 +1,98 @@
 package sysinfo
 
 import (
-	"github.com/lodastack/agent/agent/common"
+	"io/ioutil"
 
-	"github.com/lodastack/log"
-	"github.com/lodastack/nux"
+	"github.com/lodastack/agent/agent/common"
 )
 
+type memInfo struct {
+	MemTotal, MemFree, MemAvailable uint64
+	Buffers, Cached                 uint64
+	SwapTotal, SwapFree             uint64
+	MemAvaSupport                   bool
+}
+
+func init() {
+	RegisterFunc(common.TYPE_MEM, "MemMetrics", MemMetrics)
+}
+
+// MemMetrics report memory and swap usage from /proc/meminfo
 func MemMetrics() []*common.Metric {
-	m, err := nux.MemInfo()
+	content, err := ioutil.ReadFile(procPath("meminfo"))
 	if err != nil {
-		log.Error("failed to collect Metrics:", err)
-		return nil
+		collectorLog("MemMetrics").Errorf("failed to read meminfo: %s", err)
+		return collectorError("MemMetrics", errReasonRead)
 	}
-	var memFree uint64
-	if m.MemAvaSupport {
-		memFree = m.MemAvailable
-	} else {
-		memFree = m.MemFree + m.Buffers + m.Cached
+	return memMetrics(parseMeminfo(string(content)))
+}
+
+func memMetrics(m memInfo) []*common.Metric {
+	// mem.free and mem.used keep their meaning from before /proc/meminfo was
+	// parsed directly: free is what is available to new processes
+	memAvailable := m.MemAvailable
+	if !m.MemAvaSupport {
+		memAvailable = m.MemFree + m.Buffers + m.Cached
+	}
+	var memUsed uint64
+	if m.MemTotal > memAvailable {
+		memUsed = m.MemTotal - memAvailable
+	}
+	// used the way free(1) reports it
+	var memUsedRaw uint64
+	if m.MemTotal > m.MemFree+m.Buffers+m.Cached {
+		memUsedRaw = m.MemTotal - m.MemFree - m.Buffers - m.Cached
 	}
-	memUsed := m.MemTotal - memFree
 
 	pmemUsed := 0.0
 	if m.MemTotal != 0 {
 		pmemUsed = common.SetPrecision(float64(memUsed)*100.0/float64(m.MemTotal), 2)
 	}
 
+	var swapUsed uint64
+	if m.SwapTotal > m.SwapFree {
+		swapUsed = m.SwapTotal - m.SwapFree
+	}
 	pswapUsed := 0.0
 	if m.SwapTotal != 0 {
-		pswapUsed = common.SetPrecision(float64(m.SwapUsed)*100.0/float64(m.SwapTotal), 2)
+		pswapUsed = common.SetPrecision(float64(swapUsed)*100.0/float64(m.SwapTotal), 2)
 	}
 
 	return []*common.Metric{
 		toMetric("mem.total", m.MemTotal, nil),
 		toMetric("mem.used", memUsed, nil),
-		toMetric("mem.free", memFree, nil),
+		toMetric("mem.free", memAvailable, nil),
+		toMetric("mem.available", memAvailable, nil),
 		toMetric("mem.used.percent", pmemUsed, nil),
+		toMetric("mem.free.raw", m.MemFree, nil),
+		toMetric("mem.used.raw", memUsedRaw, nil),
 		toMetric("mem.buffers", m.Buffers, nil),
 		toMetric("mem.cached", m.Cached, nil),
+		toMetric("mem.swap.total", m.SwapTotal, nil),
+		toMetric("mem.swap.used", swapUsed, nil),
 		toMetric("mem.swap.used.percent", pswapUsed, nil),
 	}
+}
 
+// parseMeminfo reads the fields we need from /proc/meminfo, in bytes
+func parseMeminfo(content string) (m memInfo) {
+	fields := map[string]*uint64{
+		"MemTotal:":  &m.MemTotal,
+		"MemFree:":   &m.MemFree,
+		"Buffers:":   &m.Buffers,
+		"Cached:":    &m.Cached,
+		"SwapTotal:": &m.SwapTotal,
+		"SwapFree:":  &m.SwapFree,
+	}
+	for key, p := range fields {
+		if v, ok := parseKBField(content, key); ok {
+			*p = v * 1024
+		}
+	}
+	// MemAvailable only exists since linux 3.14
+	if v, ok := parseKBField(content, "MemAvailable:"); ok {
+		m.MemAvailable = v * 1024
+		m.MemAvaSupport = true
+	}
+	return
 }
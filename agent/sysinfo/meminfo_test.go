@@ -0,0 +1,70 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+const meminfoWithSwap = `MemTotal:        8000000 kB
+MemFree:         2000000 kB
+MemAvailable:    5000000 kB
+Buffers:          500000 kB
+Cached:          1500000 kB
+SwapCached:            0 kB
+Active:          3000000 kB
+SwapTotal:       2000000 kB
+SwapFree:        1500000 kB
+`
+
+const meminfoNoSwap = `MemTotal:        1000000 kB
+MemFree:          400000 kB
+Buffers:          100000 kB
+Cached:           100000 kB
+SwapCached:            0 kB
+SwapTotal:             0 kB
+SwapFree:              0 kB
+`
+
+func memValues(m memInfo) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, metric := range memMetrics(m) {
+		values[metric.Name] = metric.Value
+	}
+	return values
+}
+
+func Test_parseMeminfo(t *testing.T) {
+	m := parseMeminfo(meminfoWithSwap)
+	if m.MemTotal != 8000000*1024 || m.Cached != 1500000*1024 || !m.MemAvaSupport {
+		t.Fatalf("parse meminfo fatal: %+v", m)
+	}
+	values := memValues(m)
+	if values["mem.used"] != uint64(3000000*1024) || values["mem.free"] != uint64(5000000*1024) {
+		t.Fatalf("mem.used fatal: %v %v", values["mem.used"], values["mem.free"])
+	}
+	if values["mem.used.raw"] != uint64(4000000*1024) || values["mem.free.raw"] != uint64(2000000*1024) {
+		t.Fatalf("mem.used.raw fatal: %v %v", values["mem.used.raw"], values["mem.free.raw"])
+	}
+	if values["mem.available"] != uint64(5000000*1024) {
+		t.Fatalf("mem.available fatal: %v", values["mem.available"])
+	}
+	if values["mem.used.percent"] != 37.5 {
+		t.Fatalf("mem.used.percent fatal: %v", values["mem.used.percent"])
+	}
+	if values["mem.swap.used"] != uint64(500000*1024) || values["mem.swap.used.percent"] != 25.0 {
+		t.Fatalf("mem.swap fatal: %v %v", values["mem.swap.used"], values["mem.swap.used.percent"])
+	}
+}
+
+func Test_parseMeminfoNoSwap(t *testing.T) {
+	m := parseMeminfo(meminfoNoSwap)
+	if m.MemAvaSupport {
+		t.Fatalf("MemAvailable should be missing")
+	}
+	values := memValues(m)
+	if values["mem.available"] != uint64(600000*1024) || values["mem.free"] != uint64(600000*1024) {
+		t.Fatalf("mem.available fallback fatal: %v %v", values["mem.available"], values["mem.free"])
+	}
+	if values["mem.swap.total"] != uint64(0) || values["mem.swap.used.percent"] != 0.0 {
+		t.Fatalf("swap disabled fatal: %v %v", values["mem.swap.total"], values["mem.swap.used.percent"])
+	}
+}
@@ -0,0 +1,39 @@
+package sysinfo
+
+import (
+	"github.com/lodastack/agent/agent/common"
+)
+
+// unit and help of the builtin metrics, used by self-describing outputs
+func init() {
+	common.SetMetricMeta("agent.alive", "", "agent is running")
+	common.SetMetricMeta("agent.collector.success.ratio", "ratio", "fraction of recent cycles the collector ran without collector.error, panic or timeout")
+
+	common.SetMetricMeta("cpu.idle", "percent", "CPU idle time")
+	common.SetMetricMeta("cpu.idle.core", "percent", "CPU idle time per core")
+	common.SetMetricMeta("cpu.loadavg.1", "", "1 minute load average")
+	common.SetMetricMeta("cpu.loadavg.5", "", "5 minute load average")
+	common.SetMetricMeta("cpu.loadavg.15", "", "15 minute load average")
+	common.SetMetricMeta("cpu.runqueue.latency.rate", "milliseconds per second", "time runnable tasks waited for the CPU")
+
+	common.SetMetricMeta("mem.used.percent", "percent", "used memory")
+	common.SetMetricMeta("mem.swap.used.percent", "percent", "used swap")
+
+	common.SetMetricMeta("fs.space.used.percent", "percent", "used space of the filesystem")
+	common.SetMetricMeta("fs.inodes.used.percent", "percent", "used inodes of the filesystem")
+	common.SetMetricMeta("kernel.files.allocated.percent", "percent", "allocated file handles of the system limit")
+	common.SetMetricMeta("kernel.files.open.byfstype", "", "open file descriptors per filesystem type")
+
+	common.SetMetricMeta("net.in", "bits per second", "received traffic of the interface")
+	common.SetMetricMeta("net.out", "bits per second", "sent traffic of the interface")
+	common.SetMetricMeta("net.in.dropped", "packets per second", "dropped received packets")
+	common.SetMetricMeta("net.out.dropped", "packets per second", "dropped sent packets")
+	common.SetMetricMeta("net.port.established", "", "established connections of the port")
+	common.SetMetricMeta("net.port.listening", "", "port is listening")
+
+	common.SetMetricMeta("time.offset", "seconds", "local clock offset to the NTP server")
+	common.SetMetricMeta("kernel.timezone.offset.seconds", "seconds", "UTC offset of the local timezone")
+	common.SetMetricMeta("kernel.coredumps.rate", "files per second", "new core files")
+	common.SetMetricMeta("power.battery.percent", "percent", "battery capacity")
+	common.SetMetricMeta("container.runtime.up", "", "container runtime answers on its socket")
+}
@@ -0,0 +1,73 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type mountInfo struct {
+	Device     string
+	MountPoint string
+	FsType     string
+	Options    []string
+}
+
+func listMounts() ([]mountInfo, error) {
+	read, err := ioutil.ReadFile(procPath("mounts"))
+	if err != nil {
+		return nil, err
+	}
+	return parseMounts(string(read)), nil
+}
+
+// parseMounts parses the fstab-like format of /proc/mounts
+func parseMounts(content string) (mounts []mountInfo) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			Device:     unescapeMountField(fields[0]),
+			MountPoint: unescapeMountField(fields[1]),
+			FsType:     fields[2],
+			Options:    strings.Split(fields[3], ","),
+		})
+	}
+	return
+}
+
+// unescapeMountField decodes the octal escapes (\040 for space) the kernel
+// uses for whitespace in mount fields
+func unescapeMountField(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				buf = append(buf, byte(v))
+				i += 3
+				continue
+			}
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
+
+// mountOf returns the mount holding path, the one with the longest mount point prefix
+func mountOf(path string, mounts []mountInfo) (m mountInfo, ok bool) {
+	for _, mount := range mounts {
+		mp := mount.MountPoint
+		if path != mp && mp != "/" && !strings.HasPrefix(path, mp+"/") {
+			continue
+		}
+		if !ok || len(mp) >= len(m.MountPoint) {
+			m, ok = mount, true
+		}
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package sysinfo
+
+import (
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_FS, "MountStateMetrics", MountStateMetrics)
+}
+
+// MountStateMetrics report read-only real filesystems and whether the
+// mounts of Conf.RequiredMounts exist
+func MountStateMetrics() []*common.Metric {
+	mounts, err := listMounts()
+	if err != nil {
+		collectorLog("MountStateMetrics").Errorf("failed to list mounts: %s", err)
+		return nil
+	}
+	var required []string
+	if common.Conf != nil {
+		required = common.Conf.RequiredMounts
+	}
+	return mountStateMetrics(mounts, required)
+}
+
+func mountStateMetrics(mounts []mountInfo, required []string) (L []*common.Metric) {
+	for _, m := range filterDfMounts(mounts) {
+		readonly := 0
+		if inStrings("ro", m.Options) {
+			readonly = 1
+		}
+		tags := map[string]string{"mount": m.MountPoint, "fstype": m.FsType}
+		L = append(L, toMetric("df.mount.readonly", readonly, tags))
+	}
+
+	for _, mp := range required {
+		present := 0
+		for _, m := range mounts {
+			if m.MountPoint == mp {
+				present = 1
+				break
+			}
+		}
+		L = append(L, toMetric("df.mount.present", present, map[string]string{"mount": mp}))
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const mountStateSample = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 / ext4 rw,relatime,errors=remount-ro 0 0
+/dev/sdb1 /data xfs ro,relatime,attr2,inode64,noquota 0 0
+/dev/sr0 /media/cd\040rom iso9660 ro,nosuid,nodev 0 0
+`
+
+func Test_mountStateMetrics(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{}
+
+	L := mountStateMetrics(parseMounts(mountStateSample), []string{"/data", "/backup"})
+	readonly := map[string]int{}
+	present := map[string]int{}
+	for _, m := range L {
+		switch m.Name {
+		case "df.mount.readonly":
+			readonly[m.Tags["mount"]] = m.Value.(int)
+		case "df.mount.present":
+			present[m.Tags["mount"]] = m.Value.(int)
+		}
+	}
+	// errors=remount-ro of / is an option value, not the ro flag
+	want := map[string]int{"/": 0, "/data": 1, "/media/cd rom": 1}
+	if len(readonly) != len(want) {
+		t.Fatalf("mount readonly fatal: %v", readonly)
+	}
+	for mp, v := range want {
+		if readonly[mp] != v {
+			t.Fatalf("mount readonly of %s fatal: %d, want %d", mp, readonly[mp], v)
+		}
+	}
+	if present["/data"] != 1 || present["/backup"] != 0 {
+		t.Fatalf("mount present fatal: %v", present)
+	}
+}
@@ -0,0 +1,47 @@
+package sysinfo
+
+import (
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/nux"
+)
+
+var netDevRates = common.NewRateTracker()
+
+// netIfCounters maps the interfaces sampled by NetMetrics to the counters
+// of netDevMetrics, so /proc/net/dev is read once for both
+func netIfCounters(netIfs []*nux.NetIf) map[string]map[string]uint64 {
+	res := make(map[string]map[string]uint64, len(netIfs))
+	for _, netIf := range netIfs {
+		res[netIf.Iface] = map[string]uint64{
+			"in_bytes":    netIf.InBytes,
+			"in_packets":  netIf.InPackages,
+			"in_errors":   netIf.InErrors,
+			"in_dropped":  netIf.InDropped,
+			"out_bytes":   netIf.OutBytes,
+			"out_packets": netIf.OutPackages,
+			"out_errors":  netIf.OutErrors,
+			"out_dropped": netIf.OutDropped,
+		}
+	}
+	return res
+}
+
+// netDevMetrics report per second traffic, packets, errors and drops of
+// the monitored interfaces as net.if.*
+func netDevMetrics(rates *common.RateTracker, stats map[string]map[string]uint64, now float64) (L []*common.Metric) {
+	for iface, counters := range stats {
+		// ignore docker and warden bridge
+		if !common.IsMonitoredInterface(iface) {
+			continue
+		}
+		tags := map[string]string{"iface": iface}
+		for field, value := range counters {
+			name := "net.if." + field
+			if rate, ok := rates.RateAt(name, tags, value, now); ok {
+				L = append(L, toMetric(name, common.SetPrecision(rate, 2), tags))
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/nux"
+)
+
+func netIfSample(inBytes, inPackets, inDropped, outBytes, outPackets uint64) []*nux.NetIf {
+	return []*nux.NetIf{
+		{Iface: "lo", InBytes: 123456, InPackages: 100, OutBytes: 123456, OutPackages: 100},
+		{Iface: "eth0", InBytes: inBytes, InPackages: inPackets, InErrors: 1, InDropped: inDropped,
+			OutBytes: outBytes, OutPackages: outPackets, OutErrors: 3, OutDropped: 4},
+		{Iface: "docker0", InBytes: 5000, InPackages: 50, OutBytes: 6000, OutPackages: 60},
+	}
+}
+
+func Test_netDevMetrics(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{IfacePrefix: []string{"eth"}}
+
+	stats := netIfCounters(netIfSample(1000000, 2000, 2, 500000, 1000))
+	if len(stats) != 3 || stats["eth0"]["in_bytes"] != 1000000 || stats["eth0"]["out_dropped"] != 4 {
+		t.Fatalf("net if counters fatal: %v", stats)
+	}
+
+	rates := common.NewRateTracker()
+	if L := netDevMetrics(rates, stats, 100); len(L) != 0 {
+		t.Fatalf("net dev rate of first sample fatal: %d", len(L))
+	}
+	L := netDevMetrics(rates, netIfCounters(netIfSample(1100000, 2100, 4, 550000, 1050)), 110)
+	got := map[string]float64{}
+	for _, m := range L {
+		if m.Tags["iface"] != "eth0" {
+			t.Fatalf("net dev metric of filtered interface: %s", m.String())
+		}
+		got[m.Name] = m.Value.(float64)
+	}
+	want := map[string]float64{
+		"net.if.in_bytes":    10000,
+		"net.if.out_bytes":   5000,
+		"net.if.in_packets":  10,
+		"net.if.out_packets": 5,
+		"net.if.in_errors":   0,
+		"net.if.in_dropped":  0.2,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("net dev rate %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+}
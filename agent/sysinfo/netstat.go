@@ -3,7 +3,6 @@ package sysinfo
 import (
 	"github.com/lodastack/agent/agent/common"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
@@ -37,7 +36,7 @@ func NetstatMetrics() (L []*common.Metric) {
 	tcpExts, err := nux.Netstat("TcpExt")
 
 	if err != nil {
-		log.Error("failed to collect NetstatMetrics:", err)
+		collectorLog("NetstatMetrics").Errorf("failed to collect NetstatMetrics: %s", err)
 		return
 	}
 
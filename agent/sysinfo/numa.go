@@ -0,0 +1,101 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+var numaRates = common.NewRateTracker()
+
+// counters of /proc/vmstat reported as mem.numa.<name>.rate
+var numaVmstatKeys = []string{"numa_hit", "numa_miss", "numa_foreign"}
+
+func init() {
+	RegisterFunc(common.TYPE_MEM, "NumaMetrics", NumaMetrics)
+}
+
+// NumaMetrics report the memory of every NUMA node and the NUMA allocation
+// rates, single node systems report nothing
+func NumaMetrics() []*common.Metric {
+	nodes := numaNodeMeminfo(sysPath("devices", "system", "node"))
+	if len(nodes) < 2 {
+		return nil
+	}
+	L := numaNodeMetrics(nodes)
+	content, err := ioutil.ReadFile(procPath("vmstat"))
+	if err != nil {
+		collectorLog("NumaMetrics").Errorf("failed to read vmstat: %s", err)
+		return L
+	}
+	return append(L, numaRateMetrics(numaRates, parseVmstat(string(content)), common.RateNow())...)
+}
+
+// numaNodeMeminfo reads node<N>/meminfo of every node, in kB
+func numaNodeMeminfo(dir string) map[string]map[string]uint64 {
+	res := make(map[string]map[string]uint64)
+	files, _ := filepath.Glob(filepath.Join(dir, "node[0-9]*", "meminfo"))
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			collectorLog("NumaMetrics").Errorf("failed to read node meminfo: %s", err)
+			continue
+		}
+		node := strings.TrimPrefix(filepath.Base(filepath.Dir(file)), "node")
+		res[node] = parseNodeMeminfo(string(content))
+	}
+	return res
+}
+
+// parseNodeMeminfo parses lines like "Node 0 MemFree:   1234 kB"
+func parseNodeMeminfo(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "Node" {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[strings.TrimSuffix(fields[2], ":")] = v
+	}
+	return res
+}
+
+func numaNodeMetrics(nodes map[string]map[string]uint64) (L []*common.Metric) {
+	names := make([]string, 0, len(nodes))
+	for node := range nodes {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+	for _, node := range names {
+		info := nodes[node]
+		total, free := info["MemTotal"], info["MemFree"]
+		tags := map[string]string{"node": node}
+		L = append(L, toMetric("mem.numa.free", free*1024, tags))
+		if total > 0 && free <= total {
+			L = append(L, toMetric("mem.numa.used.percent", common.SetPrecision(float64(total-free)*100/float64(total), 2), tags))
+		}
+	}
+	return
+}
+
+func numaRateMetrics(rates *common.RateTracker, vmstat map[string]uint64, now float64) (L []*common.Metric) {
+	for _, key := range numaVmstatKeys {
+		value, ok := vmstat[key]
+		if !ok {
+			continue
+		}
+		name := "mem.numa." + strings.TrimPrefix(key, "numa_") + ".rate"
+		if rate, ok := rates.RateAt(name, nil, value, now); ok {
+			L = append(L, toMetric(name, common.SetPrecision(rate, 2), nil))
+		}
+	}
+	return
+}
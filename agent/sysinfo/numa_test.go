@@ -0,0 +1,57 @@
+package sysinfo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_numaNodeMetrics(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"node0/meminfo": "Node 0 MemTotal:       16000000 kB\nNode 0 MemFree:         4000000 kB\nNode 0 MemUsed:        12000000 kB\n",
+		"node1/meminfo": "Node 1 MemTotal:       16000000 kB\nNode 1 MemFree:        12000000 kB\nNode 1 MemUsed:         4000000 kB\n",
+		"possible":      "0-1\n",
+	})
+	defer os.RemoveAll(dir)
+
+	nodes := numaNodeMeminfo(dir)
+	if len(nodes) != 2 {
+		t.Fatalf("numa nodes fatal: %v", nodes)
+	}
+	L := numaNodeMetrics(nodes)
+	if len(L) != 4 {
+		t.Fatalf("numa node metrics fatal: %d", len(L))
+	}
+	if L[0].Name != "mem.numa.free" || L[0].Tags["node"] != "0" || L[0].Value.(uint64) != 4000000*1024 {
+		t.Fatalf("numa free fatal: %s", L[0].String())
+	}
+	if L[1].Name != "mem.numa.used.percent" || L[1].Value.(float64) != 75 {
+		t.Fatalf("numa used percent fatal: %s", L[1].String())
+	}
+	if L[3].Tags["node"] != "1" || L[3].Value.(float64) != 25 {
+		t.Fatalf("numa used percent of node1 fatal: %s", L[3].String())
+	}
+}
+
+func Test_numaRateMetrics(t *testing.T) {
+	rates := common.NewRateTracker()
+	sample := "nr_free_pages 1000\nnuma_hit 1000\nnuma_miss 10\nnuma_foreign 10\nnuma_interleave 5\n"
+	if L := numaRateMetrics(rates, parseVmstat(sample), 100); len(L) != 0 {
+		t.Fatalf("numa rate of first sample fatal: %d", len(L))
+	}
+	sample = "nr_free_pages 900\nnuma_hit 2000\nnuma_miss 110\nnuma_foreign 10\nnuma_interleave 5\n"
+	got := map[string]float64{}
+	for _, m := range numaRateMetrics(rates, parseVmstat(sample), 110) {
+		got[m.Name] = m.Value.(float64)
+	}
+	want := map[string]float64{"mem.numa.hit.rate": 100, "mem.numa.miss.rate": 10, "mem.numa.foreign.rate": 0}
+	if len(got) != len(want) {
+		t.Fatalf("numa rates fatal: %v", got)
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("numa rate %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/lodastack/agent/agent/common"
-	"github.com/lodastack/log"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
@@ -21,12 +20,16 @@ const (
 	maxPacketSize               = 30
 )
 
+func init() {
+	RegisterFunc(common.TYPE_DEV, "PcapMetrics", PcapMetrics)
+}
+
 // PcapMetrics dep pcap lib
 func PcapMetrics() (L []*common.Metric) {
 	var mu sync.Mutex
 	ifaces, err := net.Interfaces()
 	if err != nil {
-		log.Error("collect net interfaces error:", err)
+		collectorLog("PcapMetrics").Errorf("collect net interfaces error: %s", err)
 		return
 	}
 	var wg sync.WaitGroup
@@ -40,7 +43,7 @@ func PcapMetrics() (L []*common.Metric) {
 		}
 
 		// ignore docker and warden bridge
-		if !common.HasInterfacePrefix(iface.Name) {
+		if !common.IsMonitoredInterface(iface.Name) {
 			continue
 		}
 
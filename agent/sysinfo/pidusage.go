@@ -0,0 +1,67 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "PidMetrics", PidMetrics)
+}
+
+// PidMetrics report the used pids against kernel.pid_max and the number of
+// threads of all processes, to catch fork storms before the pids run out
+func PidMetrics() (L []*common.Metric) {
+	pids, err := listPids()
+	if err != nil {
+		collectorLog("PidMetrics").Errorf("failed to list processes: %s", err)
+		return collectorError("PidMetrics", errReasonRead)
+	}
+	L = append(L, toMetric("kernel.pid.used", len(pids), nil))
+	max, err := readFileUint(procPath("sys", "kernel", "pid_max"))
+	if err != nil {
+		collectorLog("PidMetrics").Errorf("failed to read pid_max: %s", err)
+	} else {
+		L = append(L, toMetric("kernel.pid.max", max, nil))
+		if max > 0 {
+			v := common.SetPrecision(float64(len(pids))*100/float64(max), 2)
+			L = append(L, toMetric("kernel.pid.used.percent", v, nil))
+		}
+	}
+	L = append(L, toMetric("kernel.threads.total", countThreads(pids), nil))
+	return
+}
+
+// countThreads sums the Threads of /proc/<pid>/status, processes that exited
+// meanwhile are skipped
+func countThreads(pids []int) (total uint64) {
+	for _, pid := range pids {
+		content, err := ioutil.ReadFile(pidPath(pid, "status"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				collectorLog("PidMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		if n, ok := parseStatusThreads(string(content)); ok {
+			total += n
+		}
+	}
+	return
+}
+
+// parseStatusThreads returns the number of "Threads:\t4"
+func parseStatusThreads(content string) (uint64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "Threads:") {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "Threads:")), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_PidMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	old := procRoot
+	defer func() { procRoot = old }()
+	procRoot = dir
+
+	os.MkdirAll(filepath.Join(dir, "sys", "kernel"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "sys", "kernel", "pid_max"), []byte("1000\n"), 0644)
+	writeProcFixture(t, dir, 1, "", "Name:\tinit\nThreads:\t1\n")
+	writeProcFixture(t, dir, 300, "", "Name:\tjava\nThreads:\t120\n")
+	// exited between listing and reading status
+	writeProcFixture(t, dir, 400, "", "")
+	// not a process
+	os.MkdirAll(filepath.Join(dir, "net"), 0755)
+
+	got := map[string]float64{}
+	for _, m := range PidMetrics() {
+		switch v := m.Value.(type) {
+		case int:
+			got[m.Name] = float64(v)
+		case uint64:
+			got[m.Name] = float64(v)
+		case float64:
+			got[m.Name] = v
+		}
+	}
+	want := map[string]float64{
+		"kernel.pid.used":         3,
+		"kernel.pid.max":          1000,
+		"kernel.pid.used.percent": 0.3,
+		"kernel.threads.total":    121,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("pid metrics fatal: %v", got)
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("pid metric %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package sysinfo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/log"
+)
+
+const (
+	defaultCollectConcurrency = 4
+	// deadline of a collection without a cycle, e.g. CollectAll
+	defaultCollectDeadline = 60 * time.Second
+)
+
+func collectConcurrency() int {
+	if common.Conf != nil && common.Conf.CollectConcurrency > 0 {
+		return common.Conf.CollectConcurrency
+	}
+	return defaultCollectConcurrency
+}
+
+// collectorTimeout is the hard deadline of a single collector, by default
+// half the collection deadline so a hanging one leaves time to the others
+func collectorTimeout(cycle int) time.Duration {
+	if common.Conf != nil && common.Conf.CollectorTimeout > 0 {
+		return time.Duration(common.Conf.CollectorTimeout) * time.Second
+	}
+	return collectDeadline(cycle) / 2
+}
+
+// collectDeadline keeps a collection from overrunning its cycle in seconds
+func collectDeadline(cycle int) time.Duration {
+	if cycle > 0 {
+		return time.Duration(cycle) * time.Second
+	}
+	return defaultCollectDeadline
+}
+
+type collectResult struct {
+	metrics []*common.Metric
+	// false if the metrics were cached by a sample rate
+	ran     bool
+	elapsed time.Duration
+	done    bool
+	// the collector exceeded its deadline or is still stuck in a previous run
+	timedOut bool
+	// the collector panicked or reported collector.error
+	failed bool
+}
+
+var (
+	stuckLock sync.Mutex
+	// collectors whose abandoned run has not returned yet
+	stuckCollectors = make(map[string]bool)
+)
+
+// watchdog runs fn of the named collector until ctx is done. A run that
+// does not return in time is abandoned: its goroutine is left to finish on
+// its own and the collector is not started again until it has, so a
+// collector hanging on e.g. a frozen NFS mount holds one goroutine at most.
+func watchdog(ctx context.Context, name string, fn func() collectResult) (collectResult, bool) {
+	stuckLock.Lock()
+	stuck := stuckCollectors[name]
+	stuckLock.Unlock()
+	if stuck {
+		log.Errorf("collector %s is still stuck in its last run, skipped", name)
+		return collectResult{}, true
+	}
+
+	ch := make(chan collectResult, 1)
+	go func() {
+		ch <- fn()
+	}()
+	select {
+	case res := <-ch:
+		return res, false
+	case <-ctx.Done():
+	}
+
+	stuckLock.Lock()
+	stuckCollectors[name] = true
+	stuckLock.Unlock()
+	go func() {
+		<-ch
+		stuckLock.Lock()
+		delete(stuckCollectors, name)
+		stuckLock.Unlock()
+	}()
+	log.Errorf("collector %s exceeded its deadline, abandoned", name)
+	return collectResult{}, true
+}
+
+// timeoutMetric reports a collector abandoned by the watchdog
+func timeoutMetric(name string) *common.Metric {
+	return toMetric("collector.timeout", 1, map[string]string{"collector": name})
+}
+
+// runCollectors runs fn for every collector on a pool of concurrency
+// workers and returns the results in collectors order. A collector running
+// longer than timeout is abandoned and its worker moves on to the next one.
+// Collectors not started when ctx is done are logged and left with an
+// empty result.
+func runCollectors(ctx context.Context, collectors []MetricCollector, concurrency int, timeout time.Duration, fn func(MetricCollector) collectResult) []collectResult {
+	var lock sync.Mutex
+	results := make([]collectResult, len(collectors))
+	if concurrency > len(collectors) {
+		concurrency = len(collectors)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range collectors {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := collectors[i]
+				cctx, cancel := context.WithTimeout(ctx, timeout)
+				res, timedOut := watchdog(cctx, c.Name(), func() collectResult {
+					return fn(c)
+				})
+				cancel()
+				res.done, res.timedOut = true, timedOut
+				lock.Lock()
+				results[i] = res
+				lock.Unlock()
+			}
+		}()
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	// late collectors still write into results
+	res := make([]collectResult, len(results))
+	copy(res, results)
+	for i, r := range res {
+		if !r.done {
+			log.Errorf("collector %s did not finish before the cycle deadline, skipped", collectors[i].Name())
+		}
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package sysinfo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func sleepCollector(name string, d time.Duration) MetricCollector {
+	return funcCollector{name: name, fn: func() []*common.Metric {
+		time.Sleep(d)
+		return []*common.Metric{toMetric(name, 1, nil)}
+	}}
+}
+
+func Test_collectConcurrent(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{CollectConcurrency: 4}
+
+	collectors := []MetricCollector{
+		sleepCollector("slow", 300*time.Millisecond),
+		sleepCollector("medium", 200*time.Millisecond),
+		sleepCollector("fast", 100*time.Millisecond),
+		sleepCollector("fastest", 0),
+	}
+	start := time.Now()
+	L := collect(collectors)
+	elapsed := time.Since(start)
+	// the slowest, not the sum of 600ms
+	if elapsed < 300*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Fatalf("concurrent collect took %s", elapsed)
+	}
+	if len(L) != 4 {
+		t.Fatalf("concurrent collect metrics fatal: %d", len(L))
+	}
+	for i, name := range []string{"slow", "medium", "fast", "fastest"} {
+		if L[i].Name != name {
+			t.Fatalf("metrics should keep the collectors order: %d %s", i, L[i].Name)
+		}
+	}
+}
+
+func Test_runCollectorsDeadline(t *testing.T) {
+	collectors := []MetricCollector{
+		sleepCollector("hang", time.Second),
+		sleepCollector("fast", 0),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results := runCollectors(ctx, collectors, 2, time.Minute, func(c MetricCollector) collectResult {
+		return collectResult{metrics: c.Collect(), ran: true}
+	})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("collect should stop at the deadline: %s", elapsed)
+	}
+	// abandoned by the watchdog or not started, either way without metrics
+	if results[0].ran || len(results[0].metrics) != 0 {
+		t.Fatalf("hanging collector should be skipped: %v", results[0])
+	}
+	if !results[1].done || len(results[1].metrics) != 1 {
+		t.Fatalf("fast collector result fatal: %v", results[1])
+	}
+}
+
+func Test_runCollectorsPoolSize(t *testing.T) {
+	var collectors []MetricCollector
+	for _, name := range []string{"a", "b", "c", "d"} {
+		collectors = append(collectors, sleepCollector(name, 100*time.Millisecond))
+	}
+	start := time.Now()
+	runCollectors(context.Background(), collectors, 2, time.Minute, func(c MetricCollector) collectResult {
+		return collectResult{metrics: c.Collect(), ran: true}
+	})
+	// two rounds of two workers
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 350*time.Millisecond {
+		t.Fatalf("pool of 2 took %s", elapsed)
+	}
+}
+
+func Test_collectWatchdog(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{CollectorTimeout: 1, CollectConcurrency: 1}
+
+	release := make(chan struct{})
+	defer close(release)
+	var started int
+	var lock sync.Mutex
+	blocker := funcCollector{name: "BlockingMetrics", fn: func() []*common.Metric {
+		lock.Lock()
+		started++
+		lock.Unlock()
+		<-release
+		return nil
+	}}
+	collectors := []MetricCollector{blocker, sleepCollector("after", 0)}
+
+	for cycle := 0; cycle < 2; cycle++ {
+		start := time.Now()
+		L := collect(collectors)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Fatalf("cycle %d should not wait for the stuck collector: %s", cycle, elapsed)
+		}
+		// the one worker is reclaimed for the next collector
+		if len(L) != 2 || L[0].Name != "collector.timeout" || L[0].Tags["collector"] != "BlockingMetrics" ||
+			L[1].Name != "after" {
+			t.Fatalf("cycle %d metrics fatal: %v", cycle, L)
+		}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if started != 1 {
+		t.Fatalf("stuck collector started again: %d", started)
+	}
+}
@@ -8,8 +8,6 @@ import (
 
 	"github.com/lodastack/agent/agent/common"
 	"github.com/lodastack/agent/agent/outputs"
-
-	"github.com/lodastack/log"
 )
 
 type PortCollector struct{}
@@ -37,7 +35,7 @@ func isListening(port string, timeoutStr string) bool {
 	var err error
 	var timeout int
 	if timeout, err = strconv.Atoi(timeoutStr); err != nil {
-		log.Errorf("convert port timeout to int failed: %s", err)
+		collectorLog("PortCollector").Errorf("convert port timeout to int failed: %s", err)
 		return false
 	}
 	addr := fmt.Sprintf("127.0.0.1:%s", port)
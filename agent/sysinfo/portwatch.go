@@ -0,0 +1,63 @@
+package sysinfo
+
+import (
+	"strconv"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_NET, "PortWatchMetrics", PortWatchMetrics)
+}
+
+// PortWatchMetrics report established connections and listen state of
+// the ports configured in Conf.PortWatch
+func PortWatchMetrics() (L []*common.Metric) {
+	if common.Conf == nil || len(common.Conf.PortWatch) == 0 {
+		return
+	}
+	conns, err := listTCPConns()
+	if err != nil {
+		collectorLog("PortWatchMetrics").Errorf("failed to read tcp socket table: %s", err)
+		return collectorError("PortWatchMetrics", errReasonRead)
+	}
+	return portWatchMetrics(common.Conf.PortWatch, conns)
+}
+
+var (
+	lastPortEstablished map[int]int
+	lastPortTime        float64
+)
+
+func portWatchMetrics(ports []int, conns []tcpConn) (L []*common.Metric) {
+	now := common.RateNow()
+	interval := now - lastPortTime
+	newEstablished := make(map[int]int, len(ports))
+	for _, port := range ports {
+		established, listening := 0, 0
+		for _, c := range conns {
+			switch c.State {
+			case tcpEstablished:
+				if c.LocalPort == port || c.RemotePort == port {
+					established++
+				}
+			case tcpListen:
+				if c.LocalPort == port {
+					listening = 1
+				}
+			}
+		}
+		tags := map[string]string{"port": strconv.Itoa(port)}
+		L = append(L, toMetric("net.port.established", established, tags))
+		L = append(L, toMetric("net.port.listening", listening, tags))
+
+		newEstablished[port] = established
+		if old, ok := lastPortEstablished[port]; ok && interval > 0 {
+			rate := common.SetPrecision(float64(established-old)/interval, 2)
+			L = append(L, toMetric("net.port.established.rate", rate, tags))
+		}
+	}
+	lastPortEstablished = newEstablished
+	lastPortTime = now
+	return
+}
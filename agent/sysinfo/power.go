@@ -0,0 +1,51 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_POWER, "PowerMetrics", PowerMetrics)
+}
+
+// PowerMetrics report AC adapter and battery status from /sys/class/power_supply
+func PowerMetrics() (L []*common.Metric) {
+	fis, err := ioutil.ReadDir(sysPath("class", "power_supply"))
+	if err != nil {
+		// most servers and VMs have no power supply class at all
+		if !os.IsNotExist(err) {
+			collectorLog("PowerMetrics").Errorf("failed to read power supply dir: %s", err)
+		}
+		return
+	}
+
+	for _, fi := range fis {
+		dir := sysPath("class", "power_supply", fi.Name())
+		supplyType, err := readFileString(filepath.Join(dir, "type"))
+		if err != nil {
+			continue
+		}
+		tags := map[string]string{"supply": fi.Name()}
+		switch supplyType {
+		case "Mains", "USB":
+			if online, err := readFileUint(filepath.Join(dir, "online")); err == nil {
+				L = append(L, toMetric("power.ac.online", online, tags))
+			}
+		case "Battery", "UPS":
+			if capacity, err := readFileUint(filepath.Join(dir, "capacity")); err == nil {
+				L = append(L, toMetric("power.battery.percent", capacity, tags))
+			}
+			if status, err := readFileString(filepath.Join(dir, "status")); err == nil {
+				// Charging, Discharging, Full, Not charging or Unknown
+				L = append(L, toMetric("power.battery.status", 1,
+					map[string]string{"supply": fi.Name(), "status": strings.ToLower(status)}))
+			}
+		}
+	}
+	return
+}
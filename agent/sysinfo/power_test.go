@@ -0,0 +1,38 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+func Test_PowerMetrics(t *testing.T) {
+	defer useFixtureRoot(t, map[string]string{
+		"sys/class/power_supply/AC/type":        "Mains\n",
+		"sys/class/power_supply/AC/online":      "1\n",
+		"sys/class/power_supply/BAT0/type":      "Battery\n",
+		"sys/class/power_supply/BAT0/capacity":  "87\n",
+		"sys/class/power_supply/BAT0/status":    "Discharging\n",
+		"sys/class/power_supply/hidpp_0/online": "1\n",
+	})()
+
+	L := PowerMetrics()
+	if len(L) != 3 {
+		t.Fatalf("power metrics fatal: %d", len(L))
+	}
+	if L[0].Name != "power.ac.online" || L[0].Tags["supply"] != "AC" || L[0].Value.(uint64) != 1 {
+		t.Fatalf("power ac online fatal: %s", L[0].String())
+	}
+	if L[1].Name != "power.battery.percent" || L[1].Tags["supply"] != "BAT0" || L[1].Value.(uint64) != 87 {
+		t.Fatalf("power battery percent fatal: %s", L[1].String())
+	}
+	if L[2].Name != "power.battery.status" || L[2].Tags["status"] != "discharging" {
+		t.Fatalf("power battery status fatal: %s", L[2].String())
+	}
+}
+
+func Test_PowerMetricsNoSupply(t *testing.T) {
+	defer useFixtureRoot(t, map[string]string{"sys/class/net/lo/mtu": "65536\n"})()
+
+	if L := PowerMetrics(); len(L) != 0 {
+		t.Fatalf("power metrics without power supply fatal: %d", len(L))
+	}
+}
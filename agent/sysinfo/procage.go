@@ -0,0 +1,129 @@
+package sysinfo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type procAge struct {
+	Pid     int
+	Cmdline string
+	Start   uint64 // clock ticks after boot, field 22 of /proc/<pid>/stat
+}
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "ProcAgeMetrics", ProcAgeMetrics)
+}
+
+// ProcAgeMetrics report the processes of every maxagematcher that run
+// longer than its maxage
+func ProcAgeMetrics() (L []*common.Metric) {
+	if common.Conf == nil || len(common.Conf.MaxAgeMatchers) == 0 {
+		return
+	}
+	uptime, err := common.Uptime()
+	if err != nil {
+		collectorLog("ProcAgeMetrics").Errorf("failed to read uptime: %s", err)
+		return collectorError("ProcAgeMetrics", errReasonRead)
+	}
+	procs, err := readProcAges()
+	if err != nil {
+		collectorLog("ProcAgeMetrics").Errorf("failed to list processes: %s", err)
+		return
+	}
+	return procAgeMetrics(common.Conf.MaxAgeMatchers, procs, uptime, procTopN())
+}
+
+// procAgeMetrics compares the start time of the processes against the
+// seconds since boot, both are on the monotonic clock of the kernel so
+// wall clock steps do not change the age. At most n of the oldest
+// processes of a matcher are reported.
+func procAgeMetrics(matchers []common.MaxAgeMatcher, procs []procAge, uptime float64, n int) (L []*common.Metric) {
+	type aged struct {
+		pid int
+		age float64
+	}
+	for _, m := range matchers {
+		var old []aged
+		for _, p := range procs {
+			if !m.Match(p.Cmdline) {
+				continue
+			}
+			if age := uptime - float64(p.Start)/clockTicks; age > float64(m.MaxAge) {
+				old = append(old, aged{p.Pid, age})
+			}
+		}
+		sort.Slice(old, func(i, j int) bool { return old[i].age > old[j].age })
+		for i := 0; i < len(old) && i < n; i++ {
+			tags := map[string]string{"name": m.Name, "pid": strconv.Itoa(old[i].pid)}
+			L = append(L, toMetric("proc.age.seconds", common.SetPrecision(old[i].age, 2), tags))
+		}
+		exceeded := 0
+		if len(old) > 0 {
+			exceeded = 1
+		}
+		L = append(L, toMetric("proc.age.exceeded", exceeded, map[string]string{"name": m.Name}))
+	}
+	return
+}
+
+// readProcAges reads the command line and start time of every user space
+// process, processes exiting during the scan are skipped
+func readProcAges() ([]procAge, error) {
+	pids, err := listPids()
+	if err != nil {
+		return nil, err
+	}
+	procs := make([]procAge, 0, len(pids))
+	for _, pid := range pids {
+		p, err := readProcAge(pid)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				collectorLog("ProcAgeMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		if p.Cmdline != "" {
+			procs = append(procs, p)
+		}
+	}
+	return procs, nil
+}
+
+func readProcAge(pid int) (p procAge, err error) {
+	b, err := ioutil.ReadFile(pidPath(pid, "cmdline"))
+	if err != nil {
+		return
+	}
+	stat, err := ioutil.ReadFile(pidPath(pid, "stat"))
+	if err != nil {
+		return
+	}
+	if p.Start, err = parseStartTime(stat); err != nil {
+		return
+	}
+	p.Pid, p.Cmdline = pid, parseCmdline(b)
+	return p, nil
+}
+
+// parseStartTime reads starttime of /proc/<pid>/stat, in clock ticks after
+// boot; the fields follow the last ')' as comm may contain spaces
+func parseStartTime(stat []byte) (uint64, error) {
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("invalid stat content: %q", stat)
+	}
+	// fields[0] is the state, field 3 of proc(5); starttime is 22
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("invalid stat content: %q", stat)
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
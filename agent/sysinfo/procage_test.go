@@ -0,0 +1,86 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_procAgeMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := procRoot
+	defer func() { procRoot = oldDir }()
+	procRoot = dir
+
+	// starttime is in USER_HZ ticks after boot
+	const stat = "%d (%s) S 1 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 %d 1000000 200\n"
+	procs := []struct {
+		pid     int
+		cmdline string
+		start   int
+	}{
+		{1, "/sbin/init\x00", 100},
+		{300, "/bin/sh\x00/usr/local/bin/backup.sh\x00", 1000 * clockTicks},
+		{301, "/bin/sh\x00/usr/local/bin/backup.sh\x00", 9000 * clockTicks},
+		{400, "", 200},
+	}
+	for _, p := range procs {
+		writeProcFixture(t, dir, p.pid, fmt.Sprintf(stat, p.pid, "sh", p.start), "")
+		ioutil.WriteFile(filepath.Join(dir, strconv.Itoa(p.pid), "cmdline"), []byte(p.cmdline), 0644)
+	}
+
+	ages, err := readProcAges()
+	if err != nil || len(ages) != 3 {
+		t.Fatalf("read proc ages fatal: %v %v", ages, err)
+	}
+
+	matchers := []common.MaxAgeMatcher{
+		{Name: "backup", Pattern: "backup.sh", MaxAge: 3600},
+		{Name: "init", Pattern: "^/sbin/init", MaxAge: 100000},
+	}
+	for i := range matchers {
+		if err := matchers[i].Compile(); err != nil {
+			t.Fatalf("compile maxagematcher fatal: %s", err)
+		}
+	}
+
+	// booted 10000s ago: pid 300 runs for 9000s, pid 301 for 1000s
+	L := procAgeMetrics(matchers, ages, 10000, 5)
+	exceeded := map[string]int{}
+	var aged []*common.Metric
+	for _, m := range L {
+		switch m.Name {
+		case "proc.age.exceeded":
+			exceeded[m.Tags["name"]] = m.Value.(int)
+		case "proc.age.seconds":
+			aged = append(aged, m)
+		}
+	}
+	if exceeded["backup"] != 1 || exceeded["init"] != 0 || len(exceeded) != 2 {
+		t.Fatalf("proc age exceeded fatal: %v", exceeded)
+	}
+	if len(aged) != 1 || aged[0].Tags["pid"] != "300" || aged[0].Value.(float64) != 9000 {
+		t.Fatalf("proc age seconds fatal: %v", aged)
+	}
+
+	// both backup runs are older than 500s, only the oldest is kept
+	matchers[0].MaxAge = 500
+	aged = aged[:0]
+	for _, m := range procAgeMetrics(matchers[:1], ages, 10000, 1) {
+		if m.Name == "proc.age.seconds" {
+			aged = append(aged, m)
+		}
+	}
+	if len(aged) != 1 || aged[0].Tags["pid"] != "300" {
+		t.Fatalf("proc age top n fatal: %v", aged)
+	}
+}
@@ -0,0 +1,131 @@
+package sysinfo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/nux"
+)
+
+// /proc/diskstats always counts 512 byte sectors
+const sectorSize = 512
+
+var nvmeDiskRe = regexp.MustCompile(`^nvme[0-9]+n[0-9]+$`)
+
+type diskCounters struct {
+	ReadOps      uint64
+	ReadSectors  uint64
+	ReadMsec     uint64
+	WriteOps     uint64
+	WriteSectors uint64
+	WriteMsec    uint64
+	IoMsec       uint64
+	// IoMsec weighted by the number of IOs in flight
+	WeightedIoMsec uint64
+}
+
+// diskBytesMetrics report per device throughput, operations, utilization,
+// await and queue size between the last two samples of UpdateDiskStats, so
+// /proc/diskstats is read once for them and IOStatsMetrics. NVMe disks are
+// reported too.
+func diskBytesMetrics(device string, samples [2]*nux.DiskStats) []*common.Metric {
+	if samples[0] == nil || samples[1] == nil || (!ShouldHandleDevice(device) && !nvmeDiskRe.MatchString(device)) {
+		return nil
+	}
+	seconds := samples[0].TS.Sub(samples[1].TS).Seconds()
+	return diskRateMetrics(map[string]string{"device": device}, nuxDiskCounters(samples[1]), nuxDiskCounters(samples[0]), seconds)
+}
+
+func nuxDiskCounters(ds *nux.DiskStats) diskCounters {
+	return diskCounters{
+		ReadOps:        ds.ReadRequests,
+		ReadSectors:    ds.ReadSectors,
+		ReadMsec:       ds.MsecRead,
+		WriteOps:       ds.WriteRequests,
+		WriteSectors:   ds.WriteSectors,
+		WriteMsec:      ds.MsecWrite,
+		IoMsec:         ds.MsecTotal,
+		WeightedIoMsec: ds.MsecWeightedTotal,
+	}
+}
+
+// diskRateMetrics computes the rates of the counters over seconds, a
+// counter that went backwards (wrapped or reset) yields 0
+func diskRateMetrics(tags map[string]string, prev, cur diskCounters, seconds float64) (L []*common.Metric) {
+	if seconds <= 0 {
+		return nil
+	}
+	rate := func(old, new uint64) float64 {
+		if new < old {
+			return 0
+		}
+		return float64(new-old) / seconds
+	}
+	readOps, writeOps := rate(prev.ReadOps, cur.ReadOps), rate(prev.WriteOps, cur.WriteOps)
+	L = append(L, toMetric("disk.io.read_bytes", common.SetPrecision(rate(prev.ReadSectors, cur.ReadSectors)*sectorSize, 2), tags))
+	L = append(L, toMetric("disk.io.write_bytes", common.SetPrecision(rate(prev.WriteSectors, cur.WriteSectors)*sectorSize, 2), tags))
+	L = append(L, toMetric("disk.io.read_ops", common.SetPrecision(readOps, 2), tags))
+	L = append(L, toMetric("disk.io.write_ops", common.SetPrecision(writeOps, 2), tags))
+	// ms spent per second over ops per second is the ms spent per op
+	L = append(L, toMetric("disk.io.read_await_ms", awaitMs(rate(prev.ReadMsec, cur.ReadMsec), readOps), tags))
+	L = append(L, toMetric("disk.io.write_await_ms", awaitMs(rate(prev.WriteMsec, cur.WriteMsec), writeOps), tags))
+	// weighted ms per second over 1000 ms is the average queue size
+	L = append(L, toMetric("disk.io.queue", common.SetPrecision(rate(prev.WeightedIoMsec, cur.WeightedIoMsec)/1000, 2), tags))
+	// ms spent doing IO per second, 1000 ms/s is 100%
+	util := rate(prev.IoMsec, cur.IoMsec) / 10
+	if util > 100 {
+		util = 100
+	}
+	L = append(L, toMetric("disk.io.util.percent", common.SetPrecision(util, 2), tags))
+	return
+}
+
+func awaitMs(msecRate, opsRate float64) float64 {
+	if opsRate <= 0 {
+		return 0
+	}
+	return common.SetPrecision(msecRate/opsRate, 2)
+}
+
+// parseDiskstats returns the counters of whole disks, partitions, loop
+// and ram devices are skipped
+func parseDiskstats(content string) map[string]diskCounters {
+	res := make(map[string]diskCounters)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 14 {
+			continue
+		}
+		device := fields[2]
+		if !ShouldHandleDevice(device) && !nvmeDiskRe.MatchString(device) {
+			continue
+		}
+		var v [11]uint64
+		ok := true
+		for i := range v {
+			n, err := strconv.ParseUint(fields[i+3], 10, 64)
+			if err != nil {
+				ok = false
+				break
+			}
+			v[i] = n
+		}
+		if !ok {
+			continue
+		}
+		res[device] = diskCounters{
+			ReadOps:        v[0],
+			ReadSectors:    v[2],
+			ReadMsec:       v[3],
+			WriteOps:       v[4],
+			WriteSectors:   v[6],
+			WriteMsec:      v[7],
+			IoMsec:         v[9],
+			WeightedIoMsec: v[10],
+		}
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+package sysinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lodastack/nux"
+)
+
+const diskstatsSample = `   7       0 loop0 52 0 2084 12 0 0 0 0 0 28 12 0 0 0 0
+   8       0 sda 10000 500 800000 4000 20000 1000 1600000 12000 0 9000 16000 0 0 0 0
+   8       1 sda1 9000 500 700000 3500 19000 1000 1500000 11000 0 8000 14500 0 0 0 0
+ 259       0 nvme0n1 300 0 24000 100 600 0 48000 200 0 250 300
+ 259       1 nvme0n1p1 200 0 16000 80 500 0 40000 150 0 200 230
+   1       0 ram0 0 0 0 0 0 0 0 0 0 0 0
+`
+
+const diskstatsSample2 = `   8       0 sda 10100 500 802000 4040 20200 1000 1604000 12100 0 9500 16200 0 0 0 0
+ 259       0 nvme0n1 300 0 24000 100 600 0 48000 200 0 250 300
+`
+
+func Test_parseDiskstats(t *testing.T) {
+	counters := parseDiskstats(diskstatsSample)
+	if len(counters) != 2 {
+		t.Fatalf("parse diskstats fatal: %v", counters)
+	}
+	want := diskCounters{ReadOps: 10000, ReadSectors: 800000, ReadMsec: 4000, WriteOps: 20000, WriteSectors: 1600000, WriteMsec: 12000, IoMsec: 9000, WeightedIoMsec: 16000}
+	if counters["sda"] != want {
+		t.Fatalf("parse diskstats sda fatal: %+v", counters["sda"])
+	}
+
+	next := parseDiskstats(diskstatsSample2)
+	got := map[string]float64{}
+	for _, m := range diskRateMetrics(map[string]string{"device": "sda"}, counters["sda"], next["sda"], 10) {
+		got[m.Name] = m.Value.(float64)
+	}
+	expect := map[string]float64{
+		"disk.io.read_bytes":   102400,
+		"disk.io.write_bytes":  204800,
+		"disk.io.read_ops":     10,
+		"disk.io.write_ops":    20,
+		"disk.io.util.percent": 5,
+		// 40ms over 100 reads, 100ms over 200 writes
+		"disk.io.read_await_ms":  0.4,
+		"disk.io.write_await_ms": 0.5,
+		// 200 weighted ms in 10s
+		"disk.io.queue": 0.02,
+	}
+	for name, v := range expect {
+		if got[name] != v {
+			t.Fatalf("disk rate %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+	idle := map[string]float64{}
+	for _, m := range diskRateMetrics(map[string]string{"device": "nvme0n1"}, counters["nvme0n1"], next["nvme0n1"], 10) {
+		idle[m.Name] = m.Value.(float64)
+	}
+	if v, ok := idle["disk.io.read_await_ms"]; !ok || v != 0 {
+		t.Fatalf("await of an idle disk fatal: %v", idle)
+	}
+}
+
+func Test_diskBytesMetrics(t *testing.T) {
+	now := time.Now()
+	prev := &nux.DiskStats{Device: "sda", ReadRequests: 100, ReadSectors: 800, MsecTotal: 1000, TS: now.Add(-10 * time.Second)}
+	cur := &nux.DiskStats{Device: "sda", ReadRequests: 200, ReadSectors: 1600, MsecTotal: 3000, TS: now}
+
+	values := map[string]float64{}
+	for _, m := range diskBytesMetrics("sda", [2]*nux.DiskStats{cur, prev}) {
+		values[m.Name] = m.Value.(float64)
+	}
+	if values["disk.io.read_ops"] != 10 || values["disk.io.read_bytes"] != 40960 || values["disk.io.util.percent"] != 20 {
+		t.Fatalf("disk bytes metrics fatal: %v", values)
+	}
+	if L := diskBytesMetrics("sda", [2]*nux.DiskStats{cur, nil}); len(L) != 0 {
+		t.Fatalf("disk bytes metrics of a single sample fatal: %d", len(L))
+	}
+	if L := diskBytesMetrics("sda1", [2]*nux.DiskStats{cur, prev}); len(L) != 0 {
+		t.Fatalf("disk bytes metrics of a partition fatal: %d", len(L))
+	}
+}
@@ -0,0 +1,96 @@
+package sysinfo
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type procFds struct {
+	Pid   int
+	Comm  string
+	Open  int
+	Limit uint64 // soft limit, 0 if unlimited or unknown
+}
+
+func init() {
+	RegisterFunc(common.TYPE_FS, "ProcFdMetrics", ProcFdMetrics)
+}
+
+// ProcFdMetrics report the processes holding the most file descriptors and
+// how close they are to their soft limit
+func ProcFdMetrics() []*common.Metric {
+	pids, err := listPids()
+	if err != nil {
+		collectorLog("ProcFdMetrics").Errorf("failed to list processes: %s", err)
+		return nil
+	}
+	var procs []procFds
+	for _, pid := range pids {
+		p, err := readProcFds(pid)
+		if err != nil {
+			// exited, or the fd dir of another user without CAP_SYS_PTRACE
+			if !os.IsNotExist(err) && !os.IsPermission(err) {
+				collectorLog("ProcFdMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		procs = append(procs, p)
+	}
+	return procFdMetrics(procs, procTopN())
+}
+
+func procFdMetrics(procs []procFds, n int) (L []*common.Metric) {
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Open > procs[j].Open })
+	for i := 0; i < len(procs) && i < n; i++ {
+		p := procs[i]
+		tags := map[string]string{"pid": strconv.Itoa(p.Pid), "comm": p.Comm}
+		L = append(L, toMetric("proc.fd.open", p.Open, tags))
+		if p.Limit > 0 {
+			L = append(L, toMetric("proc.fd.limit.percent", common.SetPrecision(float64(p.Open)*100/float64(p.Limit), 2), tags))
+		}
+	}
+	return
+}
+
+func readProcFds(pid int) (p procFds, err error) {
+	f, err := os.Open(pidPath(pid, "fd"))
+	if err != nil {
+		return
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return
+	}
+	p.Pid, p.Open = pid, len(names)
+	if p.Comm, err = readFileString(pidPath(pid, "comm")); err != nil {
+		return
+	}
+	if limits, err := readFileString(pidPath(pid, "limits")); err == nil {
+		p.Limit = parseOpenFilesLimit(limits)
+	}
+	return p, nil
+}
+
+// parseOpenFilesLimit returns the soft limit of the line
+// "Max open files            1024                 4096                 files"
+func parseOpenFilesLimit(limits string) uint64 {
+	const key = "Max open files"
+	for _, line := range strings.Split(limits, "\n") {
+		if !strings.HasPrefix(line, key) {
+			continue
+		}
+		fields := strings.Fields(line[len(key):])
+		if len(fields) == 0 {
+			return 0
+		}
+		// "unlimited" fails to parse and is reported as 0
+		v, _ := strconv.ParseUint(fields[0], 10, 64)
+		return v
+	}
+	return 0
+}
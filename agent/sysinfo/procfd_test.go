@@ -0,0 +1,69 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const limitsSample = `Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            1024                 4096                 files
+Max locked memory         65536                65536                bytes
+`
+
+func Test_ProcFdMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := procRoot
+	defer func() { procRoot = oldDir }()
+	procRoot = dir
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{ProcMetricsTopN: 2}
+
+	fds := map[int]int{1: 10, 100: 512, 200: 3}
+	comms := map[int]string{1: "init", 100: "java", 200: "sh"}
+	for pid, n := range fds {
+		pidDir := filepath.Join(dir, strconv.Itoa(pid))
+		os.MkdirAll(filepath.Join(pidDir, "fd"), 0755)
+		for fd := 0; fd < n; fd++ {
+			ioutil.WriteFile(filepath.Join(pidDir, "fd", strconv.Itoa(fd)), nil, 0644)
+		}
+		ioutil.WriteFile(filepath.Join(pidDir, "comm"), []byte(comms[pid]+"\n"), 0644)
+		ioutil.WriteFile(filepath.Join(pidDir, "limits"), []byte(limitsSample), 0644)
+	}
+	// exited before its fd dir was read
+	os.MkdirAll(filepath.Join(dir, "300"), 0755)
+
+	L := ProcFdMetrics()
+	if len(L) != 4 {
+		t.Fatalf("proc fd metrics fatal: %d", len(L))
+	}
+	if L[0].Name != "proc.fd.open" || L[0].Tags["comm"] != "java" || L[0].Value.(int) != 512 {
+		t.Fatalf("proc fd open fatal: %s", L[0].String())
+	}
+	if L[1].Name != "proc.fd.limit.percent" || L[1].Value.(float64) != 50 {
+		t.Fatalf("proc fd limit percent fatal: %s", L[1].String())
+	}
+	if L[2].Tags["pid"] != "1" {
+		t.Fatalf("proc fd top n fatal: %s", L[2].String())
+	}
+}
+
+func Test_parseOpenFilesLimit(t *testing.T) {
+	if v := parseOpenFilesLimit(limitsSample); v != 1024 {
+		t.Fatalf("parse open files limit fatal: %d", v)
+	}
+	if v := parseOpenFilesLimit("Max open files            unlimited            unlimited            files\n"); v != 0 {
+		t.Fatalf("parse unlimited open files fatal: %d", v)
+	}
+}
@@ -0,0 +1,85 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// mount points of procfs and sysfs, every collector reads through them so
+// tests can point them at a fixture directory
+var (
+	procRoot = "/proc"
+	sysRoot  = "/sys"
+)
+
+// procPath joins elem to the procfs mount point
+func procPath(elem ...string) string {
+	return filepath.Join(append([]string{procRoot}, elem...)...)
+}
+
+// sysPath joins elem to the sysfs mount point
+func sysPath(elem ...string) string {
+	return filepath.Join(append([]string{sysRoot}, elem...)...)
+}
+
+// listPids returns the pid of every process currently in procfs
+func listPids() ([]int, error) {
+	fis, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	pids := make([]int, 0, len(fis))
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		pid, err := strconv.Atoi(fi.Name())
+		if err != nil {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+func pidPath(pid int, name string) string {
+	return procPath(strconv.Itoa(pid), name)
+}
+
+// procSwapBytes returns the swapped out memory of a process, read from
+// smaps_rollup and falling back to VmSwap in status on kernels before 4.14
+func procSwapBytes(pid int) (uint64, error) {
+	content, err := ioutil.ReadFile(pidPath(pid, "smaps_rollup"))
+	if err == nil {
+		if kb, ok := parseKBField(string(content), "Swap:"); ok {
+			return kb * 1024, nil
+		}
+	}
+	content, err = ioutil.ReadFile(pidPath(pid, "status"))
+	if err != nil {
+		return 0, err
+	}
+	kb, _ := parseKBField(string(content), "VmSwap:")
+	return kb * 1024, nil
+}
+
+// parseKBField finds a "Key:   123 kB" line and returns the number
+func parseKBField(content, key string) (uint64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, key) {
+			continue
+		}
+		fields := strings.Fields(line[len(key):])
+		if len(fields) == 0 {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,128 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+type procIOSample struct {
+	Pid        int
+	Comm       string
+	ReadBytes  uint64
+	WriteBytes uint64
+	ReadRate   float64
+	WriteRate  float64
+}
+
+var procIORates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_DISK, "ProcIOMetrics", ProcIOMetrics)
+}
+
+// ProcIOMetrics report the processes reading and writing the most bytes per
+// second from the storage layer, read from /proc/<pid>/io
+func ProcIOMetrics() []*common.Metric {
+	samples, err := readProcIOSamples()
+	if err != nil {
+		collectorLog("ProcIOMetrics").Errorf("failed to list processes: %s", err)
+		return nil
+	}
+	return procIOMetrics(procIORates, samples, common.RateNow(), procTopN())
+}
+
+func procIOMetrics(rates *common.RateTracker, samples []procIOSample, now float64, n int) (L []*common.Metric) {
+	var withRate []procIOSample
+	for _, s := range samples {
+		// comm is part of the series so a reused pid starts over
+		tags := map[string]string{"pid": strconv.Itoa(s.Pid), "comm": s.Comm}
+		read, ok1 := rates.RateAt("proc.io.read_bytes", tags, s.ReadBytes, now)
+		write, ok2 := rates.RateAt("proc.io.write_bytes", tags, s.WriteBytes, now)
+		if ok1 && ok2 {
+			s.ReadRate, s.WriteRate = read, write
+			withRate = append(withRate, s)
+		}
+	}
+	// forget the processes that exited since the last scan
+	rates.Prune(now)
+
+	sort.Slice(withRate, func(i, j int) bool { return withRate[i].ReadRate > withRate[j].ReadRate })
+	for i := 0; i < len(withRate) && i < n; i++ {
+		s := withRate[i]
+		tags := map[string]string{"pid": strconv.Itoa(s.Pid), "comm": s.Comm}
+		L = append(L, toMetric("proc.io.read_bytes", common.SetPrecision(s.ReadRate, 2), tags))
+	}
+	sort.Slice(withRate, func(i, j int) bool { return withRate[i].WriteRate > withRate[j].WriteRate })
+	for i := 0; i < len(withRate) && i < n; i++ {
+		s := withRate[i]
+		tags := map[string]string{"pid": strconv.Itoa(s.Pid), "comm": s.Comm}
+		L = append(L, toMetric("proc.io.write_bytes", common.SetPrecision(s.WriteRate, 2), tags))
+	}
+	return
+}
+
+// readProcIOSamples reads the io counters of every process, the io of the
+// processes of other users is only readable with privileges, those and the
+// processes exiting during the scan are skipped
+func readProcIOSamples() ([]procIOSample, error) {
+	pids, err := listPids()
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]procIOSample, 0, len(pids))
+	for _, pid := range pids {
+		s, err := readProcIOSample(pid)
+		if err != nil {
+			if !os.IsNotExist(err) && !os.IsPermission(err) {
+				collectorLog("ProcIOMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func readProcIOSample(pid int) (s procIOSample, err error) {
+	content, err := ioutil.ReadFile(pidPath(pid, "io"))
+	if err != nil {
+		return
+	}
+	stat, err := ioutil.ReadFile(pidPath(pid, "stat"))
+	if err != nil {
+		return
+	}
+	ps, err := parseProcPidStat(stat)
+	if err != nil {
+		return
+	}
+	s.Pid, s.Comm = pid, ps.Comm
+	s.ReadBytes, s.WriteBytes = parseProcIO(string(content))
+	return s, nil
+}
+
+// parseProcIO returns read_bytes and write_bytes of "key: value" lines
+func parseProcIO(content string) (read, write uint64) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "read_bytes:":
+			read = v
+		case "write_bytes:":
+			write = v
+		}
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_procIOMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	old := procRoot
+	defer func() { procRoot = old }()
+	procRoot = dir
+
+	stat := "%d (%s) S 1 1 1 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 100 0 0\n"
+	io := "rchar: 1000\nwchar: 1000\nsyscr: 10\nsyscw: 10\nread_bytes: %d\nwrite_bytes: %d\ncancelled_write_bytes: 0\n"
+	writeIO := func(pid int, comm string, read, write uint64) {
+		writeProcFixture(t, dir, pid, fmt.Sprintf(stat, pid, comm), "")
+		ioutil.WriteFile(filepath.Join(dir, fmt.Sprint(pid), "io"), []byte(fmt.Sprintf(io, read, write)), 0644)
+	}
+	writeIO(1, "init", 0, 0)
+	writeIO(300, "mysqld", 1000000, 5000000)
+	writeIO(400, "backup", 2000000, 0)
+	// no io file readable
+	writeProcFixture(t, dir, 500, fmt.Sprintf(stat, 500, "sshd"), "")
+
+	samples, err := readProcIOSamples()
+	if err != nil || len(samples) != 3 {
+		t.Fatalf("read proc io samples fatal: %d %v", len(samples), err)
+	}
+	rates := common.NewRateTracker()
+	if L := procIOMetrics(rates, samples, 100, 2); len(L) != 0 {
+		t.Fatalf("proc io of first sample fatal: %d", len(L))
+	}
+
+	writeIO(1, "init", 0, 10000)
+	writeIO(300, "mysqld", 1100000, 25000000)
+	writeIO(400, "backup", 52000000, 0)
+	samples, _ = readProcIOSamples()
+	L := procIOMetrics(rates, samples, 110, 2)
+	if len(L) != 4 {
+		t.Fatalf("proc io fatal: %d", len(L))
+	}
+	want := []struct {
+		name, comm string
+		value      float64
+	}{
+		{"proc.io.read_bytes", "backup", 5000000},
+		{"proc.io.read_bytes", "mysqld", 10000},
+		{"proc.io.write_bytes", "mysqld", 2000000},
+		{"proc.io.write_bytes", "init", 1000},
+	}
+	for i, w := range want {
+		if L[i].Name != w.name || L[i].Tags["comm"] != w.comm || L[i].Value.(float64) != w.value {
+			t.Fatalf("proc io %d fatal: %s", i, L[i].String())
+		}
+	}
+
+	// backup exited, its read and write series are dropped
+	os.RemoveAll(filepath.Join(dir, "400"))
+	samples, _ = readProcIOSamples()
+	procIOMetrics(rates, samples, 120, 2)
+	if rates.Len() != 4 {
+		t.Fatalf("exited process not pruned: %d", rates.Len())
+	}
+}
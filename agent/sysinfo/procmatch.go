@@ -0,0 +1,65 @@
+package sysinfo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "ProcMatchMetrics", ProcMatchMetrics)
+}
+
+// ProcMatchMetrics report the number of processes matching every
+// configured procmatcher, 0 included so a dead daemon can be alerted on
+func ProcMatchMetrics() (L []*common.Metric) {
+	if common.Conf == nil || len(common.Conf.ProcMatchers) == 0 {
+		return
+	}
+	cmdlines, err := readCmdlines()
+	if err != nil {
+		collectorLog("ProcMatchMetrics").Errorf("failed to list processes: %s", err)
+		return
+	}
+	for _, m := range common.Conf.ProcMatchers {
+		count := 0
+		for _, cmdline := range cmdlines {
+			if m.Match(cmdline) {
+				count++
+			}
+		}
+		L = append(L, toMetric("proc.match.count", count, map[string]string{"name": m.Name}))
+	}
+	return
+}
+
+// readCmdlines returns the command line of every user space process with
+// the arguments joined by spaces, kernel threads have none and are skipped
+func readCmdlines() ([]string, error) {
+	pids, err := listPids()
+	if err != nil {
+		return nil, err
+	}
+	cmdlines := make([]string, 0, len(pids))
+	for _, pid := range pids {
+		b, err := ioutil.ReadFile(pidPath(pid, "cmdline"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				collectorLog("ProcMatchMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		if cmdline := parseCmdline(b); cmdline != "" {
+			cmdlines = append(cmdlines, cmdline)
+		}
+	}
+	return cmdlines, nil
+}
+
+// parseCmdline turns the NUL separated arguments into a single line
+func parseCmdline(b []byte) string {
+	b = bytes.TrimRight(b, "\x00")
+	return string(bytes.Replace(b, []byte{0}, []byte{' '}, -1))
+}
@@ -0,0 +1,61 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_ProcMatchMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := procRoot
+	defer func() { procRoot = oldDir }()
+	procRoot = dir
+
+	cmdlines := map[int]string{
+		1:   "/sbin/init\x00splash\x00",
+		2:   "",
+		100: "nginx: master process /usr/sbin/nginx\x00",
+		101: "nginx: worker process\x00",
+		102: "nginx: worker process\x00",
+		200: "/usr/bin/vim\x00nginx.conf\x00",
+	}
+	for pid, cmdline := range cmdlines {
+		pidDir := filepath.Join(dir, strconv.Itoa(pid))
+		os.MkdirAll(pidDir, 0755)
+		ioutil.WriteFile(filepath.Join(pidDir, "cmdline"), []byte(cmdline), 0644)
+	}
+
+	matchers := []common.ProcMatcher{
+		{Name: "nginx", Pattern: "^nginx: (master|worker) process"},
+		{Name: "init", Pattern: "^/sbin/init splash$"},
+		{Name: "sshd", Pattern: "sshd"},
+	}
+	for i := range matchers {
+		if err := matchers[i].Compile(); err != nil {
+			t.Fatalf("compile procmatcher fatal: %s", err)
+		}
+	}
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{ProcMatchers: matchers}
+
+	got := map[string]int{}
+	for _, m := range ProcMatchMetrics() {
+		got[m.Tags["name"]] = m.Value.(int)
+	}
+	want := map[string]int{"nginx": 3, "init": 1, "sshd": 0}
+	for name, v := range want {
+		if c, ok := got[name]; !ok || c != v {
+			t.Fatalf("proc match count %s fatal: %d, want %d", name, c, v)
+		}
+	}
+}
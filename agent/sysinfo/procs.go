@@ -3,12 +3,10 @@ package sysinfo
 import (
 	"fmt"
 	"math"
-	"time"
 
 	"github.com/lodastack/agent/agent/common"
 	"github.com/lodastack/agent/agent/outputs"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
@@ -19,7 +17,7 @@ var (
 	wBytes       map[int]uint64
 	CPUTotal     map[int]float64
 	CPUProc      map[int]float64
-	lastProcTime time.Time
+	lastProcTime float64
 )
 
 func (self ProcCollector) Run() {
@@ -30,7 +28,7 @@ func (self ProcCollector) Run() {
 	}
 	ps, err := nux.Procs(cmdlines)
 	if err != nil {
-		log.Error("failed to collect ProcMetrics:", err)
+		collectorLog("ProcessCollector").Errorf("failed to collect ProcMetrics: %s", err)
 		return
 	}
 
@@ -40,8 +38,11 @@ func (self ProcCollector) Run() {
 	newWBytes := make(map[int]uint64)
 	newCPUTotal := make(map[int]float64)
 	newCPUProc := make(map[int]float64)
-	interval := float64(time.Now().Unix() - lastProcTime.Unix())
-	lastProcTime = time.Now()
+	newProcDelay := make(map[int]uint64)
+	schedstat := common.Conf != nil && common.Conf.EnableSchedstat
+	now := common.RateNow()
+	interval := now - lastProcTime
+	lastProcTime = now
 	for _, p := range ps {
 		newRBytes[p.Pid] = p.RBytes
 		newWBytes[p.Pid] = p.WBytes
@@ -51,13 +52,25 @@ func (self ProcCollector) Run() {
 	for _, proc := range procs {
 		var cnt int
 		var fdNum int
-		var memory uint64
+		var memory, swap uint64
 		var cpu, procCpuTime, totalCpuTime float64
 		var ioWrite, ioRead uint64
+		var delay uint64
 		for _, p := range ps {
 			if proc.BinaryPath == p.Exe {
 				cnt++
 				memory += p.Mem
+				if v, err := procSwapBytes(p.Pid); err == nil {
+					swap += v
+				}
+				if schedstat {
+					if wait, err := procSchedWait(p.Pid); err == nil {
+						newProcDelay[p.Pid] = wait
+						if old, ok := lastProcDelay[p.Pid]; ok && old <= wait {
+							delay += wait - old
+						}
+					}
+				}
 
 				if totalCpuOld, _ := CPUTotal[p.Pid]; totalCpuOld <= p.TotalCpu {
 					totalCpuTime = p.TotalCpu - totalCpuOld
@@ -83,6 +96,7 @@ func (self ProcCollector) Run() {
 			toMetric(fmt.Sprintf("%s.fdnum", proc.Name), fdNum, nil),
 			// unit:Byte
 			toMetric(fmt.Sprintf("%s.mem", proc.Name), memory*1024, nil),
+			procSwapMetric(proc.Name, swap),
 			toMetric(fmt.Sprintf("%s.cpu", proc.Name), common.SetPrecision(cpu*100, 2), nil))
 
 		if rBytes != nil {
@@ -91,17 +105,35 @@ func (self ProcCollector) Run() {
 				toMetric(fmt.Sprintf("%s.io.read", proc.Name), math.Ceil(float64(ioRead)/(interval)), nil),
 				toMetric(fmt.Sprintf("%s.io.write", proc.Name), math.Ceil(float64(ioWrite)/(interval)), nil))
 		}
+		if schedstat && lastProcDelay != nil {
+			m[proc.Namespace] = append(m[proc.Namespace], procSchedDelayMetric(proc.Name, delay))
+		}
 	}
 	rBytes = newRBytes
 	wBytes = newWBytes
 	CPUTotal = newCPUTotal
 	CPUProc = newCPUProc
+	if schedstat {
+		lastProcDelay = newProcDelay
+	}
 	for k, v := range m {
 		outputs.SendMetrics(common.TYPE_PROC, k, v)
 	}
 	return
 }
 
+// procSwapMetric reports the swapped out memory (unit: Byte) of the
+// processes of a reported proc
+func procSwapMetric(name string, swap uint64) *common.Metric {
+	return toMetric("proc.swap.bytes", swap, map[string]string{"name": name})
+}
+
+// procSchedDelayMetric reports the run queue wait (unit: ns) of the processes
+// of a reported proc during the last cycle, in milliseconds
+func procSchedDelayMetric(name string, delay uint64) *common.Metric {
+	return toMetric("proc.sched.delay.ms", common.SetPrecision(float64(delay)/1e6, 2), map[string]string{"name": name})
+}
+
 func (self ProcCollector) Description() string {
 	return "ProcessCollector"
 }
@@ -0,0 +1,31 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+func Test_procSwapMetric(t *testing.T) {
+	defer useFixtureRoot(t, map[string]string{
+		"proc/100/smaps_rollup": "Rss:     2048 kB\nSwap:     512 kB\n",
+		"proc/200/status":       "Name:\tjava\nVmRSS:\t  1024 kB\nVmSwap:\t    64 kB\n",
+	})()
+
+	var swap uint64
+	for _, pid := range []int{100, 200} {
+		v, err := procSwapBytes(pid)
+		if err != nil {
+			t.Fatalf("read swap of %d fatal: %s", pid, err)
+		}
+		swap += v
+	}
+	if swap != 576*1024 {
+		t.Fatalf("proc swap bytes fatal: %d", swap)
+	}
+	m := procSwapMetric("java", swap)
+	if m.Name != "proc.swap.bytes" || m.Tags["name"] != "java" || m.Value.(uint64) != 576*1024 {
+		t.Fatalf("proc swap metric fatal: %s", m.String())
+	}
+	if _, err := procSwapBytes(300); err == nil {
+		t.Fatalf("swap of a missing process should fail")
+	}
+}
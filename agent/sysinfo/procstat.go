@@ -0,0 +1,64 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// counters of /proc/stat and the metric reporting their rate
+var procStatCounters = map[string]string{
+	"ctxt":      "kernel.ctxt.rate",
+	"intr":      "kernel.intr.rate",
+	"processes": "kernel.fork.rate",
+}
+
+var procStatRates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "ProcStatRateMetrics", ProcStatRateMetrics)
+}
+
+// ProcStatRateMetrics report context switches, interrupts and forks per
+// second from /proc/stat
+func ProcStatRateMetrics() []*common.Metric {
+	content, err := ioutil.ReadFile(procPath("stat"))
+	if err != nil {
+		collectorLog("ProcStatRateMetrics").Errorf("failed to read stat: %s", err)
+		return collectorError("ProcStatRateMetrics", errReasonRead)
+	}
+	return procStatRateMetrics(procStatRates, parseProcStatCounters(string(content)), common.RateNow())
+}
+
+func procStatRateMetrics(rates *common.RateTracker, counters map[string]uint64, now float64) (L []*common.Metric) {
+	for key, value := range counters {
+		name := procStatCounters[key]
+		if rate, ok := rates.RateAt(name, nil, value, now); ok {
+			L = append(L, toMetric(name, common.SetPrecision(rate, 2), nil))
+		}
+	}
+	return
+}
+
+// parseProcStatCounters returns the counters of procStatCounters, the
+// first field of "intr" is the total and the rest is per irq
+func parseProcStatCounters(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if _, ok := procStatCounters[fields[0]]; !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[fields[0]] = v
+	}
+	return res
+}
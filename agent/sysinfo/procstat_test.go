@@ -0,0 +1,49 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const procStatSample = `cpu  1000 10 500 8000 100 0 20 0 0 0
+cpu0 1000 10 500 8000 100 0 20 0 0 0
+intr 50000 30 0 0 12
+ctxt 200000
+btime 1500000000
+processes 3000
+procs_running 1
+procs_blocked 0
+`
+
+const procStatSample2 = `cpu  1250 10 600 8600 120 0 30 20 0 0
+intr 60000 40 0 0 14
+ctxt 250000
+processes 3100
+`
+
+func Test_procStatRateMetrics(t *testing.T) {
+	counters := parseProcStatCounters(procStatSample)
+	if len(counters) != 3 || counters["intr"] != 50000 || counters["ctxt"] != 200000 || counters["processes"] != 3000 {
+		t.Fatalf("parse stat counters fatal: %v", counters)
+	}
+
+	rates := common.NewRateTracker()
+	if L := procStatRateMetrics(rates, counters, 100); len(L) != 0 {
+		t.Fatalf("stat rate of first sample fatal: %d", len(L))
+	}
+	got := map[string]float64{}
+	for _, m := range procStatRateMetrics(rates, parseProcStatCounters(procStatSample2), 110) {
+		got[m.Name] = m.Value.(float64)
+	}
+	want := map[string]float64{
+		"kernel.ctxt.rate": 5000,
+		"kernel.intr.rate": 1000,
+		"kernel.fork.rate": 10,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("stat rate %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// uids below this belong to system users
+const minNormalUid = 1000
+
+// passwdFile maps uids to user names, replaceable in tests
+var passwdFile = "/etc/passwd"
+
+// userNames caches the uid to name lookups of passwdFile
+type userNames struct {
+	lock  sync.Mutex
+	names map[int]string
+}
+
+var procUsers = &userNames{}
+
+// lookup returns the name of uid, passwdFile is read again on a miss and
+// an unknown uid is remembered as its number
+func (u *userNames) lookup(uid int) string {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if name, ok := u.names[uid]; ok {
+		return name
+	}
+	content, err := ioutil.ReadFile(passwdFile)
+	if err != nil {
+		collectorLog("UserProcMetrics").Debugf("failed to read %s: %s", passwdFile, err)
+	}
+	u.names = parsePasswd(string(content))
+	if _, ok := u.names[uid]; !ok {
+		u.names[uid] = strconv.Itoa(uid)
+	}
+	return u.names[uid]
+}
+
+// parsePasswd parses "name:x:uid:gid:gecos:home:shell" lines
+func parsePasswd(content string) map[int]string {
+	names := make(map[int]string)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		names[uid] = fields[0]
+	}
+	return names
+}
+
+// procUidCounts counts the processes of every real uid
+func procUidCounts() (map[int]int, error) {
+	pids, err := listPids()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int)
+	for _, pid := range pids {
+		content, err := ioutil.ReadFile(pidPath(pid, "status"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				collectorLog("UserProcMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		if uid, ok := parseStatusUid(string(content)); ok {
+			counts[uid]++
+		}
+	}
+	return counts, nil
+}
+
+// parseStatusUid returns the real uid of "Uid: real effective saved fs"
+func parseStatusUid(content string) (int, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(line[len("Uid:"):])
+		if len(fields) == 0 {
+			return 0, false
+		}
+		uid, err := strconv.Atoi(fields[0])
+		return uid, err == nil
+	}
+	return 0, false
+}
+
+// userProcMetrics report processes per user and the sessions of the logged
+// in users
+func userProcMetrics(us []*Utmp, counts map[int]int) (L []*common.Metric) {
+	skipSystem := common.Conf != nil && common.Conf.SkipSystemUsers
+	for uid, n := range counts {
+		if skipSystem && uid < minNormalUid {
+			continue
+		}
+		L = append(L, toMetric("proc.user.count", n, map[string]string{"user": procUsers.lookup(uid)}))
+	}
+
+	sessions := make(map[string]int)
+	for _, u := range us {
+		if u.Type == UserProcess {
+			sessions[NewGoUtmp(u).User]++
+		}
+	}
+	for user, n := range sessions {
+		L = append(L, toMetric("proc.user.sessions", n, map[string]string{"user": user}))
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const passwdSample = `root:x:0:0:root:/root:/bin/bash
+# comment
+nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin
+alice:x:1000:1000:Alice,,,:/home/alice:/bin/bash
+bob:x:1001:1001::/home/bob:/bin/sh
+`
+
+func Test_userProcMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir, oldPasswd, oldUsers := procRoot, passwdFile, procUsers
+	defer func() { procRoot, passwdFile, procUsers = oldDir, oldPasswd, oldUsers }()
+	procRoot, passwdFile, procUsers = dir, filepath.Join(dir, "passwd"), &userNames{}
+	ioutil.WriteFile(passwdFile, []byte(passwdSample), 0644)
+
+	uids := map[int]int{1: 0, 2: 0, 100: 1000, 101: 1000, 102: 1000, 200: 1001, 300: 1500}
+	for pid, uid := range uids {
+		pidDir := filepath.Join(dir, strconv.Itoa(pid))
+		os.MkdirAll(pidDir, 0755)
+		status := fmt.Sprintf("Name:\ttest\nUid:\t%d\t%d\t%d\t%d\n", uid, uid, uid, uid)
+		ioutil.WriteFile(filepath.Join(pidDir, "status"), []byte(status), 0644)
+	}
+	counts, err := procUidCounts()
+	if err != nil || len(counts) != 4 || counts[1000] != 3 {
+		t.Fatalf("count processes per uid fatal: %v %v", counts, err)
+	}
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{SkipSystemUsers: true}
+
+	now := time.Now()
+	us := []*Utmp{
+		newTestUtmp(UserProcess, "alice", "10.0.0.1", now),
+		newTestUtmp(UserProcess, "alice", "10.0.0.2", now),
+		newTestUtmp(DeadProcess, "", "", now),
+	}
+	procs, sessions := map[string]int{}, map[string]int{}
+	for _, m := range userProcMetrics(us, counts) {
+		switch m.Name {
+		case "proc.user.count":
+			procs[m.Tags["user"]] = m.Value.(int)
+		case "proc.user.sessions":
+			sessions[m.Tags["user"]] = m.Value.(int)
+		}
+	}
+	want := map[string]int{"alice": 3, "bob": 1, "1500": 1}
+	if len(procs) != len(want) {
+		t.Fatalf("processes per user fatal: %v", procs)
+	}
+	for user, n := range want {
+		if procs[user] != n {
+			t.Fatalf("processes of %s fatal: %d, want %d", user, procs[user], n)
+		}
+	}
+	if len(sessions) != 1 || sessions["alice"] != 2 {
+		t.Fatalf("sessions per user fatal: %v", sessions)
+	}
+}
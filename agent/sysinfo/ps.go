@@ -0,0 +1,132 @@
+package sysinfo
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// default timeout of the `ps` command, unit: second
+const defaultPsTimeout = 5
+
+// the ps binary and the arguments of execPSContext, printing one state
+// per process below a STAT header
+const defaultPsPath = "ps"
+
+var defaultPsArgs = []string{"axo", "state"}
+
+func psTimeout() time.Duration {
+	if common.Conf != nil && common.Conf.PsTimeout > 0 {
+		return time.Duration(common.Conf.PsTimeout) * time.Second
+	}
+	return defaultPsTimeout * time.Second
+}
+
+// process states reported by PsMetrics, in order
+var psStates = []string{"wait", "blocked", "zombies", "stopped", "running", "sleeping", "idle", "exit", "unknown", "total"}
+
+// ps(1) state modifiers counted by PsMetrics on top of the states, only
+// reported when the states come from `ps`
+var psModifiers = []struct {
+	letter byte
+	name   string
+}{
+	{'l', "multithreaded"},
+	{'+', "foreground"},
+}
+
+// psStateMetrics turns the counts of parsePsStates or procPsStates into
+// ps.<state>.num metrics
+func psStateMetrics(fields map[string]int64) (L []*common.Metric) {
+	for _, state := range psStates {
+		L = append(L, toMetric("ps."+state+".num", fields[state], nil))
+	}
+	for _, m := range psModifiers {
+		if v, ok := fields[m.name]; ok {
+			L = append(L, toMetric("ps."+m.name+".num", v, nil))
+		}
+	}
+	return
+}
+
+// parsePsStates counts the processes per state in `ps axo state` output,
+// and the multithreaded and foreground ones from the modifiers following
+// the state letter, e.g. "Ssl+"
+func parsePsStates(out []byte) map[string]int64 {
+	fields := make(map[string]int64)
+	for _, m := range psModifiers {
+		fields[m.name] = 0
+	}
+	for i, status := range bytes.Fields(out) {
+		if i == 0 && string(status) == "STAT" {
+			// This is a header, skip it
+			continue
+		}
+		if len(status) == 0 {
+			continue
+		}
+		countPsState(fields, status[0])
+		for _, m := range psModifiers {
+			if bytes.IndexByte(status[1:], m.letter) >= 0 {
+				fields[m.name]++
+			}
+		}
+	}
+	return fields
+}
+
+// countPsState buckets a process by the first letter of its state, the
+// letters of ps(1) and of /proc/<pid>/stat are the same
+func countPsState(fields map[string]int64, state byte) {
+	switch state {
+	case 'W':
+		fields["wait"] = fields["wait"] + int64(1)
+	case 'U', 'D', 'L':
+		// Also known as uninterruptible sleep or disk sleep
+		fields["blocked"] = fields["blocked"] + int64(1)
+	case 'Z':
+		fields["zombies"] = fields["zombies"] + int64(1)
+	case 'T', 't':
+		fields["stopped"] = fields["stopped"] + int64(1)
+	case 'R':
+		fields["running"] = fields["running"] + int64(1)
+	case 'S':
+		fields["sleeping"] = fields["sleeping"] + int64(1)
+	case 'I':
+		fields["idle"] = fields["idle"] + int64(1)
+	case 'X', 'x':
+		fields["exit"] = fields["exit"] + int64(1)
+	case '?':
+		fields["unknown"] = fields["unknown"] + int64(1)
+	default:
+		collectorLog("PsMetrics").Errorf("processes: Unknown state [ %s ] from ps",
+			string(state))
+	}
+	fields["total"] = fields["total"] + int64(1)
+}
+
+// execPSContext runs `ps axo state`, or pspath with psargs if configured,
+// the output gathered so far is returned along with the error if ctx
+// expires first
+func execPSContext(ctx context.Context) ([]byte, error) {
+	name, args := defaultPsPath, defaultPsArgs
+	if common.Conf != nil && common.Conf.PsPath != "" {
+		name = common.Conf.PsPath
+	}
+	if common.Conf != nil && len(common.Conf.PsArgs) > 0 {
+		args = common.Conf.PsArgs
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := exec.CommandContext(ctx, bin, args...).Output()
+	if ctx.Err() != nil {
+		return out, ctx.Err()
+	}
+	return out, err
+}
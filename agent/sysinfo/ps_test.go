@@ -0,0 +1,142 @@
+package sysinfo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_parsePsStates(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want map[string]int64
+	}{
+		{"header only", "STAT\n", map[string]int64{}},
+		{"no header", "S\nR\n", map[string]int64{"sleeping": 1, "running": 1, "total": 2}},
+		{"header not first", "S\nSTAT\n", map[string]int64{"sleeping": 2, "total": 2}},
+		// only the first letter counts, the rest are BSD style modifiers
+		{"multi-char states", "STAT\nSs\nR+\nD\nD<\nZ\nT\nI<\nSl+\nRN\n", map[string]int64{
+			"sleeping": 2, "running": 2, "blocked": 2, "zombies": 1, "stopped": 1, "idle": 1, "total": 9,
+		}},
+		{"linux and darwin letters", "S\nW\nX\nx\n?\nL\nU\nt\n", map[string]int64{
+			"sleeping": 1, "wait": 1, "exit": 2, "unknown": 1, "blocked": 2, "stopped": 1, "total": 8,
+		}},
+		// an unknown letter counts in total only
+		{"unknown letter", "STAT\nS\nQ\nP<\n", map[string]int64{"sleeping": 1, "total": 3}},
+		{"empty", "", map[string]int64{}},
+		{"blank lines", "STAT\n\n  \nS\n\n", map[string]int64{"sleeping": 1, "total": 1}},
+	}
+	for _, tt := range tests {
+		got := parsePsStates([]byte(tt.out))
+		for _, state := range psStates {
+			if got[state] != tt.want[state] {
+				t.Fatalf("parse ps states %s fatal: %s - %d, want %d", tt.name, state, got[state], tt.want[state])
+			}
+		}
+	}
+}
+
+func Test_parsePsModifiers(t *testing.T) {
+	tests := []struct {
+		out                       string
+		multithreaded, foreground int64
+	}{
+		{"STAT\nS\nR\n", 0, 0},
+		{"STAT\nSsl\nR+\nSl+\nD<\nSNl\nS+l+\n", 4, 3},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		fields := parsePsStates([]byte(tt.out))
+		if fields["multithreaded"] != tt.multithreaded || fields["foreground"] != tt.foreground {
+			t.Fatalf("parse ps modifiers fatal: %q - %v", tt.out, fields)
+		}
+		L := psStateMetrics(fields)
+		if len(L) != len(psStates)+2 || L[len(L)-2].Name != "ps.multithreaded.num" {
+			t.Fatalf("ps modifier metrics fatal: %v", L)
+		}
+	}
+
+	// states read from /proc carry no modifiers
+	if L := psStateMetrics(map[string]int64{"running": 1, "total": 1}); len(L) != len(psStates) {
+		t.Fatalf("ps metrics without modifiers fatal: %v", L)
+	}
+}
+
+func Benchmark_execPsStates(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		out, err := execPSContext(context.Background())
+		if err != nil {
+			b.Skip("exec ps failed: ", err)
+		}
+		parsePsStates(out)
+	}
+}
+
+func Test_PsMetricsExecFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ps is not run on windows")
+	}
+	old, oldRoot := common.Conf, procRoot
+	defer func() { common.Conf, procRoot = old, oldRoot }()
+	// no procfs to fall back from and no ps to run
+	procRoot = filepath.Join(os.TempDir(), "no-such-proc")
+	common.Conf = &common.AgentConfig{PsPath: filepath.Join(os.TempDir(), "no-such-ps")}
+
+	L := PsMetrics()
+	if len(L) != 1 || L[0].Name != "collector.error" ||
+		L[0].Tags["collector"] != "PsMetrics" || L[0].Tags["reason"] != "exec" || L[0].Value != 1 {
+		t.Fatalf("ps exec failure metrics fatal: %v", L)
+	}
+	if !hasCollectorError(L) {
+		t.Fatalf("collector error not detected")
+	}
+}
+
+func Test_execPSContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := execPSContext(ctx); err == nil {
+		t.Fatalf("exec ps with cancelled context fatal: no error")
+	}
+}
+
+func Test_execPSContextConfigured(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir, err := ioutil.TempDir("", "ps-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	// echoes its arguments as process states, so the output proves both
+	// the binary and the arguments were used
+	script := filepath.Join(dir, "fake-ps")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\necho STAT\nfor s in \"$@\"; do echo $s; done\n"), 0755); err != nil {
+		t.Fatalf("write fake ps fatal: %s", err)
+	}
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{PsPath: script, PsArgs: []string{"Z", "Z", "R+"}}
+
+	out, err := execPSContext(context.Background())
+	if err != nil {
+		t.Fatalf("exec configured ps fatal: %s", err)
+	}
+	fields := parsePsStates(out)
+	if fields["zombies"] != 2 || fields["running"] != 1 || fields["foreground"] != 1 || fields["total"] != 3 {
+		t.Fatalf("configured ps output fatal: %q - %v", out, fields)
+	}
+
+	common.Conf = &common.AgentConfig{PsPath: filepath.Join(dir, "missing")}
+	if _, err := execPSContext(context.Background()); err == nil {
+		t.Fatalf("exec missing ps should fail")
+	}
+}
@@ -0,0 +1,67 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+var psiResources = []string{"cpu", "memory", "io"}
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "PsiMetrics", PsiMetrics)
+}
+
+// PsiMetrics report the pressure stall averages of /proc/pressure, which
+// exists since linux 4.20
+func PsiMetrics() []*common.Metric {
+	return psiMetrics(procPath("pressure"))
+}
+
+func psiMetrics(dir string) (L []*common.Metric) {
+	for _, res := range psiResources {
+		content, err := ioutil.ReadFile(filepath.Join(dir, res))
+		if err != nil {
+			if os.IsNotExist(err) {
+				collectorLog("PsiMetrics").Debugf("skip pressure/%s, psi may not be enabled: %s", res, err)
+			} else {
+				collectorLog("PsiMetrics").Errorf("failed to read pressure/%s: %s", res, err)
+			}
+			continue
+		}
+		for _, m := range parsePsi(string(content)) {
+			m.Name = "psi." + res + "." + m.Name
+			L = append(L, m)
+		}
+	}
+	return
+}
+
+// parsePsi parses lines like
+// "some avg10=0.12 avg60=0.05 avg300=0.01 total=123456"
+// into some.avg10, some.avg60 and some.avg300, total is left out
+func parsePsi(content string) (L []*common.Metric) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kind := fields[0]
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || !strings.HasPrefix(kv[0], "avg") {
+				continue
+			}
+			v, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				continue
+			}
+			L = append(L, toMetric(kind+"."+kv[0], v, nil))
+		}
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const psiCPUSample = `some avg10=1.53 avg60=0.87 avg300=0.32 total=4441524
+`
+
+const psiMemorySample = `some avg10=0.00 avg60=0.12 avg300=0.05 total=1031227
+full avg10=0.00 avg60=0.06 avg300=0.02 total=519506
+`
+
+func Test_psiMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pressure-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if L := psiMetrics(filepath.Join(dir, "missing")); len(L) != 0 {
+		t.Fatalf("psi metrics without pressure dir fatal: %d", len(L))
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "cpu"), []byte(psiCPUSample), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "memory"), []byte(psiMemorySample), 0644)
+	got := map[string]float64{}
+	for _, m := range psiMetrics(dir) {
+		got[m.Name] = m.Value.(float64)
+	}
+	if len(got) != 9 {
+		t.Fatalf("psi metrics fatal: %v", got)
+	}
+	want := map[string]float64{
+		"psi.cpu.some.avg10":     1.53,
+		"psi.cpu.some.avg300":    0.32,
+		"psi.memory.some.avg60":  0.12,
+		"psi.memory.full.avg10":  0,
+		"psi.memory.full.avg300": 0.02,
+	}
+	for name, v := range want {
+		if value, ok := got[name]; !ok || value != v {
+			t.Fatalf("psi metric %s fatal: %f, want %f", name, value, v)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sysinfo
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// raplZone is one RAPL power domain, a package or a subzone of it like
+// core or dram
+type raplZone struct {
+	dir    string
+	domain string
+	// microjoules, wraps to 0 past max
+	energy uint64
+	max    uint64
+}
+
+// raplCounter unwraps the energy counter of a zone
+type raplCounter struct {
+	last  uint64
+	total uint64
+}
+
+var (
+	raplRates    = common.NewRateTracker()
+	raplCounters = make(map[string]*raplCounter)
+)
+
+func init() {
+	RegisterFunc(common.TYPE_POWER, "RaplMetrics", RaplMetrics)
+}
+
+// RaplMetrics report the power draw of every Intel RAPL domain in watts,
+// averaged over the collect interval
+func RaplMetrics() []*common.Metric {
+	zones := readRaplZones(sysPath("class", "powercap"))
+	if len(zones) == 0 {
+		// AMD before zen, VMs and kernels without intel_rapl
+		collectorLog("RaplMetrics").Debugf("no RAPL energy counter found")
+		return nil
+	}
+	return raplMetrics(raplRates, raplCounters, zones, common.RateNow())
+}
+
+func raplMetrics(rates *common.RateTracker, counters map[string]*raplCounter, zones []raplZone, now float64) (L []*common.Metric) {
+	for _, z := range zones {
+		c, ok := counters[z.dir]
+		if !ok {
+			c = &raplCounter{last: z.energy}
+			counters[z.dir] = c
+		}
+		if z.energy >= c.last {
+			c.total += z.energy - c.last
+		} else if z.max > c.last {
+			c.total += z.max - c.last + z.energy
+		}
+		c.last = z.energy
+
+		tags := map[string]string{"domain": z.domain}
+		if rate, ok := rates.RateAt("power.rapl.watts", tags, c.total, now); ok {
+			L = append(L, toMetric("power.rapl.watts", common.SetPrecision(rate/1e6, 2), tags))
+		}
+	}
+	return
+}
+
+// readRaplZones reads the intel-rapl zones under the powercap class, a
+// subzone is named after its package, e.g. package-0/dram
+func readRaplZones(dir string) (zones []raplZone) {
+	dirs, _ := filepath.Glob(filepath.Join(dir, "intel-rapl:*"))
+	sort.Strings(dirs)
+	names := make(map[string]string, len(dirs))
+	for _, d := range dirs {
+		name, err := readFileString(filepath.Join(d, "name"))
+		if err != nil {
+			continue
+		}
+		id := strings.TrimPrefix(filepath.Base(d), "intel-rapl:")
+		names[id] = name
+		if i := strings.Index(id, ":"); i >= 0 {
+			if parent, ok := names[id[:i]]; ok {
+				name = parent + "/" + name
+			}
+		}
+
+		energy, err := readFileUint(filepath.Join(d, "energy_uj"))
+		if err != nil {
+			// root only since linux 5.10
+			collectorLog("RaplMetrics").Debugf("skip RAPL zone %s: %s", d, err)
+			continue
+		}
+		max, _ := readFileUint(filepath.Join(d, "max_energy_range_uj"))
+		zones = append(zones, raplZone{dir: d, domain: name, energy: energy, max: max})
+	}
+	return
+}
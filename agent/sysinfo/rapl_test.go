@@ -0,0 +1,43 @@
+package sysinfo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_raplMetrics(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"intel-rapl:0/name":                  "package-0\n",
+		"intel-rapl:0/energy_uj":             "999000000\n",
+		"intel-rapl:0/max_energy_range_uj":   "1000000000\n",
+		"intel-rapl:0:0/name":                "dram\n",
+		"intel-rapl:0:0/energy_uj":           "1000000\n",
+		"intel-rapl:0:0/max_energy_range_uj": "1000000000\n",
+		"intel-rapl:1/name":                  "package-1\n",
+	})
+	defer os.RemoveAll(dir)
+
+	zones := readRaplZones(dir)
+	if len(zones) != 2 || zones[0].domain != "package-0" || zones[1].domain != "package-0/dram" {
+		t.Fatalf("read RAPL zones fatal: %+v", zones)
+	}
+
+	rates := common.NewRateTracker()
+	counters := make(map[string]*raplCounter)
+	if L := raplMetrics(rates, counters, zones, 100); len(L) != 0 {
+		t.Fatalf("first sample fatal: %d", len(L))
+	}
+
+	// package-0 wrapped past max, 1J before and 9J after the wrap in 10s
+	zones[0].energy = 9000000
+	zones[1].energy = 51000000
+	watts := make(map[string]interface{})
+	for _, m := range raplMetrics(rates, counters, zones, 110) {
+		watts[m.Tags["domain"]] = m.Value
+	}
+	if watts["package-0"] != 1.0 || watts["package-0/dram"] != 5.0 {
+		t.Fatalf("RAPL watts fatal: %v", watts)
+	}
+}
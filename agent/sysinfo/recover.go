@@ -0,0 +1,52 @@
+package sysinfo
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/log"
+)
+
+var (
+	panicLock  sync.Mutex
+	panicTotal = make(map[string]int)
+)
+
+// RecordPanic logs a recovered collector panic with its stack and counts it
+// in agent.collector.panic.total
+func RecordPanic(collector string, r interface{}) {
+	log.Errorf("collector %s panic: %v\n%s", collector, r, debug.Stack())
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	panicTotal[collector]++
+}
+
+// safeCollect runs a collect function, a panic is recorded and turned into
+// an empty result so the other collect functions still report
+func safeCollect(name string, fn func() []*common.Metric) []*common.Metric {
+	res, _ := tryCollect(name, fn)
+	return res
+}
+
+// tryCollect is safeCollect reporting whether fn panicked
+func tryCollect(name string, fn func() []*common.Metric) (res []*common.Metric, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			RecordPanic(name, r)
+			res, panicked = nil, true
+		}
+	}()
+	return fn(), false
+}
+
+// panicMetrics report the number of panics per collector since start
+func panicMetrics() (L []*common.Metric) {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	for collector, n := range panicTotal {
+		L = append(L, toMetric("agent.collector.panic.total", n, map[string]string{"collector": collector}))
+	}
+	return
+}
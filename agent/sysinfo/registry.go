@@ -0,0 +1,201 @@
+package sysinfo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/log"
+)
+
+// MetricCollector is a named source of system metrics
+type MetricCollector interface {
+	// Name identifies the collector in config, e.g. "PsMetrics"
+	Name() string
+	// Collect returns the metrics of one collection
+	Collect() []*common.Metric
+}
+
+type funcCollector struct {
+	name string
+	fn   func() []*common.Metric
+}
+
+func (c funcCollector) Name() string {
+	return c.name
+}
+
+func (c funcCollector) Collect() []*common.Metric {
+	return c.fn()
+}
+
+var (
+	registryLock sync.RWMutex
+	runtimeLock  sync.RWMutex
+	// collect type -> collectors, in register order
+	registry = make(map[string][]MetricCollector)
+	// collector name -> enabled, set at runtime and preferred over config
+	runtimeEnabled = make(map[string]bool)
+)
+
+// Register adds a collector to a collect type, it is meant to be called
+// from init() of the file implementing the collector
+func Register(ctype string, c MetricCollector) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	for _, types := range registry {
+		for _, old := range types {
+			if old.Name() == c.Name() {
+				log.Errorf("collector %s registered twice, ignore", c.Name())
+				return
+			}
+		}
+	}
+	registry[ctype] = append(registry[ctype], c)
+}
+
+// RegisterFunc registers a collect function under the given name
+func RegisterFunc(ctype, name string, fn func() []*common.Metric) {
+	Register(ctype, funcCollector{name: name, fn: fn})
+}
+
+// Enabled reports whether the named collector is not disabled at runtime
+// or by config
+func Enabled(name string) bool {
+	runtimeLock.RLock()
+	enabled, ok := runtimeEnabled[name]
+	runtimeLock.RUnlock()
+	if ok {
+		return enabled
+	}
+	if common.Conf == nil {
+		return true
+	}
+	for _, disabled := range common.Conf.DisabledCollectors {
+		if disabled == name {
+			return false
+		}
+	}
+	return true
+}
+
+// SetEnabled enables or disables a registered collector until the agent
+// restarts, it overrides disabledcollectors of the config
+func SetEnabled(name string, enabled bool) error {
+	if _, ok := lookup(name); !ok {
+		return fmt.Errorf("unknown collector %s", name)
+	}
+	runtimeLock.Lock()
+	defer runtimeLock.Unlock()
+	runtimeEnabled[name] = enabled
+	return nil
+}
+
+// lookup returns the collect type of a registered collector
+func lookup(name string) (string, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	for t, types := range registry {
+		for _, c := range types {
+			if c.Name() == name {
+				return t, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CollectorStatus is the state of one registered collector
+type CollectorStatus struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Enabled     bool      `json:"enabled"`
+	LastSuccess time.Time `json:"last_success"`
+	Errors      int       `json:"errors"`
+}
+
+// CollectorStatuses lists every registered collector sorted by type and
+// name, with its enabled state and last run stats
+func CollectorStatuses() []CollectorStatus {
+	registryLock.RLock()
+	var res []CollectorStatus
+	for t, types := range registry {
+		for _, c := range types {
+			res = append(res, CollectorStatus{Name: c.Name(), Type: t})
+		}
+	}
+	registryLock.RUnlock()
+
+	successLock.Lock()
+	for i := range res {
+		res[i].LastSuccess = lastSuccess[res[i].Name]
+		res[i].Errors = errorTotal[res[i].Name]
+	}
+	successLock.Unlock()
+	for i := range res {
+		res[i].Enabled = Enabled(res[i].Name)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Type != res[j].Type {
+			return res[i].Type < res[j].Type
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// Collectors returns the enabled collectors of a collect type
+func Collectors(ctype string) []MetricCollector {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	var res []MetricCollector
+	for _, c := range registry[ctype] {
+		if Enabled(c.Name()) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Types returns the collect types with registered collectors, sorted
+func Types() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// CollectAll runs every enabled collector once and aggregates the metrics,
+// a panicking collector is skipped
+func CollectAll() []*common.Metric {
+	var collectors []MetricCollector
+	for _, t := range Types() {
+		collectors = append(collectors, Collectors(t)...)
+	}
+	return collect(collectors)
+}
+
+func collect(collectors []MetricCollector) (L []*common.Metric) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), collectDeadline(0))
+	defer cancel()
+	results := runCollectors(ctx, collectors, collectConcurrency(), collectorTimeout(0), func(c MetricCollector) collectResult {
+		return collectResult{metrics: safeCollect(c.Name(), c.Collect), ran: true}
+	})
+	for i, r := range results {
+		L = append(L, r.metrics...)
+		if r.timedOut {
+			L = append(L, timeoutMetric(collectors[i].Name()))
+		}
+	}
+	stampTimestamps(L, start)
+	return
+}
@@ -0,0 +1,118 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const testType = "TEST"
+
+func Test_Registry(t *testing.T) {
+	RegisterFunc(testType, "FakeMetrics", func() []*common.Metric {
+		return []*common.Metric{toMetric("fake.metric", 1, nil)}
+	})
+	RegisterFunc(testType, "OtherFakeMetrics", func() []*common.Metric {
+		return []*common.Metric{toMetric("fake.other", 1, nil)}
+	})
+
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{}
+
+	cs := Collectors(testType)
+	if len(cs) != 2 || cs[0].Name() != "FakeMetrics" {
+		t.Fatalf("registered collectors fatal: %d", len(cs))
+	}
+	if L := collect(cs); len(L) != 2 {
+		t.Fatalf("collect fatal: %d", len(L))
+	}
+
+	common.Conf.DisabledCollectors = []string{"FakeMetrics"}
+	cs = Collectors(testType)
+	if len(cs) != 1 || cs[0].Name() != "OtherFakeMetrics" {
+		t.Fatalf("disable collector fatal: %d", len(cs))
+	}
+	if Enabled("FakeMetrics") || !Enabled("OtherFakeMetrics") {
+		t.Fatalf("collector enabled state fatal")
+	}
+}
+
+func Test_collectRecoversPanic(t *testing.T) {
+	cs := []MetricCollector{
+		funcCollector{name: "PanicMetrics", fn: func() []*common.Metric {
+			var m *common.Metric
+			m.Name = "nil deref"
+			return []*common.Metric{m}
+		}},
+		funcCollector{name: "GoodMetrics", fn: func() []*common.Metric {
+			return []*common.Metric{toMetric("good.metric", 1, nil)}
+		}},
+	}
+	L := collect(cs)
+	if len(L) != 1 || L[0].Name != "good.metric" {
+		t.Fatalf("collect after panic fatal: %d", len(L))
+	}
+
+	found := false
+	for _, m := range panicMetrics() {
+		if m.Tags["collector"] == "PanicMetrics" && m.Value.(int) >= 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("panic not counted")
+	}
+}
+
+func Test_SetEnabled(t *testing.T) {
+	RegisterFunc(testType, "ToggleMetrics", func() []*common.Metric {
+		return []*common.Metric{toMetric("fake.toggle", 1, nil)}
+	})
+	defer func() {
+		runtimeLock.Lock()
+		runtimeEnabled = make(map[string]bool)
+		runtimeLock.Unlock()
+	}()
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{}
+
+	// keep CollectAll to the collector under test
+	for _, s := range CollectorStatuses() {
+		if s.Name != "ToggleMetrics" {
+			SetEnabled(s.Name, false)
+		}
+	}
+	has := func() bool {
+		for _, m := range CollectAll() {
+			if m.Name == "fake.toggle" {
+				return true
+			}
+		}
+		return false
+	}
+	if !has() {
+		t.Fatalf("enabled collector not run")
+	}
+	if err := SetEnabled("ToggleMetrics", false); err != nil {
+		t.Fatalf("disable fatal: %s", err)
+	}
+	if has() {
+		t.Fatalf("disabled collector still run")
+	}
+	if err := SetEnabled("NoSuchMetrics", false); err == nil {
+		t.Fatalf("unknown collector accepted")
+	}
+
+	common.Conf.DisabledCollectors = []string{"ToggleMetrics"}
+	SetEnabled("ToggleMetrics", true)
+	if !has() {
+		t.Fatalf("runtime enable not preferred over config")
+	}
+	for _, s := range CollectorStatuses() {
+		if s.Name == "ToggleMetrics" && (!s.Enabled || s.Type != testType) {
+			t.Fatalf("collector status fatal: %+v", s)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package sysinfo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+var (
+	sampleLock    sync.Mutex
+	sampleCycles  = make(map[string]int)
+	sampleCache   = make(map[string][]*common.Metric)
+	sampleLastRun = make(map[string]time.Time)
+	// clock of collectormininterval, replaced in tests
+	sampleNow = time.Now
+)
+
+// sampled runs the named collect function every Nth cycle as configured in
+// collectorsamplerate, and at most once per collectormininterval, and returns
+// a copy of the cached result of the last run in between, stamped by the
+// cycle reusing it. ran reports whether fn was executed.
+func sampled(name string, fn func() []*common.Metric) (res []*common.Metric, ran bool) {
+	rate, minInterval := 0, 0
+	if common.Conf != nil {
+		rate = common.Conf.CollectorSampleRate[name]
+		minInterval = common.Conf.CollectorMinInterval[name]
+	}
+	if rate <= 1 && minInterval <= 0 {
+		return safeCollect(name, fn), true
+	}
+
+	now := sampleNow()
+	sampleLock.Lock()
+	cycle := 0
+	if rate > 1 {
+		cycle = sampleCycles[name]
+		sampleCycles[name] = (cycle + 1) % rate
+	}
+	last, hasRun := sampleLastRun[name]
+	cached, ok := sampleCache[name]
+	sampleLock.Unlock()
+	tooSoon := hasRun && now.Sub(last) < time.Duration(minInterval)*time.Second
+	if (cycle != 0 || tooSoon) && ok {
+		return reusedMetrics(cached), false
+	}
+
+	res = safeCollect(name, fn)
+	sampleLock.Lock()
+	sampleCache[name] = res
+	sampleLastRun[name] = now
+	sampleLock.Unlock()
+	return res, true
+}
+
+// reusedMetrics copies the cached metrics with the timestamp cleared: the
+// cached ones were stamped by the cycle that ran the collector, re-sending
+// them would write the same points again
+func reusedMetrics(L []*common.Metric) []*common.Metric {
+	res := make([]*common.Metric, 0, len(L))
+	for _, m := range L {
+		if m == nil {
+			continue
+		}
+		c := toMetric(m.Name, m.Value, m.Tags)
+		c.Offset = m.Offset
+		res = append(res, c)
+	}
+	return res
+}
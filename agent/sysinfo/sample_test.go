@@ -0,0 +1,70 @@
+package sysinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_sampledMinInterval(t *testing.T) {
+	old, oldNow := common.Conf, sampleNow
+	defer func() { common.Conf, sampleNow = old, oldNow }()
+	common.Conf = &common.AgentConfig{CollectorMinInterval: map[string]int{"ExpensiveMetrics": 60}}
+	now := time.Unix(1500000000, 0)
+	sampleNow = func() time.Time { return now }
+
+	runs := 0
+	fn := func() []*common.Metric {
+		runs++
+		return []*common.Metric{toMetric("expensive", runs, nil)}
+	}
+	// the loop ticks every 10s
+	for i := 0; i < 13; i++ {
+		res, ran := sampled("ExpensiveMetrics", fn)
+		if ran != (i%6 == 0) {
+			t.Fatalf("tick %d ran fatal: %v", i, ran)
+		}
+		if len(res) != 1 || res[0].Value.(int) != i/6+1 {
+			t.Fatalf("tick %d should return the last result: %v", i, res)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	if runs != 3 {
+		t.Fatalf("expensive collector runs fatal: %d", runs)
+	}
+
+	// not limited collectors run every time
+	for i := 0; i < 2; i++ {
+		if _, ran := sampled("CheapMetrics", fn); !ran {
+			t.Fatalf("cheap collector should run every tick")
+		}
+	}
+}
+
+func Test_sampledReusedTimestamp(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = &common.AgentConfig{CollectorSampleRate: map[string]int{"StampedMetrics": 3}}
+
+	fn := func() []*common.Metric {
+		return []*common.Metric{toMetric("stamped", 1, map[string]string{"k": "v"})}
+	}
+	first, ran := sampled("StampedMetrics", fn)
+	if !ran {
+		t.Fatalf("first cycle should run")
+	}
+	stampTimestamps(first, time.Unix(1500000000, 0))
+
+	reused, ran := sampled("StampedMetrics", fn)
+	if ran || len(reused) != 1 {
+		t.Fatalf("second cycle should reuse the result: %v %v", ran, reused)
+	}
+	if reused[0] == first[0] || reused[0].Timestamp != 0 || reused[0].Tags["k"] != "v" {
+		t.Fatalf("reused metric should be an unstamped copy: %s", reused[0].String())
+	}
+	stampTimestamps(reused, time.Unix(1500000010, 0))
+	if reused[0].Timestamp != 1500000010 || first[0].Timestamp != 1500000000 {
+		t.Fatalf("reused metric stamp fatal: %d %d", reused[0].Timestamp, first[0].Timestamp)
+	}
+}
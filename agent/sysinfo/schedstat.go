@@ -0,0 +1,81 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+var (
+	lastRunqWait  map[string]uint64
+	lastRunqTime  float64
+	lastProcDelay map[int]uint64
+)
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "SchedstatMetrics", SchedstatMetrics)
+}
+
+// SchedstatMetrics report per CPU time tasks spent runnable but waiting
+// for the CPU, in milliseconds per second. It needs CONFIG_SCHEDSTATS.
+func SchedstatMetrics() (L []*common.Metric) {
+	if common.Conf == nil || !common.Conf.EnableSchedstat {
+		return
+	}
+	content, err := ioutil.ReadFile(procPath("schedstat"))
+	if err != nil {
+		collectorLog("SchedstatMetrics").Errorf("failed to read schedstat: %s", err)
+		return collectorError("SchedstatMetrics", errReasonRead)
+	}
+	waits := parseSchedstat(string(content))
+	now := common.RateNow()
+	interval := now - lastRunqTime
+	if lastRunqWait != nil && interval > 0 {
+		for core, wait := range waits {
+			old, ok := lastRunqWait[core]
+			if !ok || old > wait {
+				continue
+			}
+			v := common.SetPrecision(float64(wait-old)/1e6/interval, 2)
+			L = append(L, toMetric("cpu.runqueue.latency.rate", v, map[string]string{"core": core}))
+		}
+	}
+	lastRunqWait = waits
+	lastRunqTime = now
+	return
+}
+
+// parseSchedstat returns the cumulative run queue wait time (unit: ns) per
+// core from the "cpuN" lines in /proc/schedstat (version 15: the 8th field)
+func parseSchedstat(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		wait, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[strings.TrimPrefix(fields[0], "cpu")] = wait
+	}
+	return res
+}
+
+// procSchedWait returns the cumulative time (unit: ns) the process spent
+// waiting on a run queue, the second field of /proc/<pid>/schedstat
+func procSchedWait(pid int) (uint64, error) {
+	content, err := ioutil.ReadFile(pidPath(pid, "schedstat"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("invalid schedstat content: %q", content)
+	}
+	return strconv.ParseUint(fields[1], 10, 64)
+}
@@ -0,0 +1,41 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+const schedstatSample = `version 15
+timestamp 4297865826
+cpu0 0 0 0 0 0 0 1548435901558 226354003843 61051729
+domain0 00000003 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+cpu1 0 0 0 0 0 0 1499627283050 209411686279 59189552
+`
+
+func Test_parseSchedstat(t *testing.T) {
+	waits := parseSchedstat(schedstatSample)
+	if len(waits) != 2 {
+		t.Fatalf("parse schedstat fatal: %v", waits)
+	}
+	if waits["0"] != 226354003843 || waits["1"] != 209411686279 {
+		t.Fatalf("parse schedstat run queue wait fatal: %v", waits)
+	}
+}
+
+func Test_procSchedDelayMetric(t *testing.T) {
+	defer useFixtureRoot(t, map[string]string{
+		"proc/100/schedstat": "2868917669 1524000000 9828\n",
+		"proc/200/schedstat": "bad\n",
+	})()
+
+	wait, err := procSchedWait(100)
+	if err != nil || wait != 1524000000 {
+		t.Fatalf("read proc schedstat fatal: %d %v", wait, err)
+	}
+	if _, err := procSchedWait(200); err == nil {
+		t.Fatalf("invalid proc schedstat should fail")
+	}
+	m := procSchedDelayMetric("java", wait-1500000000)
+	if m.Name != "proc.sched.delay.ms" || m.Tags["name"] != "java" || m.Value.(float64) != 24 {
+		t.Fatalf("proc sched delay metric fatal: %s", m.String())
+	}
+}
@@ -0,0 +1,75 @@
+package sysinfo
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_DEV, "SensorsMetrics", SensorsMetrics)
+}
+
+// SensorsMetrics report fan, voltage and temperature readings from lm-sensors
+func SensorsMetrics() (L []*common.Metric) {
+	if common.Conf == nil || !common.Conf.EnableSensors {
+		return
+	}
+	out, err := execCommand(execTimeout, "sensors", "-j")
+	if execNotFound(err) {
+		collectorLog("SensorsMetrics").Debugf("sensors not found: %s", err)
+		return
+	} else if err != nil {
+		collectorLog("SensorsMetrics").Errorf("run sensors failed: %s", err)
+		return collectorError("SensorsMetrics", errReasonExec)
+	}
+	L, err = parseSensors(out)
+	if err != nil {
+		collectorLog("SensorsMetrics").Errorf("failed to parse sensors output: %s", err)
+		return collectorError("SensorsMetrics", errReasonExec)
+	}
+	return
+}
+
+// parseSensors parses `sensors -j` output, which looks like
+// {"chip": {"Adapter": "...", "sensor": {"fan1_input": 1200.0, ...}}}
+func parseSensors(out []byte) (L []*common.Metric, err error) {
+	chips := map[string]map[string]json.RawMessage{}
+	if err = json.Unmarshal(out, &chips); err != nil {
+		return nil, err
+	}
+	for chip, sensors := range chips {
+		for sensor, raw := range sensors {
+			readings := map[string]float64{}
+			// "Adapter" and other string fields are not readings
+			if json.Unmarshal(raw, &readings) != nil {
+				continue
+			}
+			for key, value := range readings {
+				if !strings.HasSuffix(key, "_input") {
+					continue
+				}
+				name := sensorMetricName(key)
+				if name == "" {
+					continue
+				}
+				tags := map[string]string{"chip": chip, "sensor": sensor}
+				L = append(L, toMetric(name, common.SetPrecision(value, 2), tags))
+			}
+		}
+	}
+	return L, nil
+}
+
+func sensorMetricName(key string) string {
+	switch {
+	case strings.HasPrefix(key, "fan"):
+		return "hw.fan.rpm"
+	case strings.HasPrefix(key, "in"):
+		return "hw.voltage"
+	case strings.HasPrefix(key, "temp"):
+		return "hw.temp.celsius"
+	}
+	return ""
+}
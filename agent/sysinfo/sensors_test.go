@@ -0,0 +1,64 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+const sensorsSample = `{
+   "coretemp-isa-0000":{
+      "Adapter": "ISA adapter",
+      "Package id 0":{
+         "temp1_input": 45.000,
+         "temp1_max": 80.000,
+         "temp1_crit": 100.000,
+         "temp1_crit_alarm": 0.000
+      },
+      "Core 0":{
+         "temp2_input": 43.500,
+         "temp2_max": 80.000
+      }
+   },
+   "nct6775-isa-0290":{
+      "Adapter": "ISA adapter",
+      "Vcore":{
+         "in0_input": 0.880,
+         "in0_min": 0.000
+      },
+      "fan2":{
+         "fan2_input": 1205.000,
+         "fan2_min": 0.000
+      },
+      "intrusion0":{
+         "intrusion0_alarm": 1.000
+      }
+   }
+}`
+
+func Test_parseSensors(t *testing.T) {
+	L, err := parseSensors([]byte(sensorsSample))
+	if err != nil {
+		t.Fatalf("parse sensors fatal: %s", err)
+	}
+	if len(L) != 4 {
+		t.Fatalf("parse sensors metrics fatal: %d", len(L))
+	}
+	values := make(map[string]float64)
+	for _, m := range L {
+		values[m.Name+"/"+m.Tags["chip"]+"/"+m.Tags["sensor"]] = m.Value.(float64)
+	}
+	expected := map[string]float64{
+		"hw.temp.celsius/coretemp-isa-0000/Package id 0": 45,
+		"hw.temp.celsius/coretemp-isa-0000/Core 0":       43.5,
+		"hw.voltage/nct6775-isa-0290/Vcore":              0.88,
+		"hw.fan.rpm/nct6775-isa-0290/fan2":               1205,
+	}
+	for k, v := range expected {
+		if values[k] != v {
+			t.Fatalf("parse sensors %s fatal: %v", k, values)
+		}
+	}
+
+	if _, err := parseSensors([]byte("coretemp-isa-0000\nAdapter: ISA adapter\n")); err == nil {
+		t.Fatalf("non json sensors output should fail")
+	}
+}
@@ -0,0 +1,133 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const defaultSmartctlPath = "smartctl"
+
+// smartctl forks once per disk and may spin a disk up, so it runs with the
+// slow DEV collectors rather than every DISK cycle
+func init() {
+	RegisterFunc(common.TYPE_DEV, "SmartMetrics", SmartMetrics)
+}
+
+// SmartMetrics report the SMART health of every disk with `smartctl -A -H`
+func SmartMetrics() (L []*common.Metric) {
+	smartctl := defaultSmartctlPath
+	if common.Conf != nil && common.Conf.SmartctlPath != "" {
+		smartctl = common.Conf.SmartctlPath
+	}
+	if _, err := exec.LookPath(smartctl); err != nil {
+		collectorLog("SmartMetrics").Debugf("smartctl not found: %s", err)
+		return
+	}
+	content, err := ioutil.ReadFile(procPath("diskstats"))
+	if err != nil {
+		collectorLog("SmartMetrics").Errorf("failed to read diskstats: %s", err)
+		return collectorError("SmartMetrics", errReasonRead)
+	}
+	var devices []string
+	for device := range parseDiskstats(string(content)) {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	timeout := smartctlTimeout(len(devices))
+	for _, device := range devices {
+		dev := "/dev/" + device
+		// smartctl exits non-zero when the disk logged errors, the report
+		// on stdout is still complete
+		out, err := execCommand(timeout, smartctl, "-A", "-H", dev)
+		if len(out) == 0 {
+			collectorLog("SmartMetrics").Debugf("run smartctl on %s failed: %v", dev, err)
+			continue
+		}
+		L = append(L, parseSmartctl(string(out), map[string]string{"device": dev})...)
+	}
+	return
+}
+
+// smartctlTimeout shares the collector deadline of the DEV cycle between
+// the disks, one share left for the rest, so a host with many or busy disks
+// is not abandoned by the watchdog every cycle
+func smartctlTimeout(disks int) time.Duration {
+	cycle := common.DEFAULT_INTERVAL[common.TYPE_DEV]
+	if common.Conf != nil && common.Conf.GroupIntervals[common.TYPE_DEV] > 0 {
+		cycle = common.Conf.GroupIntervals[common.TYPE_DEV]
+	}
+	timeout := collectorTimeout(cycle) / time.Duration(disks+1)
+	if timeout > execTimeout {
+		timeout = execTimeout
+	}
+	return timeout
+}
+
+// parseSmartctl parses the health and attributes of `smartctl -A -H`,
+// the ATA attribute table and the NVMe health log are both understood
+func parseSmartctl(out string, tags map[string]string) (L []*common.Metric) {
+	healthy := -1
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		// ATA and NVMe print "test result: PASSED", SCSI "Health Status: OK"
+		case strings.HasPrefix(line, "SMART overall-health self-assessment test result:"),
+			strings.HasPrefix(line, "SMART Health Status:"):
+			result := strings.TrimSpace(line[strings.LastIndex(line, ":")+1:])
+			healthy = 0
+			if result == "PASSED" || result == "OK" {
+				healthy = 1
+			}
+		case strings.HasPrefix(line, "Temperature:"):
+			// NVMe: "Temperature:  38 Celsius"
+			if fields := strings.Fields(line); len(fields) >= 2 {
+				if v, err := strconv.ParseFloat(fields[1], 64); err == nil {
+					L = append(L, toMetric("disk.smart.temperature", v, tags))
+				}
+			}
+		default:
+			// ATA: "ID# ATTRIBUTE_NAME FLAG VALUE WORST THRESH TYPE UPDATED WHEN_FAILED RAW_VALUE"
+			fields := strings.Fields(line)
+			if len(fields) < 10 {
+				continue
+			}
+			if _, err := strconv.Atoi(fields[0]); err != nil {
+				continue
+			}
+			raw, err := strconv.ParseFloat(fields[9], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[1] {
+			case "Reallocated_Sector_Ct":
+				L = append(L, toMetric("disk.smart.reallocated_sectors", raw, tags))
+			case "Current_Pending_Sector":
+				L = append(L, toMetric("disk.smart.pending_sectors", raw, tags))
+			case "Temperature_Celsius", "Airflow_Temperature_Cel":
+				if !hasMetric(L, "disk.smart.temperature") {
+					L = append(L, toMetric("disk.smart.temperature", raw, tags))
+				}
+			}
+		}
+	}
+	if healthy >= 0 {
+		L = append(L, toMetric("disk.smart.healthy", healthy, tags))
+	}
+	return
+}
+
+func hasMetric(L []*common.Metric, name string) bool {
+	for _, m := range L {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
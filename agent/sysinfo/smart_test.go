@@ -0,0 +1,89 @@
+package sysinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const smartctlSataSample = `smartctl 7.1 2019-12-30 r5022 [x86_64-linux-5.4.0-42-generic] (local build)
+Copyright (C) 2002-19, Bruce Allen, Christian Franke, www.smartmontools.org
+
+=== START OF READ SMART DATA SECTION ===
+SMART overall-health self-assessment test result: PASSED
+
+SMART Attributes Data Structure revision number: 16
+Vendor Specific SMART Attributes with Thresholds:
+ID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH TYPE      UPDATED  WHEN_FAILED RAW_VALUE
+  1 Raw_Read_Error_Rate     0x002f   200   200   051    Pre-fail  Always       -       0
+  5 Reallocated_Sector_Ct   0x0033   199   199   140    Pre-fail  Always       -       8
+  9 Power_On_Hours          0x0032   071   071   000    Old_age   Always       -       21345
+190 Airflow_Temperature_Cel 0x0022   064   052   045    Old_age   Always       -       36
+194 Temperature_Celsius     0x0022   114   102   000    Old_age   Always       -       36 (Min/Max 20/48)
+197 Current_Pending_Sector  0x0032   200   200   000    Old_age   Always       -       2
+198 Offline_Uncorrectable   0x0030   100   253   000    Old_age   Offline      -       0
+`
+
+const smartctlNvmeSample = `smartctl 7.1 2019-12-30 r5022 [x86_64-linux-5.4.0-42-generic] (local build)
+Copyright (C) 2002-19, Bruce Allen, Christian Franke, www.smartmontools.org
+
+=== START OF SMART DATA SECTION ===
+SMART overall-health self-assessment test result: FAILED!
+- NVM subsystem reliability has been degraded
+
+SMART/Health Information (NVMe Log 0x02)
+Critical Warning:                   0x04
+Temperature:                        41 Celsius
+Available Spare:                    100%
+Available Spare Threshold:          10%
+Percentage Used:                    3%
+Data Units Read:                    12,323,370 [6.30 TB]
+Media and Data Integrity Errors:    0
+`
+
+func Test_parseSmartctl(t *testing.T) {
+	for _, c := range []struct {
+		out  string
+		want map[string]interface{}
+	}{
+		{smartctlSataSample, map[string]interface{}{
+			"disk.smart.healthy":             1,
+			"disk.smart.reallocated_sectors": 8.0,
+			"disk.smart.pending_sectors":     2.0,
+			"disk.smart.temperature":         36.0,
+		}},
+		{smartctlNvmeSample, map[string]interface{}{
+			"disk.smart.healthy":     0,
+			"disk.smart.temperature": 41.0,
+		}},
+	} {
+		L := parseSmartctl(c.out, map[string]string{"device": "/dev/sda"})
+		if len(L) != len(c.want) {
+			t.Fatalf("parse smartctl fatal: %d metrics, want %d", len(L), len(c.want))
+		}
+		for _, m := range L {
+			if m.Value != c.want[m.Name] || m.Tags["device"] != "/dev/sda" {
+				t.Fatalf("parse smartctl fatal: %s=%v %v, want %v", m.Name, m.Value, m.Tags, c.want[m.Name])
+			}
+		}
+	}
+}
+
+func Test_smartctlTimeout(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	common.Conf = nil
+
+	// 300s DEV cycle, 150s collector deadline
+	if d := smartctlTimeout(2); d != execTimeout {
+		t.Fatalf("smartctl timeout of few disks fatal: %s", d)
+	}
+	if d := smartctlTimeout(29); d != 5*time.Second {
+		t.Fatalf("smartctl timeout of many disks fatal: %s", d)
+	}
+	common.Conf = &common.AgentConfig{CollectorTimeout: 20}
+	if d := smartctlTimeout(3); d != 5*time.Second {
+		t.Fatalf("smartctl timeout in collectortimeout fatal: %s", d)
+	}
+}
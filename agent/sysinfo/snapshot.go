@@ -0,0 +1,48 @@
+package sysinfo
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+var (
+	snapshotLock sync.RWMutex
+	// collect type -> metrics of its last scheduled cycle
+	snapshot = make(map[string][]*common.Metric)
+)
+
+// recordSnapshot keeps the metrics of the last cycle of a collect type
+func recordSnapshot(ctype string, L []*common.Metric) {
+	cp := make([]*common.Metric, 0, len(L))
+	for _, m := range L {
+		if m == nil {
+			continue
+		}
+		c := *m
+		cp = append(cp, &c)
+	}
+	snapshotLock.Lock()
+	defer snapshotLock.Unlock()
+	snapshot[ctype] = cp
+}
+
+// LastMetrics returns the metrics of the last scheduled cycle of every
+// collect type, sorted by type. Readers like /metrics get them without
+// running the collectors again, which would race with the scheduled cycle
+// and consume the deltas of its rate collectors.
+func LastMetrics() []*common.Metric {
+	snapshotLock.RLock()
+	defer snapshotLock.RUnlock()
+	types := make([]string, 0, len(snapshot))
+	for t := range snapshot {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	var L []*common.Metric
+	for _, t := range types {
+		L = append(L, snapshot[t]...)
+	}
+	return L
+}
@@ -0,0 +1,32 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func Test_LastMetrics(t *testing.T) {
+	defer func() {
+		snapshotLock.Lock()
+		snapshot = make(map[string][]*common.Metric)
+		snapshotLock.Unlock()
+	}()
+
+	mem := []*common.Metric{toMetric("mem.used", 1, nil)}
+	recordSnapshot(common.TYPE_MEM, mem)
+	recordSnapshot(common.TYPE_CPU, []*common.Metric{toMetric("cpu.idle", 90.0, nil), nil})
+	// the scheduled cycle keeps its own metrics
+	mem[0].Value = 2
+
+	L := LastMetrics()
+	if len(L) != 2 || L[0].Name != "cpu.idle" || L[1].Name != "mem.used" || L[1].Value != 1 {
+		t.Fatalf("last metrics fatal: %v", L)
+	}
+
+	// a new cycle replaces the last one of its type only
+	recordSnapshot(common.TYPE_CPU, nil)
+	if L := LastMetrics(); len(L) != 1 || L[0].Name != "mem.used" {
+		t.Fatalf("last metrics after new cycle fatal: %v", L)
+	}
+}
@@ -1,16 +1,38 @@
 package sysinfo
 
 import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
 	"github.com/lodastack/agent/agent/common"
 
-	"github.com/lodastack/log"
 	"github.com/lodastack/nux"
 )
 
+// sockstat counters and the metrics they are reported as
+var sockstatMetricNames = []struct {
+	key  string
+	name string
+}{
+	{"sockets.used", "net.sockets.used"},
+	{"tcp.inuse", "net.sockets.tcp.inuse"},
+	{"tcp.tw", "net.sockets.tcp.tw"},
+	{"tcp.orphan", "net.sockets.tcp.orphan"},
+	// in pages, compared against net.ipv4.tcp_mem
+	{"tcp.mem", "net.sockets.tcp.mem_pages"},
+	{"udp.inuse", "net.sockets.udp.inuse"},
+}
+
+func init() {
+	RegisterFunc(common.TYPE_NET, "SocketStatSummaryMetrics", SocketStatSummaryMetrics)
+	RegisterFunc(common.TYPE_NET, "SockstatMetrics", SockstatMetrics)
+}
+
 func SocketStatSummaryMetrics() (L []*common.Metric) {
 	ssMap, err := nux.SocketStatSummary()
 	if err != nil {
-		log.Error("failed to collect SocketStatSummaryMetrics:", err)
+		collectorLog("SocketStatSummaryMetrics").Errorf("failed to collect SocketStatSummaryMetrics: %s", err)
 		return
 	}
 
@@ -20,3 +42,43 @@ func SocketStatSummaryMetrics() (L []*common.Metric) {
 
 	return
 }
+
+// SockstatMetrics report the socket usage of /proc/net/sockstat
+func SockstatMetrics() []*common.Metric {
+	content, err := ioutil.ReadFile(procPath("net", "sockstat"))
+	if err != nil {
+		collectorLog("SockstatMetrics").Errorf("failed to read sockstat: %s", err)
+		return collectorError("SockstatMetrics", errReasonRead)
+	}
+	return sockstatMetrics(parseSockstat(string(content)))
+}
+
+func sockstatMetrics(counters map[string]uint64) (L []*common.Metric) {
+	for _, m := range sockstatMetricNames {
+		if v, ok := counters[m.key]; ok {
+			L = append(L, toMetric(m.name, v, nil))
+		}
+	}
+	return
+}
+
+// parseSockstat parses lines like "TCP: inuse 27 orphan 0 tw 2 alloc 31 mem 4"
+// into tcp.inuse, tcp.orphan and so on
+func parseSockstat(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		proto := strings.ToLower(strings.TrimSuffix(fields[0], ":"))
+		for i := 1; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				continue
+			}
+			res[proto+"."+fields[i]] = v
+		}
+	}
+	return res
+}
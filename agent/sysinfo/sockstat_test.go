@@ -0,0 +1,42 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+const sockstatSample = `sockets: used 290
+TCP: inuse 27 orphan 3 tw 2 alloc 31 mem 4
+UDP: inuse 5 mem 6
+UDPLITE: inuse 0
+RAW: inuse 1
+FRAG: inuse 0 memory 0
+`
+
+func Test_parseSockstat(t *testing.T) {
+	counters := parseSockstat(sockstatSample)
+	want := map[string]uint64{
+		"sockets.used": 290,
+		"tcp.inuse":    27,
+		"tcp.orphan":   3,
+		"tcp.tw":       2,
+		"tcp.alloc":    31,
+		"tcp.mem":      4,
+		"udp.inuse":    5,
+		"udp.mem":      6,
+		"raw.inuse":    1,
+		"frag.memory":  0,
+	}
+	for key, v := range want {
+		if got, ok := counters[key]; !ok || got != v {
+			t.Fatalf("sockstat %s fatal: %d, want %d", key, got, v)
+		}
+	}
+
+	L := sockstatMetrics(counters)
+	if len(L) != 6 {
+		t.Fatalf("sockstat metrics fatal: %d", len(L))
+	}
+	if L[4].Name != "net.sockets.tcp.mem_pages" || L[4].Value.(uint64) != 4 {
+		t.Fatalf("sockstat tcp mem fatal: %s", L[4].String())
+	}
+}
@@ -0,0 +1,59 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+var softirqRates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "SoftirqMetrics", SoftirqMetrics)
+}
+
+// SoftirqMetrics report softirqs per second of each type, summed over all
+// CPUs, from /proc/softirqs
+func SoftirqMetrics() []*common.Metric {
+	content, err := ioutil.ReadFile(procPath("softirqs"))
+	if err != nil {
+		collectorLog("SoftirqMetrics").Errorf("failed to read softirqs: %s", err)
+		return collectorError("SoftirqMetrics", errReasonRead)
+	}
+	return softirqRateMetrics(softirqRates, parseSoftirqs(string(content)), common.RateNow())
+}
+
+func softirqRateMetrics(rates *common.RateTracker, counters map[string]uint64, now float64) (L []*common.Metric) {
+	for typ, value := range counters {
+		name := "kernel.softirq." + strings.ToLower(typ) + ".rate"
+		if rate, ok := rates.RateAt(name, nil, value, now); ok {
+			L = append(L, toMetric(name, common.SetPrecision(rate, 2), nil))
+		}
+	}
+	return
+}
+
+// parseSoftirqs sums the per CPU columns of every "TYPE: n n n" row, the
+// header row of CPU names has no colon and is skipped
+func parseSoftirqs(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		typ := strings.TrimSuffix(fields[0], ":")
+		var sum uint64
+		for _, f := range fields[1:] {
+			n, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				continue
+			}
+			sum += n
+		}
+		res[typ] = sum
+	}
+	return res
+}
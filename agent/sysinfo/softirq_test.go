@@ -0,0 +1,60 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const softirqsSample = `                    CPU0       CPU1       CPU2       CPU3
+          HI:          1          0          0          2
+       TIMER:    1000000     900000     800000     700000
+      NET_TX:        100        200        300        400
+      NET_RX:    5000000    1000000     500000     100000
+       BLOCK:       1000       2000       3000       4000
+    IRQ_POLL:          0          0          0          0
+     TASKLET:         50         60         70         80
+       SCHED:     400000     300000     200000     100000
+     HRTIMER:          5          5          5          5
+         RCU:     700000     600000     500000     400000
+`
+
+const softirqsSample2 = `                    CPU0       CPU1       CPU2       CPU3
+          HI:          1          0          0          2
+       TIMER:    1001000     901000     801000     701000
+      NET_TX:        200        300        400        500
+      NET_RX:    5100000    1000000     500000     100000
+       BLOCK:       1000       2000       3000       4000
+    IRQ_POLL:          0          0          0          0
+     TASKLET:         50         60         70         80
+       SCHED:     400000     300000     200000     100000
+     HRTIMER:          5          5          5          5
+         RCU:     700000     600000     500000     400000
+`
+
+func Test_softirqRateMetrics(t *testing.T) {
+	counters := parseSoftirqs(softirqsSample)
+	if len(counters) != 10 || counters["NET_RX"] != 6600000 || counters["NET_TX"] != 1000 || counters["HI"] != 3 {
+		t.Fatalf("parse softirqs fatal: %v", counters)
+	}
+
+	rates := common.NewRateTracker()
+	if L := softirqRateMetrics(rates, counters, 100); len(L) != 0 {
+		t.Fatalf("softirq rate of first sample fatal: %d", len(L))
+	}
+	got := map[string]float64{}
+	for _, m := range softirqRateMetrics(rates, parseSoftirqs(softirqsSample2), 110) {
+		got[m.Name] = m.Value.(float64)
+	}
+	want := map[string]float64{
+		"kernel.softirq.net_rx.rate":   10000,
+		"kernel.softirq.net_tx.rate":   40,
+		"kernel.softirq.timer.rate":    400,
+		"kernel.softirq.irq_poll.rate": 0,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("softirq rate %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+}
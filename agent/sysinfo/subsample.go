@@ -0,0 +1,164 @@
+package sysinfo
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/log"
+	"github.com/lodastack/nux"
+)
+
+// subSampleSources take one fresh reading of a collector from cheap procfs
+// reads, keyed by collector name. Only these collectors can be sub-sampled,
+// a collector forking a command, e.g. ps, must not be run several times a
+// cycle.
+var subSampleSources = map[string]func() []*common.Metric{
+	"CpuMetrics": cpuSubSample,
+}
+
+// sleep between sub-samples, replaced in tests
+var subSampleSleep = time.Sleep
+
+// collectFunc returns the collect function of c for one cycle, a collector
+// configured in subsamples is collected once as usual and then read that
+// many times spread over its collector timeout, adding the min, avg and max
+// of every reading
+func collectFunc(c MetricCollector, cycle int) func() []*common.Metric {
+	n := 0
+	if common.Conf != nil {
+		n = common.Conf.SubSamples[c.Name()]
+	}
+	if n <= 1 {
+		return c.Collect
+	}
+	source, ok := subSampleSources[c.Name()]
+	if !ok {
+		log.Debugf("collector %s can not be sub-sampled, collect once", c.Name())
+		return c.Collect
+	}
+	spacing := collectorTimeout(cycle) / time.Duration(n)
+	return func() []*common.Metric {
+		return append(c.Collect(), subSample(source, n, spacing)...)
+	}
+}
+
+type subSeries struct {
+	name          string
+	tags          map[string]string
+	min, max, sum float64
+	count         int
+}
+
+// subSample reads source n times, spacing apart, and reports name.min,
+// name.avg and name.max of every numeric series. A collector.error is passed
+// through once, not aggregated.
+func subSample(source func() []*common.Metric, n int, spacing time.Duration) (L []*common.Metric) {
+	series := make(map[string]*subSeries)
+	var order []string
+	errors := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			subSampleSleep(spacing)
+		}
+		for _, m := range source() {
+			if m == nil {
+				continue
+			}
+			if m.Name == "collector.error" {
+				if key := subSeriesKey(m.Name, m.Tags); !errors[key] {
+					errors[key] = true
+					L = append(L, m)
+				}
+				continue
+			}
+			v, ok := metricFloat(m.Value)
+			if !ok {
+				continue
+			}
+			key := subSeriesKey(m.Name, m.Tags)
+			s, ok := series[key]
+			if !ok {
+				s = &subSeries{name: m.Name, tags: m.Tags, min: v, max: v}
+				series[key] = s
+				order = append(order, key)
+			}
+			if v < s.min {
+				s.min = v
+			}
+			if v > s.max {
+				s.max = v
+			}
+			s.sum += v
+			s.count++
+		}
+	}
+	for _, key := range order {
+		s := series[key]
+		L = append(L, toMetric(s.name+".min", common.SetPrecision(s.min, 2), s.tags))
+		L = append(L, toMetric(s.name+".avg", common.SetPrecision(s.sum/float64(s.count), 2), s.tags))
+		L = append(L, toMetric(s.name+".max", common.SetPrecision(s.max, 2), s.tags))
+	}
+	return
+}
+
+func subSeriesKey(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k+"="+tags[k])
+	}
+	sort.Strings(keys)
+	return name + "," + strings.Join(keys, ",")
+}
+
+func metricFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+var (
+	cpuSubSampleLock sync.Mutex
+	cpuSubSamplePrev *nux.ProcStat
+)
+
+// cpuSubSample reports cpu.idle and the CPU modes since its previous call,
+// it keeps its own /proc/stat sample so the one UpdateCpuStat refreshes is
+// left alone
+func cpuSubSample() []*common.Metric {
+	cur, err := nux.CurrentProcStat()
+	if err != nil {
+		collectorLog("CpuMetrics").Errorf("failed to read stat: %s", err)
+		return collectorError("CpuMetrics", errReasonRead)
+	}
+	cpuSubSampleLock.Lock()
+	prev := cpuSubSamplePrev
+	cpuSubSamplePrev = cur
+	cpuSubSampleLock.Unlock()
+	if prev == nil {
+		return nil
+	}
+	return cpuSubSampleMetrics(cur.Cpu, prev.Cpu)
+}
+
+func cpuSubSampleMetrics(cur, prev *nux.CpuUsage) []*common.Metric {
+	L := cpuModeMetrics(cur, prev, nil)
+	if len(L) == 0 || cur.Idle < prev.Idle {
+		return L
+	}
+	idle := common.SetPrecision(float64(cur.Idle-prev.Idle)*100/float64(cur.Total-prev.Total), 2)
+	return append(L, toMetric("cpu.idle", idle, nil))
+}
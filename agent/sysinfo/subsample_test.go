@@ -0,0 +1,108 @@
+package sysinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+
+	"github.com/lodastack/nux"
+)
+
+func Test_subSample(t *testing.T) {
+	var slept []time.Duration
+	oldSleep := subSampleSleep
+	defer func() { subSampleSleep = oldSleep }()
+	subSampleSleep = func(d time.Duration) { slept = append(slept, d) }
+
+	readings := []float64{20, 80, 50}
+	i := 0
+	source := func() []*common.Metric {
+		v := readings[i]
+		i++
+		return []*common.Metric{
+			toMetric("cpu.busy.percent", v, nil),
+			toMetric("cpu.busy.percent", v/10, map[string]string{"core": "0"}),
+			toMetric("cpu.state", "busy", nil),
+		}
+	}
+
+	got := make(map[string]interface{})
+	for _, m := range subSample(source, 3, 2*time.Second) {
+		got[m.Name+m.Tags["core"]] = m.Value
+	}
+	want := map[string]interface{}{
+		"cpu.busy.percent.min": 20.0, "cpu.busy.percent.avg": 50.0, "cpu.busy.percent.max": 80.0,
+		"cpu.busy.percent.min0": 2.0, "cpu.busy.percent.avg0": 5.0, "cpu.busy.percent.max0": 8.0,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("sub-sample metrics fatal: %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("sub-sample %s fatal: %v, want %v", k, got[k], v)
+		}
+	}
+	if len(slept) != 2 || slept[0] != 2*time.Second {
+		t.Fatalf("sub-sample spacing fatal: %v", slept)
+	}
+}
+
+func Test_collectFunc(t *testing.T) {
+	old := common.Conf
+	defer func() { common.Conf = old }()
+	oldSleep := subSampleSleep
+	defer func() { subSampleSleep = oldSleep }()
+	var slept time.Duration
+	subSampleSleep = func(d time.Duration) { slept = d }
+
+	calls := 0
+	subSampleSources["FakeSubMetrics"] = func() []*common.Metric {
+		calls++
+		return []*common.Metric{toMetric("fake.gauge", calls, nil)}
+	}
+	defer delete(subSampleSources, "FakeSubMetrics")
+	c := funcCollector{name: "FakeSubMetrics", fn: func() []*common.Metric {
+		return []*common.Metric{toMetric("fake.gauge", 1, nil)}
+	}}
+	forking := funcCollector{name: "ForkingMetrics", fn: c.fn}
+
+	common.Conf = &common.AgentConfig{SubSamples: map[string]int{"FakeSubMetrics": 4, "ForkingMetrics": 4}}
+	// the usual reading first, then the sub-samples
+	if L := collectFunc(c, 10)(); len(L) != 4 || L[0].Name != "fake.gauge" || L[2].Name != "fake.gauge.avg" || L[2].Value != 2.5 {
+		t.Fatalf("sub-sampled collect fatal: %v", L)
+	}
+	if calls != 4 || slept != 1250*time.Millisecond {
+		t.Fatalf("sub-sample calls fatal: %d - %s", calls, slept)
+	}
+	// no cheap source, collected once as usual
+	if L := collectFunc(forking, 10)(); len(L) != 1 || L[0].Name != "fake.gauge" {
+		t.Fatalf("collect without sub-sample source fatal: %v", L)
+	}
+}
+
+func Test_subSampleCollectorError(t *testing.T) {
+	oldSleep := subSampleSleep
+	defer func() { subSampleSleep = oldSleep }()
+	subSampleSleep = func(time.Duration) {}
+
+	source := func() []*common.Metric {
+		return collectorError("FakeMetrics", errReasonRead)
+	}
+	L := subSample(source, 3, time.Second)
+	if len(L) != 1 || !hasCollectorError(L) {
+		t.Fatalf("sub-sampled collector error fatal: %v", L)
+	}
+}
+
+func Test_cpuSubSampleMetrics(t *testing.T) {
+	prev := &nux.CpuUsage{User: 100, Idle: 800, Total: 1000}
+	cur := &nux.CpuUsage{User: 150, Idle: 1000, Total: 1250}
+	got := make(map[string]interface{})
+	for _, m := range cpuSubSampleMetrics(cur, prev) {
+		got[m.Name] = m.Value
+	}
+	if got["cpu.idle"] != 80.0 || got["cpu.busy.percent"] != 20.0 || got["cpu.user"] != 20.0 {
+		t.Fatalf("cpu sub-sample metrics fatal: %v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package sysinfo
+
+import (
+	"sync"
+	"time"
+)
+
+// successWindow is the number of recent cycles kept per collector
+const successWindow = 10
+
+var (
+	successLock    sync.Mutex
+	successHistory = make(map[string][]bool)
+	lastSuccess    = make(map[string]time.Time)
+	errorTotal     = make(map[string]int)
+	lastCycle      time.Time
+)
+
+// CollectorHealth is the health of one collector since start
+type CollectorHealth struct {
+	LastSuccess time.Time `json:"last_success"`
+	Errors      int       `json:"errors"`
+}
+
+// HealthStatus is the health of the collection as a whole
+type HealthStatus struct {
+	Healthy    bool                       `json:"healthy"`
+	LastCycle  time.Time                  `json:"last_cycle"`
+	Collectors map[string]CollectorHealth `json:"collectors"`
+}
+
+// recordSuccess appends the result of one cycle of the named collector and
+// returns the fraction of successful cycles in the window
+func recordSuccess(name string, ok bool) float64 {
+	successLock.Lock()
+	defer successLock.Unlock()
+	h := append(successHistory[name], ok)
+	if len(h) > successWindow {
+		h = h[len(h)-successWindow:]
+	}
+	successHistory[name] = h
+	if ok {
+		lastSuccess[name] = time.Now()
+	} else {
+		errorTotal[name]++
+	}
+
+	n := 0
+	for _, v := range h {
+		if v {
+			n++
+		}
+	}
+	return float64(n) / float64(len(h))
+}
+
+// recordCycle marks the end of a collection cycle of any collect type
+func recordCycle(t time.Time) {
+	successLock.Lock()
+	defer successLock.Unlock()
+	lastCycle = t
+}
+
+// Health reports healthy if a collection cycle completed within window
+// before now and at least one collector succeeded in that window
+func Health(now time.Time, window time.Duration) HealthStatus {
+	successLock.Lock()
+	defer successLock.Unlock()
+	s := HealthStatus{
+		LastCycle:  lastCycle,
+		Collectors: make(map[string]CollectorHealth, len(successHistory)),
+	}
+	fresh := false
+	for name := range successHistory {
+		s.Collectors[name] = CollectorHealth{LastSuccess: lastSuccess[name], Errors: errorTotal[name]}
+		if t, ok := lastSuccess[name]; ok && now.Sub(t) <= window {
+			fresh = true
+		}
+	}
+	s.Healthy = fresh && !lastCycle.IsZero() && now.Sub(lastCycle) <= window
+	return s
+}
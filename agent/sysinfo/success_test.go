@@ -0,0 +1,52 @@
+package sysinfo
+
+import (
+	"testing"
+	"time"
+)
+
+func resetHealth() {
+	successLock.Lock()
+	defer successLock.Unlock()
+	successHistory = make(map[string][]bool)
+	lastSuccess = make(map[string]time.Time)
+	errorTotal = make(map[string]int)
+	lastCycle = time.Time{}
+}
+
+func Test_Health(t *testing.T) {
+	resetHealth()
+	defer resetHealth()
+	if Health(time.Now(), time.Minute).Healthy {
+		t.Fatalf("health before any cycle should be unhealthy")
+	}
+
+	recordSuccess("PsMetrics", true)
+	recordSuccess("SmartMetrics", false)
+	recordSuccess("SmartMetrics", false)
+	recordCycle(time.Now())
+
+	s := Health(time.Now(), time.Minute)
+	if !s.Healthy {
+		t.Fatalf("health of fresh cycle fatal: %+v", s)
+	}
+	if s.Collectors["SmartMetrics"].Errors != 2 || !s.Collectors["SmartMetrics"].LastSuccess.IsZero() {
+		t.Fatalf("collector errors fatal: %+v", s.Collectors["SmartMetrics"])
+	}
+	if s.Collectors["PsMetrics"].Errors != 0 || s.Collectors["PsMetrics"].LastSuccess.IsZero() {
+		t.Fatalf("collector last success fatal: %+v", s.Collectors["PsMetrics"])
+	}
+
+	// two minutes later without another cycle
+	if s := Health(time.Now().Add(2*time.Minute), time.Minute); s.Healthy {
+		t.Fatalf("health of stale cycle fatal: %+v", s)
+	}
+
+	// cycles keep running but no collector produces metrics
+	resetHealth()
+	recordSuccess("PsMetrics", false)
+	recordCycle(time.Now())
+	if s := Health(time.Now(), time.Minute); s.Healthy {
+		t.Fatalf("health without any metrics fatal: %+v", s)
+	}
+}
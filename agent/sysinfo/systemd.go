@@ -0,0 +1,120 @@
+package sysinfo
+
+import (
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// default max number of failed units reported by name
+const defaultSystemdFailedMax = 20
+
+func init() {
+	RegisterFunc(common.TYPE_SYSTEMD, "SystemdFailedMetrics", SystemdFailedMetrics)
+	RegisterFunc(common.TYPE_SYSTEMD, "SystemdUnitMetrics", SystemdUnitMetrics)
+}
+
+// SystemdFailedMetrics report the number of failed systemd units and the
+// names of up to systemdfailedmax of them
+func SystemdFailedMetrics() (L []*common.Metric) {
+	out, err := execCommand(execTimeout, "systemctl", "list-units", "--state=failed", "--no-legend", "--plain", "--no-pager")
+	if execNotFound(err) {
+		collectorLog("SystemdFailedMetrics").Debugf("systemctl not found: %s", err)
+		return
+	} else if err != nil {
+		collectorLog("SystemdFailedMetrics").Errorf("list failed systemd units failed: %s", err)
+		return collectorError("SystemdFailedMetrics", errReasonExec)
+	}
+	units := parseFailedUnits(string(out))
+	L = append(L, toMetric("systemd.units.failed.total", len(units), nil))
+
+	max := defaultSystemdFailedMax
+	if common.Conf != nil && common.Conf.SystemdFailedMax > 0 {
+		max = common.Conf.SystemdFailedMax
+	}
+	for i, unit := range units {
+		if i >= max {
+			break
+		}
+		L = append(L, toMetric("systemd.units.failed.name", 1, map[string]string{"unit": unit}))
+	}
+	return
+}
+
+// parseFailedUnits returns the unit names, the first column of
+// `systemctl list-units --no-legend` output
+func parseFailedUnits(out string) (units []string) {
+	for _, line := range strings.Split(out, "\n") {
+		// some versions mark failed units with a bullet even in plain mode
+		line = strings.TrimLeft(strings.TrimSpace(line), "●* ")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		units = append(units, fields[0])
+	}
+	return
+}
+
+// SystemdUnitMetrics report whether every unit of watchedunits is active
+// or failed
+func SystemdUnitMetrics() (L []*common.Metric) {
+	if common.Conf == nil || len(common.Conf.WatchedUnits) == 0 {
+		return
+	}
+	args := append([]string{"show", "--property=Id,ActiveState,SubState", "--no-pager"}, common.Conf.WatchedUnits...)
+	out, err := execCommand(execTimeout, "systemctl", args...)
+	if execNotFound(err) {
+		collectorLog("SystemdUnitMetrics").Debugf("systemctl not found: %s", err)
+		return
+	} else if err != nil {
+		collectorLog("SystemdUnitMetrics").Errorf("show systemd units failed: %s", err)
+		return collectorError("SystemdUnitMetrics", errReasonExec)
+	}
+	units := parseUnitStates(string(out))
+	for _, unit := range common.Conf.WatchedUnits {
+		props, ok := units[unit]
+		if !ok {
+			// systemctl appends .service to a name without suffix
+			props = units[unit+".service"]
+		}
+		active, failed := 0, 0
+		switch props["ActiveState"] {
+		case "active":
+			active = 1
+		case "failed":
+			failed = 1
+		}
+		tags := map[string]string{"unit": unit}
+		L = append(L, toMetric("systemd.unit.active", active, tags))
+		L = append(L, toMetric("systemd.unit.failed", failed, tags))
+	}
+	return
+}
+
+// parseUnitStates parses `systemctl show` output of several units, one
+// block of "key=value" lines per unit separated by blank lines, keyed by Id
+func parseUnitStates(out string) map[string]map[string]string {
+	units := make(map[string]map[string]string)
+	props := make(map[string]string)
+	flush := func() {
+		if id := props["Id"]; id != "" {
+			units[id] = props
+		}
+		props = make(map[string]string)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		// values may contain '=' themselves
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) == 2 {
+			props[kv[0]] = kv[1]
+		}
+	}
+	flush()
+	return units
+}
@@ -0,0 +1,70 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const systemctlShowSample = `Id=nginx.service
+ActiveState=active
+SubState=running
+
+Id=cron.service
+ActiveState=failed
+SubState=failed
+
+Id=missing.service
+ActiveState=inactive
+SubState=dead
+`
+
+func Test_parseUnitStates(t *testing.T) {
+	units := parseUnitStates(systemctlShowSample)
+	if len(units) != 3 {
+		t.Fatalf("parse unit states fatal: %v", units)
+	}
+	if units["nginx.service"]["ActiveState"] != "active" || units["nginx.service"]["SubState"] != "running" {
+		t.Fatalf("parse nginx state fatal: %v", units["nginx.service"])
+	}
+	if units["cron.service"]["ActiveState"] != "failed" {
+		t.Fatalf("parse cron state fatal: %v", units["cron.service"])
+	}
+
+	units = parseUnitStates("Id=a.service\nExecStart={ path=/bin/a ; argv[]=/bin/a --x=1 }\n")
+	if units["a.service"]["ExecStart"] != "{ path=/bin/a ; argv[]=/bin/a --x=1 }" {
+		t.Fatalf("parse value with '=' fatal: %v", units["a.service"])
+	}
+}
+
+func Test_SystemdFailedMetricsExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir, err := ioutil.TempDir("", "systemd-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	oldPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", oldPath)
+
+	// no systemctl at all is a host without systemd
+	os.Setenv("PATH", dir)
+	if L := SystemdFailedMetrics(); len(L) != 0 {
+		t.Fatalf("missing systemctl fatal: %v", L)
+	}
+
+	// systemctl failing, e.g. not booted with systemd
+	script := "#!/bin/sh\necho 'System has not been booted with systemd' >&2\nexit 1\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "systemctl"), []byte(script), 0755); err != nil {
+		t.Fatalf("write fake systemctl fatal: %s", err)
+	}
+	L := SystemdFailedMetrics()
+	if len(L) != 1 || L[0].Name != "collector.error" ||
+		L[0].Tags["collector"] != "SystemdFailedMetrics" || L[0].Tags["reason"] != "exec" {
+		t.Fatalf("failing systemctl fatal: %v", L)
+	}
+}
@@ -0,0 +1,95 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// monotonic counters of /proc/net/snmp and /proc/net/netstat and the
+// metric reporting their per second rate
+var tcpRetransCounters = map[string]map[string]string{
+	"Tcp": {
+		"RetransSegs": "net.tcp.retrans.rate",
+		"OutSegs":     "net.tcp.outsegs.rate",
+		"InSegs":      "net.tcp.insegs.rate",
+	},
+	"TcpExt": {
+		"TCPLostRetransmit": "net.tcp.retrans.lost.rate",
+		"ListenDrops":       "net.tcp.listen.drops",
+		"ListenOverflows":   "net.tcp.listen.overflows",
+	},
+}
+
+var tcpRetransRates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_NET, "TcpRetransMetrics", TcpRetransMetrics)
+}
+
+// TcpRetransMetrics report tcp retransmits and listen queue drops per second
+func TcpRetransMetrics() []*common.Metric {
+	counters := make(map[string]map[string]uint64)
+	for _, file := range []string{"snmp", "netstat"} {
+		content, err := ioutil.ReadFile(procPath("net", file))
+		if err != nil {
+			collectorLog("TcpRetransMetrics").Errorf("failed to read net/%s: %s", file, err)
+			continue
+		}
+		for prefix, values := range parseSnmp(string(content)) {
+			counters[prefix] = values
+		}
+	}
+	return tcpRetransMetrics(tcpRetransRates, counters, common.RateNow())
+}
+
+func tcpRetransMetrics(rates *common.RateTracker, counters map[string]map[string]uint64, now float64) (L []*common.Metric) {
+	got := make(map[string]float64)
+	for prefix, names := range tcpRetransCounters {
+		for key, name := range names {
+			value, ok := counters[prefix][key]
+			if !ok {
+				continue
+			}
+			if rate, ok := rates.RateAt(name, nil, value, now); ok {
+				got[name] = rate
+				L = append(L, toMetric(name, common.SetPrecision(rate, 2), nil))
+			}
+		}
+	}
+	if out := got["net.tcp.outsegs.rate"]; out > 0 {
+		L = append(L, toMetric("net.tcp.retrans.percent", common.SetPrecision(got["net.tcp.retrans.rate"]*100/out, 2), nil))
+	}
+	return
+}
+
+// parseSnmp parses the layout shared by /proc/net/snmp and /proc/net/netstat,
+// a header line of names followed by a line of values with the same prefix:
+//
+//	Tcp: RtoAlgorithm RtoMin ... RetransSegs
+//	Tcp: 1 200 ... 1234
+//
+// Values that are not unsigned, like Tcp MaxConn -1, are skipped.
+func parseSnmp(content string) map[string]map[string]uint64 {
+	res := make(map[string]map[string]uint64)
+	lines := strings.Split(content, "\n")
+	for i := 0; i+1 < len(lines); i += 2 {
+		names, values := strings.Fields(lines[i]), strings.Fields(lines[i+1])
+		if len(names) == 0 || len(names) != len(values) || names[0] != values[0] {
+			continue
+		}
+		prefix := strings.TrimSuffix(names[0], ":")
+		counters := make(map[string]uint64, len(names)-1)
+		for j := 1; j < len(names); j++ {
+			v, err := strconv.ParseUint(values[j], 10, 64)
+			if err != nil {
+				continue
+			}
+			counters[names[j]] = v
+		}
+		res[prefix] = counters
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const snmpSample = `Ip: Forwarding DefaultTTL InReceives
+Ip: 1 64 123456
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 100 200 3 4 10 50000 40000 100 0 7 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams
+Udp: 10 0 0 10
+`
+
+const netstatSample = `TcpExt: SyncookiesSent ListenOverflows ListenDrops TCPLostRetransmit
+TcpExt: 0 5 6 10
+IpExt: InNoRoutes InTruncatedPkts
+IpExt: 0 0
+`
+
+const snmpSample2 = `Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 100 200 3 4 10 51000 42000 120 0 7 0
+`
+
+const netstatSample2 = `TcpExt: SyncookiesSent ListenOverflows ListenDrops TCPLostRetransmit
+TcpExt: 0 25 36 10
+`
+
+func parseSnmpSamples(samples ...string) map[string]map[string]uint64 {
+	counters := make(map[string]map[string]uint64)
+	for _, s := range samples {
+		for prefix, values := range parseSnmp(s) {
+			counters[prefix] = values
+		}
+	}
+	return counters
+}
+
+func Test_parseSnmp(t *testing.T) {
+	counters := parseSnmpSamples(snmpSample, netstatSample)
+	if len(counters) != 5 {
+		t.Fatalf("parse snmp fatal: %v", counters)
+	}
+	tcp := counters["Tcp"]
+	if tcp["RetransSegs"] != 100 || tcp["OutSegs"] != 40000 || tcp["InSegs"] != 50000 {
+		t.Fatalf("parse snmp Tcp fatal: %v", tcp)
+	}
+	if _, ok := tcp["MaxConn"]; ok {
+		t.Fatalf("signed MaxConn should be skipped")
+	}
+	if counters["TcpExt"]["ListenDrops"] != 6 || counters["TcpExt"]["ListenOverflows"] != 5 {
+		t.Fatalf("parse netstat TcpExt fatal: %v", counters["TcpExt"])
+	}
+}
+
+func Test_tcpRetransMetrics(t *testing.T) {
+	rates := common.NewRateTracker()
+	if L := tcpRetransMetrics(rates, parseSnmpSamples(snmpSample, netstatSample), 100); len(L) != 0 {
+		t.Fatalf("tcp retrans rate of first sample fatal: %d", len(L))
+	}
+	got := map[string]float64{}
+	for _, m := range tcpRetransMetrics(rates, parseSnmpSamples(snmpSample2, netstatSample2), 110) {
+		got[m.Name] = m.Value.(float64)
+	}
+	want := map[string]float64{
+		"net.tcp.retrans.rate":      2,
+		"net.tcp.retrans.lost.rate": 0,
+		"net.tcp.retrans.percent":   1,
+		"net.tcp.listen.drops":      3,
+		"net.tcp.listen.overflows":  2,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("tcp retrans %s fatal: %f, want %f", name, got[name], v)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package sysinfo
+
+import (
+	"github.com/lodastack/agent/agent/common"
+)
+
+// tcp states as numbered in include/net/tcp_states.h
+var tcpStates = map[int]string{
+	0x01: "established",
+	0x02: "syn_sent",
+	0x03: "syn_recv",
+	0x04: "fin_wait1",
+	0x05: "fin_wait2",
+	0x06: "time_wait",
+	0x07: "close",
+	0x08: "close_wait",
+	0x09: "last_ack",
+	0x0A: "listen",
+	0x0B: "closing",
+}
+
+func init() {
+	RegisterFunc(common.TYPE_NET, "TcpMetrics", TcpMetrics)
+}
+
+// TcpMetrics report the number of IPv4 and IPv6 tcp sockets per state
+func TcpMetrics() (L []*common.Metric) {
+	conns, err := listTCPConns()
+	if err != nil {
+		collectorLog("TcpMetrics").Errorf("failed to read tcp socket table: %s", err)
+		return collectorError("TcpMetrics", errReasonRead)
+	}
+	for state, num := range countTCPStates(conns) {
+		L = append(L, toMetric("net.tcp."+state, num, nil))
+	}
+	return
+}
+
+// countTCPStates counts sockets per state name, every known state is present
+func countTCPStates(conns []tcpConn) map[string]int {
+	counts := make(map[string]int, len(tcpStates))
+	for _, name := range tcpStates {
+		counts[name] = 0
+	}
+	for _, c := range conns {
+		if name, ok := tcpStates[c.State]; ok {
+			counts[name]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,80 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// socket states in /proc/net/tcp, see include/net/tcp_states.h
+const (
+	tcpEstablished = 0x01
+	tcpListen      = 0x0A
+)
+
+type tcpConn struct {
+	LocalPort  int
+	RemotePort int
+	State      int
+	// inode of the socket, 0 if unknown
+	Inode uint64
+}
+
+// listTCPConns reads the IPv4 and IPv6 tcp socket tables
+func listTCPConns() ([]tcpConn, error) {
+	var conns []tcpConn
+	var lastErr error
+	for _, name := range []string{"tcp", "tcp6"} {
+		content, err := ioutil.ReadFile(procPath("net", name))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conns = append(conns, parseTCPTable(string(content))...)
+	}
+	if conns == nil && lastErr != nil {
+		return nil, lastErr
+	}
+	return conns, nil
+}
+
+// parseTCPTable parses the content of /proc/net/tcp or /proc/net/tcp6,
+// the first line is the header and is skipped
+func parseTCPTable(content string) []tcpConn {
+	var conns []tcpConn
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		local, ok1 := hexPort(fields[1])
+		remote, ok2 := hexPort(fields[2])
+		state, err := strconv.ParseInt(fields[3], 16, 32)
+		if !ok1 || !ok2 || err != nil {
+			continue
+		}
+		conn := tcpConn{LocalPort: local, RemotePort: remote, State: int(state)}
+		if len(fields) > 9 {
+			conn.Inode, _ = strconv.ParseUint(fields[9], 10, 64)
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// hexPort extracts the port from an "ADDR:PORT" field in hex notation
+func hexPort(addr string) (int, bool) {
+	i := strings.LastIndex(addr, ":")
+	if i < 0 {
+		return 0, false
+	}
+	port, err := strconv.ParseInt(addr[i+1:], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(port), true
+}
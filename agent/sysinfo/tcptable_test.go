@@ -0,0 +1,36 @@
+package sysinfo
+
+import (
+	"testing"
+)
+
+const tcpSample = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 23456 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:18EB 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 20123 1 0000000000000000 100 0 0 10 0
+   2: 0F02000A:0CEA 0A02000A:D431 01 00000000:00000000 02:000A2C5D 00000000   999        0 34567 2 0000000000000000 20 4 30 10 -1
+   3: 0F02000A:0CEA 0B02000A:D432 01 00000000:00000000 02:000A2C5D 00000000   999        0 34568 2 0000000000000000 20 4 30 10 -1
+   4: 0F02000A:9C40 0C02000A:0050 06 00000000:00000000 03:00000F5E 00000000     0        0 0 3 0000000000000000
+`
+
+const tcp6Sample = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 19876 1 0000000000000000 100 0 0 10 0
+   1: 0000000000000000FFFF00000F02000A:0016 0000000000000000FFFF00000A02000A:C350 08 00000000:00000000 00:00000000 00000000     0        0 45678 1 0000000000000000 20 4 1 10 -1
+`
+
+func Test_parseTCPTable(t *testing.T) {
+	conns := append(parseTCPTable(tcpSample), parseTCPTable(tcp6Sample)...)
+	if len(conns) != 7 {
+		t.Fatalf("parse tcp table fatal: %d conns", len(conns))
+	}
+	if c := conns[2]; c.LocalPort != 3306 || c.RemotePort != 54321 || c.State != tcpEstablished {
+		t.Fatalf("parse tcp conn fatal: %+v", c)
+	}
+
+	counts := countTCPStates(conns)
+	want := map[string]int{"listen": 3, "established": 2, "time_wait": 1, "close_wait": 1, "syn_sent": 0}
+	for state, n := range want {
+		if counts[state] != n {
+			t.Fatalf("count tcp states fatal: %s - %d, want %d", state, counts[state], n)
+		}
+	}
+}
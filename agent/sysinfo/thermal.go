@@ -0,0 +1,70 @@
+package sysinfo
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_DEV, "ThermalMetrics", ThermalMetrics)
+}
+
+// ThermalMetrics report the temperature of every thermal zone and hwmon
+// temperature input from sysfs
+func ThermalMetrics() []*common.Metric {
+	return append(thermalZoneMetrics(sysPath("class", "thermal")), hwmonTempMetrics(sysPath("class", "hwmon"))...)
+}
+
+// thermalZoneMetrics reads thermal_zone*/temp, tagged by zone and zone type
+func thermalZoneMetrics(dir string) (L []*common.Metric) {
+	zones, _ := filepath.Glob(filepath.Join(dir, "thermal_zone*"))
+	for _, zone := range zones {
+		celsius, err := readMilliCelsius(filepath.Join(zone, "temp"))
+		if err != nil {
+			collectorLog("ThermalMetrics").Debugf("skip thermal zone %s: %s", zone, err)
+			continue
+		}
+		typ, _ := readFileString(filepath.Join(zone, "type"))
+		tags := map[string]string{"zone": filepath.Base(zone), "type": typ}
+		L = append(L, toMetric("sensor.temp.celsius", celsius, tags))
+	}
+	return
+}
+
+// hwmonTempMetrics reads hwmon*/temp*_input, tagged by the hwmon as zone,
+// the chip name as type and the input label as sensor
+func hwmonTempMetrics(dir string) (L []*common.Metric) {
+	inputs, _ := filepath.Glob(filepath.Join(dir, "hwmon*", "temp*_input"))
+	for _, input := range inputs {
+		celsius, err := readMilliCelsius(input)
+		if err != nil {
+			collectorLog("ThermalMetrics").Debugf("skip hwmon input %s: %s", input, err)
+			continue
+		}
+		hwmon := filepath.Dir(input)
+		sensor := strings.TrimSuffix(filepath.Base(input), "_input")
+		if label, err := readFileString(filepath.Join(hwmon, sensor+"_label")); err == nil && label != "" {
+			sensor = label
+		}
+		name, _ := readFileString(filepath.Join(hwmon, "name"))
+		tags := map[string]string{"zone": filepath.Base(hwmon), "type": name, "sensor": sensor}
+		L = append(L, toMetric("sensor.temp.celsius", celsius, tags))
+	}
+	return
+}
+
+// readMilliCelsius reads a sysfs temperature in millidegrees as celsius
+func readMilliCelsius(path string) (float64, error) {
+	s, err := readFileString(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return common.SetPrecision(float64(v)/1000, 2), nil
+}
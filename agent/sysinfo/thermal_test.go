@@ -0,0 +1,69 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSysFixture(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "sys-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(content), 0644)
+	}
+	return dir
+}
+
+func Test_thermalZoneMetrics(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"thermal_zone0/type": "x86_pkg_temp\n",
+		"thermal_zone0/temp": "45500\n",
+		"thermal_zone1/type": "acpitz\n",
+		"thermal_zone1/temp": "-1234\n",
+		// fails to read on some firmware
+		"thermal_zone2/type": "iwlwifi_1\n",
+	})
+	defer os.RemoveAll(dir)
+
+	L := thermalZoneMetrics(dir)
+	if len(L) != 2 {
+		t.Fatalf("thermal zone metrics fatal: %d", len(L))
+	}
+	if L[0].Tags["zone"] != "thermal_zone0" || L[0].Tags["type"] != "x86_pkg_temp" || L[0].Value.(float64) != 45.5 {
+		t.Fatalf("thermal zone metric fatal: %s", L[0].String())
+	}
+	if L[1].Value.(float64) != -1.23 {
+		t.Fatalf("negative thermal zone metric fatal: %s", L[1].String())
+	}
+}
+
+func Test_hwmonTempMetrics(t *testing.T) {
+	dir := writeSysFixture(t, map[string]string{
+		"hwmon0/name":        "coretemp\n",
+		"hwmon0/temp1_input": "52000\n",
+		"hwmon0/temp1_label": "Package id 0\n",
+		"hwmon0/temp2_input": "50000\n",
+		"hwmon1/name":        "drivetemp\n",
+		"hwmon1/temp1_input": "bad\n",
+		"hwmon1/fan1_input":  "1200\n",
+	})
+	defer os.RemoveAll(dir)
+
+	L := hwmonTempMetrics(dir)
+	if len(L) != 2 {
+		t.Fatalf("hwmon metrics fatal: %d", len(L))
+	}
+	m := L[0]
+	if m.Tags["zone"] != "hwmon0" || m.Tags["type"] != "coretemp" || m.Tags["sensor"] != "Package id 0" || m.Value.(float64) != 52 {
+		t.Fatalf("hwmon metric fatal: %s", m.String())
+	}
+	if L[1].Tags["sensor"] != "temp2" {
+		t.Fatalf("hwmon metric without label fatal: %s", L[1].String())
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"time"
 
 	"github.com/lodastack/agent/agent/common"
-
-	"github.com/lodastack/log"
 )
 
 type mode uint8
@@ -29,6 +27,10 @@ const (
 
 	ntpserver  = "133.100.11.8" // 日本福冈大学 NTP Server
 	ntpversion = 4
+	ntpport    = "123"
+
+	// a clock off by less than this is reported as synced
+	defaultNtpMaxOffset = 100 * time.Millisecond
 )
 
 var (
@@ -114,25 +116,62 @@ type Response struct {
 	RootDispersion time.Duration // server's dispersion to the reference clock
 }
 
+func init() {
+	RegisterFunc(common.TYPE_TIME, "TimeMetrics", TimeMetrics)
+}
+
 func TimeMetrics() (L []*common.Metric) {
+	return ntpMetrics(ntpServer())
+}
+
+// ntpServer returns the configured NTP server, default ntpserver
+func ntpServer() string {
+	if common.Conf != nil && common.Conf.NtpServer != "" {
+		return common.Conf.NtpServer
+	}
+	return ntpserver
+}
+
+// ntpMaxOffset returns the offset a synced clock may drift at most
+func ntpMaxOffset() time.Duration {
+	if common.Conf != nil && common.Conf.NtpMaxOffset > 0 {
+		return time.Duration(common.Conf.NtpMaxOffset) * time.Millisecond
+	}
+	return defaultNtpMaxOffset
+}
+
+func ntpMetrics(host string) (L []*common.Metric) {
 	times := 3
 	for i := 1; i <= times; i++ {
-		res, err := Query(ntpserver, ntpversion)
+		res, err := Query(host, ntpversion)
 		if err != nil && i == times {
-			log.Debugf("query time from NTP server failed: %s", err)
-			return
+			collectorLog("TimeMetrics").Errorf("query time from NTP server failed: %s", err)
+			return collectorError("TimeMetrics", errReasonExec)
 		}
 
 		if err != nil {
 			continue
 		}
 
+		sync := 0
+		if absDuration(res.ClockOffset) <= ntpMaxOffset() {
+			sync = 1
+		}
 		L = append(L, toMetric("time.offset", res.ClockOffset.Seconds(), nil))
+		L = append(L, toMetric("kernel.ntp.offset_ms", durationMs(res.ClockOffset), nil))
+		L = append(L, toMetric("kernel.ntp.sync", sync, nil))
 		return
 	}
 	return
 }
 
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
 // Query returns information from the remote NTP server specifed as host.  NTP
 // client mode is used.
 func Query(host string, version int) (*Response, error) {
@@ -169,7 +208,11 @@ func getTime(host string, version int) (*msg, error) {
 		panic("ntp: invalid version number")
 	}
 
-	raddr, err := net.ResolveUDPAddr("udp", host+":123")
+	addr := host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		addr = net.JoinHostPort(host, ntpport)
+	}
+	raddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
 		return nil, err
 	}
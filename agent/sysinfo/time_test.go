@@ -0,0 +1,84 @@
+package sysinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// serveNtp answers every request on conn as a server whose clock runs skew
+// ahead of the local one
+func serveNtp(conn *net.UDPConn, skew time.Duration) {
+	buf := make([]byte, 48)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := new(msg)
+		if err := binary.Read(bytes.NewReader(buf[:n]), binary.BigEndian, req); err != nil {
+			continue
+		}
+		now := toNtpTime(time.Now().Add(skew))
+		res := &msg{
+			Stratum:      2,
+			OriginTime:   req.TransmitTime,
+			ReceiveTime:  now,
+			TransmitTime: now,
+		}
+		res.setVersion(ntpversion)
+		res.setMode(server)
+		var out bytes.Buffer
+		binary.Write(&out, binary.BigEndian, res)
+		conn.WriteToUDP(out.Bytes(), addr)
+	}
+}
+
+func Test_ntpMetrics(t *testing.T) {
+	for _, c := range []struct {
+		skew time.Duration
+		sync int
+	}{
+		{2 * time.Second, 0},
+		{0, 1},
+	} {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("listen udp fatal: %s", err)
+		}
+		go serveNtp(conn, c.skew)
+
+		values := map[string]interface{}{}
+		for _, m := range ntpMetrics(conn.LocalAddr().String()) {
+			values[m.Name] = m.Value
+		}
+		conn.Close()
+
+		offset, _ := values["kernel.ntp.offset_ms"].(float64)
+		want := float64(c.skew / time.Millisecond)
+		if offset < want-50 || offset > want+50 {
+			t.Fatalf("ntp offset fatal: skew %v, got %vms", c.skew, offset)
+		}
+		if values["kernel.ntp.sync"] != c.sync {
+			t.Fatalf("ntp sync fatal: skew %v, got %v", c.skew, values["kernel.ntp.sync"])
+		}
+	}
+}
+
+func Test_ntpMetricsQueryFailure(t *testing.T) {
+	// nothing listens on the port once it is closed
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen udp fatal: %s", err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	L := ntpMetrics(addr)
+	if len(L) != 1 || L[0].Name != "collector.error" ||
+		L[0].Tags["collector"] != "TimeMetrics" || L[0].Tags["reason"] != "exec" {
+		t.Fatalf("ntp query failure fatal: %v", L)
+	}
+}
@@ -0,0 +1,53 @@
+package sysinfo
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const (
+	timezoneFile  = "/etc/timezone"
+	localtimeFile = "/etc/localtime"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_TIME, "TimezoneMetrics", TimezoneMetrics)
+}
+
+// TimezoneMetrics report the UTC offset and configured timezone name
+func TimezoneMetrics() (L []*common.Metric) {
+	zone, offset := time.Now().Zone()
+	L = append(L, toMetric("kernel.timezone.offset.seconds", offset, nil))
+
+	tz := configuredTimezone()
+	if tz == "" {
+		tz = zone
+	}
+	L = append(L, toMetric("kernel.timezone", 1, map[string]string{"tz": tz}))
+	return
+}
+
+// configuredTimezone returns the timezone from /etc/timezone, or the
+// zoneinfo name the /etc/localtime symlink points to
+func configuredTimezone() string {
+	if tz, err := readFileString(timezoneFile); err == nil && tz != "" {
+		return tz
+	}
+	target, err := os.Readlink(localtimeFile)
+	if err != nil {
+		return ""
+	}
+	return zoneFromPath(target)
+}
+
+// zoneFromPath turns "/usr/share/zoneinfo/Asia/Shanghai" into "Asia/Shanghai"
+func zoneFromPath(path string) string {
+	const marker = "zoneinfo/"
+	if i := strings.LastIndex(path, marker); i >= 0 {
+		return path[i+len(marker):]
+	}
+	return path
+}
@@ -0,0 +1,144 @@
+package sysinfo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// default number of processes reported by the top N process collectors
+const defaultProcTopN = 5
+
+// USER_HZ, the unit of utime and stime in /proc/<pid>/stat
+const clockTicks = 100
+
+type procSample struct {
+	Pid   int
+	Comm  string
+	Ticks uint64 // utime + stime
+	RSS   uint64 // bytes
+	CPU   float64
+}
+
+var topProcRates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_CPU, "TopProcMetrics", TopProcMetrics)
+}
+
+func procTopN() int {
+	if common.Conf != nil && common.Conf.ProcMetricsTopN > 0 {
+		return common.Conf.ProcMetricsTopN
+	}
+	return defaultProcTopN
+}
+
+// TopProcMetrics report the processes using the most CPU and memory
+func TopProcMetrics() []*common.Metric {
+	samples, err := readProcSamples()
+	if err != nil {
+		collectorLog("TopProcMetrics").Errorf("failed to list processes: %s", err)
+		return nil
+	}
+	return topProcMetrics(topProcRates, samples, common.RateNow(), procTopN())
+}
+
+func topProcMetrics(rates *common.RateTracker, samples []procSample, now float64, n int) (L []*common.Metric) {
+	var withCPU []procSample
+	for _, s := range samples {
+		// comm is part of the series so a reused pid starts over
+		tags := map[string]string{"pid": strconv.Itoa(s.Pid), "comm": s.Comm}
+		if rate, ok := rates.RateAt("proc.cpu", tags, s.Ticks, now); ok {
+			s.CPU = rate * 100 / clockTicks
+			withCPU = append(withCPU, s)
+		}
+	}
+	// forget the processes that exited since the last scan
+	rates.Prune(now)
+
+	sort.Slice(withCPU, func(i, j int) bool { return withCPU[i].CPU > withCPU[j].CPU })
+	for i := 0; i < len(withCPU) && i < n; i++ {
+		s := withCPU[i]
+		tags := map[string]string{"pid": strconv.Itoa(s.Pid), "comm": s.Comm}
+		L = append(L, toMetric("proc.cpu.percent", common.SetPrecision(s.CPU, 2), tags))
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].RSS > samples[j].RSS })
+	for i := 0; i < len(samples) && i < n; i++ {
+		s := samples[i]
+		tags := map[string]string{"pid": strconv.Itoa(s.Pid), "comm": s.Comm}
+		L = append(L, toMetric("proc.mem.rss", s.RSS, tags))
+	}
+	return
+}
+
+// readProcSamples reads cpu time and rss of every process, processes
+// exiting during the scan are skipped
+func readProcSamples() ([]procSample, error) {
+	pids, err := listPids()
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]procSample, 0, len(pids))
+	for _, pid := range pids {
+		s, err := readProcSample(pid)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				collectorLog("TopProcMetrics").Debugf("skip process %d: %s", pid, err)
+			}
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func readProcSample(pid int) (s procSample, err error) {
+	stat, err := ioutil.ReadFile(pidPath(pid, "stat"))
+	if err != nil {
+		return
+	}
+	if s, err = parseProcPidStat(stat); err != nil {
+		return
+	}
+	s.Pid = pid
+	status, err := ioutil.ReadFile(pidPath(pid, "status"))
+	if err != nil {
+		return
+	}
+	// kernel threads have no VmRSS
+	kb, _ := parseKBField(string(status), "VmRSS:")
+	s.RSS = kb * 1024
+	return s, nil
+}
+
+// parseProcPidStat reads comm, utime and stime of /proc/<pid>/stat, comm
+// may contain spaces and parentheses so the fields follow the last ')'
+func parseProcPidStat(stat []byte) (s procSample, err error) {
+	start, end := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return s, fmt.Errorf("invalid stat content: %q", stat)
+	}
+	s.Comm = string(stat[start+1 : end])
+	// fields[0] is the state, field 3 of proc(5); utime and stime are 14 and 15
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 13 {
+		return s, fmt.Errorf("invalid stat content: %q", stat)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return
+	}
+	s.Ticks = utime + stime
+	return s, nil
+}
@@ -0,0 +1,90 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func writeProcFixture(t *testing.T, dir string, pid int, stat, status string) {
+	pidDir := filepath.Join(dir, strconv.Itoa(pid))
+	if err := os.MkdirAll(pidDir, 0755); err != nil {
+		t.Fatalf("create proc fixture fatal: %s", err)
+	}
+	ioutil.WriteFile(filepath.Join(pidDir, "stat"), []byte(stat), 0644)
+	if status != "" {
+		ioutil.WriteFile(filepath.Join(pidDir, "status"), []byte(status), 0644)
+	}
+}
+
+func Test_parseProcPidStat(t *testing.T) {
+	s, err := parseProcPidStat([]byte("42 (tmux: server) S 1 42 42 0 -1 4194560 1000 0 0 0 150 50 0 0 20 0 1 0 100 1000000 200 18446744073709551615\n"))
+	if err != nil {
+		t.Fatalf("parse pid stat fatal: %s", err)
+	}
+	if s.Comm != "tmux: server" || s.Ticks != 200 {
+		t.Fatalf("parse pid stat fatal: %+v", s)
+	}
+	if _, err := parseProcPidStat([]byte("42 (broken")); err == nil {
+		t.Fatalf("parse invalid pid stat should fail")
+	}
+}
+
+func Test_topProcMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create tmp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	old := procRoot
+	defer func() { procRoot = old }()
+	procRoot = dir
+
+	stat := "%d (%s) S 1 1 1 0 -1 0 0 0 0 0 %d 0 0 0 20 0 1 0 100 0 0\n"
+	writeProcFixture(t, dir, 1, fmt.Sprintf(stat, 1, "init", 100), "VmRSS:\t    4000 kB\n")
+	writeProcFixture(t, dir, 2, fmt.Sprintf(stat, 2, "kthreadd", 0), "Name:\tkthreadd\n")
+	writeProcFixture(t, dir, 300, fmt.Sprintf(stat, 300, "java", 1000), "VmRSS:\t 2000000 kB\n")
+	// exited between listing and reading status
+	writeProcFixture(t, dir, 400, fmt.Sprintf(stat, 400, "sh", 10), "")
+
+	samples, err := readProcSamples()
+	if err != nil || len(samples) != 3 {
+		t.Fatalf("read proc samples fatal: %d %v", len(samples), err)
+	}
+
+	rates := common.NewRateTracker()
+	L := topProcMetrics(rates, samples, 100, 2)
+	if len(L) != 2 || L[0].Name != "proc.mem.rss" || L[0].Tags["comm"] != "java" || L[0].Value.(uint64) != 2000000*1024 {
+		t.Fatalf("top proc of first sample fatal: %d", len(L))
+	}
+
+	writeProcFixture(t, dir, 1, fmt.Sprintf(stat, 1, "init", 110), "VmRSS:\t    4000 kB\n")
+	writeProcFixture(t, dir, 300, fmt.Sprintf(stat, 300, "java", 1500), "VmRSS:\t 2000000 kB\n")
+	samples, _ = readProcSamples()
+	L = topProcMetrics(rates, samples, 110, 2)
+	if len(L) != 4 {
+		t.Fatalf("top proc fatal: %d", len(L))
+	}
+	if L[0].Name != "proc.cpu.percent" || L[0].Tags["pid"] != "300" || L[0].Value.(float64) != 50 {
+		t.Fatalf("top proc cpu fatal: %s", L[0].String())
+	}
+	if L[1].Tags["pid"] != "1" || L[1].Value.(float64) != 1 {
+		t.Fatalf("top proc cpu fatal: %s", L[1].String())
+	}
+
+	// java exited, its series is dropped
+	if rates.Len() != 3 {
+		t.Fatalf("tracked processes fatal: %d", rates.Len())
+	}
+	os.RemoveAll(filepath.Join(dir, "300"))
+	samples, _ = readProcSamples()
+	topProcMetrics(rates, samples, 120, 2)
+	if rates.Len() != 2 {
+		t.Fatalf("exited process not pruned: %d", rates.Len())
+	}
+}
@@ -0,0 +1,29 @@
+package sysinfo
+
+import (
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+func init() {
+	RegisterFunc(common.TYPE_TIME, "UptimeMetrics", UptimeMetrics)
+}
+
+// UptimeMetrics report the seconds since boot and the boot time, a drop of
+// kernel.uptime.seconds means the host rebooted
+func UptimeMetrics() (L []*common.Metric) {
+	up, err := common.Uptime()
+	if err != nil {
+		collectorLog("UptimeMetrics").Errorf("failed to read uptime: %s", err)
+		return collectorError("UptimeMetrics", errReasonRead)
+	}
+	return uptimeMetrics(up, time.Now())
+}
+
+func uptimeMetrics(up float64, now time.Time) (L []*common.Metric) {
+	boot := now.Add(-time.Duration(up * float64(time.Second)))
+	L = append(L, toMetric("kernel.uptime.seconds", int64(up), nil))
+	L = append(L, toMetric("kernel.boottime", boot.Unix(), nil))
+	return
+}
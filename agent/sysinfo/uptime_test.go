@@ -0,0 +1,20 @@
+package sysinfo
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_uptimeMetrics(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	L := uptimeMetrics(350735.47, now)
+	if len(L) != 2 {
+		t.Fatalf("uptime metrics fatal: %d", len(L))
+	}
+	if L[0].Name != "kernel.uptime.seconds" || L[0].Value.(int64) != 350735 {
+		t.Fatalf("uptime metric fatal: %s", L[0].String())
+	}
+	if L[1].Name != "kernel.boottime" || L[1].Value.(int64) != 1500000000-350736 {
+		t.Fatalf("boot time metric fatal: %s", L[1].String())
+	}
+}
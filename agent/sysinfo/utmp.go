@@ -0,0 +1,215 @@
+package sysinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// ut_type values, see utmp(5)
+const (
+	BootTime     = 2
+	LoginProcess = 6
+	UserProcess  = 7
+	DeadProcess  = 8
+)
+
+// size of a glibc struct utmp on Linux
+const utmpSize = 384
+
+// default window of recent logins reported by WtmpMetrics
+const defaultLoginWindow = 5 * time.Minute
+
+// loginWindow returns the configured login window, it should not be
+// shorter than the LOGIN collect interval or logins are missed
+func loginWindow() time.Duration {
+	if common.Conf != nil && common.Conf.LoginWindow > 0 {
+		return time.Duration(common.Conf.LoginWindow) * time.Second
+	}
+	return defaultLoginWindow
+}
+
+// Utmp is the raw glibc struct utmp record found in utmp and wtmp
+type Utmp struct {
+	Type    int16
+	Pad     [2]byte
+	Pid     int32
+	Line    [32]byte
+	Id      [4]byte
+	User    [32]byte
+	Host    [256]byte
+	Exit    [2]int16
+	Session int32
+	TvSec   int32
+	TvUsec  int32
+	AddrV6  [4]int32
+	Unused  [20]byte
+}
+
+// GoUtmp is an Utmp record with Go types
+type GoUtmp struct {
+	Type int
+	Pid  int
+	Line string
+	User string
+	Host string
+	Addr string // remote address, empty for local logins
+	Time time.Time
+}
+
+// NewGoUtmp converts a raw record
+func NewGoUtmp(u *Utmp) *GoUtmp {
+	return &GoUtmp{
+		Type: int(u.Type),
+		Pid:  int(u.Pid),
+		Line: string(u.Line[:getByteLen(u.Line[:])]),
+		User: string(u.User[:getByteLen(u.User[:])]),
+		Host: string(u.Host[:getByteLen(u.Host[:])]),
+		Addr: addrToString(u.AddrV6),
+		Time: time.Unix(int64(u.TvSec), int64(u.TvUsec)*int64(time.Microsecond)),
+	}
+}
+
+// addrToString formats ut_addr_v6, an IPv4 address fills the first word
+// only. The words hold the address bytes in network order, so they are
+// written back little endian the way Read decoded them.
+func addrToString(addr [4]int32) string {
+	if addr == [4]int32{} {
+		return ""
+	}
+	ip := make(net.IP, net.IPv6len)
+	for i, w := range addr {
+		binary.LittleEndian.PutUint32(ip[i*4:], uint32(w))
+	}
+	if addr[1] == 0 && addr[2] == 0 && addr[3] == 0 {
+		return ip[:net.IPv4len].String()
+	}
+	return ip.String()
+}
+
+// Read reads utmp records until EOF, a trailing partial record is ignored
+func Read(r io.Reader) ([]*Utmp, error) {
+	var us []*Utmp
+	for {
+		u := new(Utmp)
+		err := binary.Read(r, binary.LittleEndian, u)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return us, nil
+		}
+		if err != nil {
+			return us, err
+		}
+		us = append(us, u)
+	}
+}
+
+// getByteLen returns the length of a NUL terminated C string
+func getByteLen(b []byte) int {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return i
+	}
+	return len(b)
+}
+
+// readUtmpFile reads all records of an utmp format file
+func readUtmpFile(path string) ([]*Utmp, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// utmpTail remembers how far an append only utmp file (wtmp, btmp) was
+// read, so each read returns the new records only
+type utmpTail struct {
+	offset int64
+}
+
+func (t *utmpTail) read(path string) ([]*Utmp, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < t.offset {
+		// rotated
+		t.offset = 0
+	}
+	if _, err = f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	us, err := Read(f)
+	t.offset += int64(len(us)) * utmpSize
+	return us, err
+}
+
+// loggedInMetrics report active user sessions and distinct users of utmp
+func loggedInMetrics(us []*Utmp) (L []*common.Metric) {
+	sessions := 0
+	users := make(map[string]struct{})
+	for _, u := range us {
+		if u.Type != UserProcess {
+			continue
+		}
+		sessions++
+		users[NewGoUtmp(u).User] = struct{}{}
+	}
+	L = append(L, toMetric("kernel.users.logged_in", sessions, nil))
+	L = append(L, toMetric("kernel.users.logged_in.distinct", len(users), nil))
+	return
+}
+
+// loginMetrics report user logins not older than window
+func loginMetrics(us []*Utmp, now time.Time, window time.Duration) (L []*common.Metric) {
+	return recentLoginMetrics("kernel.user.login", us, now, window, UserProcess)
+}
+
+// failedLoginMetrics report btmp records not older than window, depending
+// on the program the failed attempt is logged as a login or user process
+func failedLoginMetrics(us []*Utmp, now time.Time, window time.Duration) (L []*common.Metric) {
+	return recentLoginMetrics("kernel.user.login.failed", us, now, window, LoginProcess, UserProcess)
+}
+
+func recentLoginMetrics(name string, us []*Utmp, now time.Time, window time.Duration, types ...int16) (L []*common.Metric) {
+	for _, u := range us {
+		if !hasUtmpType(u, types) {
+			continue
+		}
+		gu := NewGoUtmp(u)
+		if gu.Time.Before(now.Add(-window)) || gu.Time.After(now) {
+			continue
+		}
+		host := gu.Host
+		if host == "" {
+			host = gu.Addr
+		}
+		if host == "" {
+			host = "local"
+		}
+		m := toMetric(name, 1, map[string]string{"user": gu.User, "host": host})
+		m.Timestamp = gu.Time.Unix()
+		L = append(L, m)
+	}
+	return
+}
+
+func hasUtmpType(u *Utmp, types []int16) bool {
+	for _, t := range types {
+		if u.Type == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package sysinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestUtmp(typ int16, user, host string, t time.Time) *Utmp {
+	u := &Utmp{Type: typ, TvSec: int32(t.Unix())}
+	copy(u.User[:], user)
+	copy(u.Host[:], host)
+	return u
+}
+
+func Test_ReadUtmp(t *testing.T) {
+	now := time.Now()
+	records := []*Utmp{
+		newTestUtmp(BootTime, "reboot", "", now),
+		newTestUtmp(UserProcess, "alice", "10.0.0.1", now.Add(-time.Minute)),
+		newTestUtmp(UserProcess, "bob", "10.0.0.2", now.Add(-time.Hour)),
+	}
+	buf := new(bytes.Buffer)
+	for _, u := range records {
+		if err := binary.Write(buf, binary.LittleEndian, u); err != nil {
+			t.Fatalf("write utmp fatal: %s", err)
+		}
+	}
+	if buf.Len() != len(records)*utmpSize {
+		t.Fatalf("utmp record size fatal: %d", buf.Len())
+	}
+
+	us, err := Read(buf)
+	if err != nil || len(us) != len(records) {
+		t.Fatalf("read utmp fatal: %v - %d", err, len(us))
+	}
+
+	L := loginMetrics(us, now, defaultLoginWindow)
+	if len(L) != 1 {
+		t.Fatalf("login metrics fatal: %d", len(L))
+	}
+	m := L[0]
+	if m.Name != "kernel.user.login" || m.Tags["user"] != "alice" || m.Tags["host"] != "10.0.0.1" {
+		t.Fatalf("login metric fatal: %s", m.String())
+	}
+	if m.Timestamp != now.Add(-time.Minute).Unix() {
+		t.Fatalf("login metric timestamp fatal: %d", m.Timestamp)
+	}
+}
+
+func Test_loggedInMetrics(t *testing.T) {
+	now := time.Now()
+	records := []*Utmp{
+		newTestUtmp(BootTime, "reboot", "", now),
+		newTestUtmp(UserProcess, "alice", "10.0.0.1", now),
+		newTestUtmp(UserProcess, "alice", "10.0.0.2", now),
+		newTestUtmp(UserProcess, "bob", "", now),
+		newTestUtmp(DeadProcess, "", "", now),
+	}
+	buf := new(bytes.Buffer)
+	for _, u := range records {
+		binary.Write(buf, binary.LittleEndian, u)
+	}
+	us, err := Read(buf)
+	if err != nil {
+		t.Fatalf("read utmp fatal: %s", err)
+	}
+
+	L := loggedInMetrics(us)
+	if len(L) != 2 || L[0].Value.(int) != 3 || L[1].Value.(int) != 2 {
+		t.Fatalf("logged in metrics fatal: %v", L)
+	}
+}
+
+func Test_loginMetricsWindow(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	window := 10 * time.Minute
+	us := []*Utmp{
+		newTestUtmp(UserProcess, "edge", "", now.Add(-window)),
+		newTestUtmp(UserProcess, "old", "", now.Add(-window-time.Second)),
+		newTestUtmp(UserProcess, "future", "", now.Add(time.Second)),
+		newTestUtmp(UserProcess, "now", "", now),
+	}
+	L := loginMetrics(us, now, window)
+	if len(L) != 2 || L[0].Tags["user"] != "edge" || L[1].Tags["user"] != "now" {
+		t.Fatalf("login window fatal: %v", L)
+	}
+}
+
+func Test_failedLoginMetrics(t *testing.T) {
+	now := time.Now()
+	us := []*Utmp{
+		newTestUtmp(LoginProcess, "root", "203.0.113.5", now.Add(-time.Minute)),
+		newTestUtmp(UserProcess, "admin", "203.0.113.6", now.Add(-2*time.Minute)),
+		newTestUtmp(LoginProcess, "root", "203.0.113.5", now.Add(-time.Hour)),
+		newTestUtmp(BootTime, "reboot", "", now),
+	}
+	L := failedLoginMetrics(us, now, defaultLoginWindow)
+	if len(L) != 2 {
+		t.Fatalf("failed login metrics fatal: %d", len(L))
+	}
+	for _, m := range L {
+		if m.Name != "kernel.user.login.failed" || m.Tags["host"] == "" {
+			t.Fatalf("failed login metric fatal: %s", m.String())
+		}
+	}
+}
+
+func Test_utmpTail(t *testing.T) {
+	f, err := ioutil.TempFile("", "wtmp-test-")
+	if err != nil {
+		t.Fatalf("create tmp file fatal: %s", err)
+	}
+	defer os.Remove(f.Name())
+	now := time.Now()
+	binary.Write(f, binary.LittleEndian, newTestUtmp(UserProcess, "alice", "", now))
+
+	tail := new(utmpTail)
+	if us, err := tail.read(f.Name()); err != nil || len(us) != 1 {
+		t.Fatalf("first tail read fatal: %v - %d", err, len(us))
+	}
+	binary.Write(f, binary.LittleEndian, newTestUtmp(UserProcess, "bob", "", now))
+	f.Close()
+	us, err := tail.read(f.Name())
+	if err != nil || len(us) != 1 || NewGoUtmp(us[0]).User != "bob" {
+		t.Fatalf("second tail read fatal: %v - %d", err, len(us))
+	}
+}
+
+func Test_addrToString(t *testing.T) {
+	word := func(b ...byte) int32 { return int32(binary.LittleEndian.Uint32(b)) }
+	tests := []struct {
+		addr [4]int32
+		want string
+	}{
+		{[4]int32{}, ""},
+		{[4]int32{word(192, 168, 1, 20)}, "192.168.1.20"},
+		{[4]int32{word(10, 0, 0, 1)}, "10.0.0.1"},
+		// 2001:db8::1 with "::" compression and no leading zeros
+		{[4]int32{word(0x20, 0x01, 0x0d, 0xb8), 0, 0, word(0, 0, 0, 1)}, "2001:db8::1"},
+		{[4]int32{word(0xfe, 0x80, 0, 0), 0, word(0x02, 0x16, 0x3e, 0xff), word(0xfe, 0x12, 0x00, 0x34)}, "fe80::216:3eff:fe12:34"},
+	}
+	for _, tt := range tests {
+		if got := addrToString(tt.addr); got != tt.want {
+			t.Fatalf("addr to string fatal: %v - %s, want %s", tt.addr, got, tt.want)
+		}
+	}
+
+	// the address stands in for an empty host field
+	u := newTestUtmp(UserProcess, "carol", "", time.Now())
+	u.AddrV6 = tests[4].addr
+	L := loginMetrics([]*Utmp{u, newTestUtmp(UserProcess, "dave", "", time.Now())}, time.Now(), defaultLoginWindow)
+	if len(L) != 2 || L[0].Tags["host"] != "fe80::216:3eff:fe12:34" || L[1].Tags["host"] != "local" {
+		t.Fatalf("login host from addr fatal: %v", L)
+	}
+}
@@ -0,0 +1,67 @@
+package sysinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// vmstat counters reported as pages per second, swapping and major faults
+// show memory pressure the swap used gauge lags behind
+var swapVmstatMetrics = []struct {
+	key  string
+	name string
+}{
+	{"pswpin", "mem.swap.in.rate"},
+	{"pswpout", "mem.swap.out.rate"},
+	{"pgmajfault", "mem.majfault.rate"},
+}
+
+var swapRates = common.NewRateTracker()
+
+func init() {
+	RegisterFunc(common.TYPE_MEM, "SwapActivityMetrics", SwapActivityMetrics)
+}
+
+// SwapActivityMetrics report the pages swapped in and out and the major
+// page faults per second
+func SwapActivityMetrics() []*common.Metric {
+	content, err := ioutil.ReadFile(procPath("vmstat"))
+	if err != nil {
+		collectorLog("SwapActivityMetrics").Errorf("failed to read vmstat: %s", err)
+		return collectorError("SwapActivityMetrics", errReasonRead)
+	}
+	return swapActivityMetrics(swapRates, parseVmstat(string(content)), common.RateNow())
+}
+
+func swapActivityMetrics(rates *common.RateTracker, vmstat map[string]uint64, now float64) (L []*common.Metric) {
+	for _, m := range swapVmstatMetrics {
+		value, ok := vmstat[m.key]
+		if !ok {
+			continue
+		}
+		if rate, ok := rates.RateAt(m.name, nil, value, now); ok {
+			L = append(L, toMetric(m.name, common.SetPrecision(rate, 2), nil))
+		}
+	}
+	return
+}
+
+// parseVmstat parses the "name value" lines of /proc/vmstat
+func parseVmstat(content string) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[fields[0]] = v
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+const vmstatSample = `nr_free_pages 123456
+pgpgin 1000
+pgpgout 2000
+pswpin 500
+pswpout 1000
+pgfault 900000
+pgmajfault 300
+`
+
+const vmstatSample2 = `nr_free_pages 120000
+pgpgin 1100
+pgpgout 2100
+pswpin 1500
+pswpout 1000
+pgfault 910000
+pgmajfault 350
+`
+
+func Test_swapActivityMetrics(t *testing.T) {
+	vmstat := parseVmstat(vmstatSample)
+	if vmstat["pswpin"] != 500 || vmstat["pgmajfault"] != 300 || len(vmstat) != 7 {
+		t.Fatalf("parse vmstat fatal: %v", vmstat)
+	}
+
+	rates := common.NewRateTracker()
+	if L := swapActivityMetrics(rates, vmstat, 100); len(L) != 0 {
+		t.Fatalf("swap rate of first sample fatal: %d", len(L))
+	}
+	check := func(L []*common.Metric, want map[string]float64) {
+		if len(L) != len(want) {
+			t.Fatalf("swap rates fatal: %d", len(L))
+		}
+		for _, m := range L {
+			if v, ok := want[m.Name]; !ok || m.Value.(float64) != v {
+				t.Fatalf("swap rate %s fatal: %v, want %v", m.Name, m.Value, v)
+			}
+		}
+	}
+	check(swapActivityMetrics(rates, parseVmstat(vmstatSample2), 110), map[string]float64{
+		"mem.swap.in.rate":  100,
+		"mem.swap.out.rate": 0,
+		"mem.majfault.rate": 5,
+	})
+	// counters reset by a reboot yield 0, then count from the new base
+	check(swapActivityMetrics(rates, parseVmstat(vmstatSample), 120), map[string]float64{
+		"mem.swap.in.rate":  0,
+		"mem.swap.out.rate": 0,
+		"mem.majfault.rate": 0,
+	})
+	check(swapActivityMetrics(rates, parseVmstat(vmstatSample2), 130), map[string]float64{
+		"mem.swap.in.rate":  100,
+		"mem.swap.out.rate": 0,
+		"mem.majfault.rate": 5,
+	})
+}
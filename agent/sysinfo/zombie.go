@@ -0,0 +1,70 @@
+package sysinfo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lodastack/agent/agent/common"
+)
+
+// default number of zombies above which they are attributed to their parent
+const defaultZombieThreshold = 10
+
+func zombieThreshold() int {
+	if common.Conf != nil && common.Conf.ZombieThreshold > 0 {
+		return common.Conf.ZombieThreshold
+	}
+	return defaultZombieThreshold
+}
+
+// zombieParentMetrics report the zombies per parent process, the parent is
+// the one failing to reap them
+func zombieParentMetrics() (L []*common.Metric) {
+	pids, err := listPids()
+	if err != nil {
+		collectorLog("PsMetrics").Errorf("failed to list processes: %s", err)
+		return
+	}
+	parents := make(map[int]int)
+	for _, pid := range pids {
+		stat, err := ioutil.ReadFile(pidPath(pid, "stat"))
+		if err != nil {
+			// process exited or was reaped
+			continue
+		}
+		if ppid, ok := zombieParent(stat); ok {
+			parents[ppid]++
+		}
+	}
+	for ppid, count := range parents {
+		pcomm := "unknown"
+		if stat, err := ioutil.ReadFile(pidPath(ppid, "stat")); err == nil {
+			if s, err := parseProcPidStat(stat); err == nil {
+				pcomm = s.Comm
+			}
+		}
+		tags := map[string]string{"ppid": strconv.Itoa(ppid), "pcomm": pcomm}
+		L = append(L, toMetric("proc.zombie.count", count, tags))
+	}
+	return
+}
+
+// zombieParent returns the ppid of a process in Z state, the state and ppid
+// are the first two fields after the last ')' of /proc/<pid>/stat
+func zombieParent(stat []byte) (int, bool) {
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 2 || fields[0] != "Z" {
+		return 0, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, false
+	}
+	return ppid, true
+}
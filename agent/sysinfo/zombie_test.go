@@ -0,0 +1,48 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func Test_zombieParentMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc-test-")
+	if err != nil {
+		t.Fatalf("create temp dir fatal: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	old := procRoot
+	defer func() { procRoot = old }()
+	procRoot = dir
+
+	const stat = "%d (%s) %s %d 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 100 0 0\n"
+	writeProcFixture(t, dir, 1, fmt.Sprintf(stat, 1, "init", "S", 0), "")
+	writeProcFixture(t, dir, 100, fmt.Sprintf(stat, 100, "bad parent", "S", 1), "")
+	for _, pid := range []int{101, 102, 103} {
+		writeProcFixture(t, dir, pid, fmt.Sprintf(stat, pid, "worker", "Z", 100), "")
+	}
+	writeProcFixture(t, dir, 104, fmt.Sprintf(stat, 104, "worker", "S", 100), "")
+	// zombie whose parent exited meanwhile
+	writeProcFixture(t, dir, 201, fmt.Sprintf(stat, 201, "orphan", "Z", 200), "")
+
+	L := zombieParentMetrics()
+	if len(L) != 2 {
+		t.Fatalf("zombie parent metrics fatal: %d metrics", len(L))
+	}
+	for _, m := range L {
+		switch m.Tags["ppid"] {
+		case "100":
+			if m.Tags["pcomm"] != "bad parent" || m.Value.(int) != 3 {
+				t.Fatalf("zombie parent fatal: %s", m.String())
+			}
+		case "200":
+			if m.Tags["pcomm"] != "unknown" || m.Value.(int) != 1 {
+				t.Fatalf("zombie of exited parent fatal: %s", m.String())
+			}
+		default:
+			t.Fatalf("unexpected zombie parent: %s", m.String())
+		}
+	}
+}
@@ -39,6 +39,15 @@ var CmdStart = cli.Command{
 			Value: "",
 			Usage: "Memory pprof file",
 		},
+		cli.BoolFlag{
+			Name:  "once",
+			Usage: "collect twice 2s apart, so rates have two samples, print the metrics of the second cycle to stdout and exit",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: agent.FormatJSON,
+			Usage: "output format of -once: json or prometheus",
+		},
 	},
 }
 
@@ -51,6 +60,15 @@ func runStart(c *cli.Context) {
 	//init log setting
 	initLog()
 
+	if c.Bool("once") {
+		err := agent.Once(&config.C.Agent, os.Stdout, c.String("format"))
+		logBackend.Flush()
+		if err != nil {
+			log.Fatalf("collect once failed: %s", err.Error())
+		}
+		return
+	}
+
 	//start agent module
 	a, err := agent.New(config.C)
 	if err != nil {